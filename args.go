@@ -2,10 +2,40 @@ package cobra
 
 import (
 	"fmt"
+	"strings"
 )
 
 type PositionalArgs func(cmd *Command, args []string) error
 
+// ArgsPolicy defines how the arguments of commands which do not set Args are validated.
+// It is set on the root command and applies to the whole tree.
+type ArgsPolicy int
+
+const (
+	// LegacyArgsPolicy validates arguments with the legacy behaviour described below.
+	LegacyArgsPolicy ArgsPolicy = iota
+	// ArbitraryArgsPolicy accepts arbitrary arguments on every command.
+	ArbitraryArgsPolicy
+	// StrictArgsPolicy makes every command with subcommands reject arguments which are
+	// not subcommands, while commands without subcommands accept arbitrary arguments.
+	StrictArgsPolicy
+)
+
+// validate validates args of cmd according to the policy.
+func (p ArgsPolicy) validate(cmd *Command, args []string) error {
+	switch p {
+	case ArbitraryArgsPolicy:
+		return nil
+	case StrictArgsPolicy:
+		if cmd.HasSubCommands() && len(args) > 0 {
+			return fmt.Errorf("unknown command %q for %q%s", args[0], cmd.CommandPath(), cmd.findSuggestions(args[0]))
+		}
+		return nil
+	default:
+		return legacyArgs(cmd, args)
+	}
+}
+
 // Legacy arg validation has the following behaviour:
 // - root commands with no subcommands can take arbitrary arguments
 // - root commands with subcommands will do subcommand validity checking
@@ -99,3 +129,23 @@ func RangeArgs(min int, max int) PositionalArgs {
 		return nil
 	}
 }
+
+// RequireSubcommand returns a validator making a command with subcommands require one
+// of them: it returns an error listing the subcommands when none is given, and an
+// unknown command error for other arguments. It replaces the help displayed when a
+// command with subcommands is invoked bare, and can be set on runnable commands, e.g.
+// a root running persistent hooks.
+func RequireSubcommand() PositionalArgs {
+	return func(cmd *Command, args []string) error {
+		if len(args) > 0 {
+			return fmt.Errorf("unknown command %q for %q%s", args[0], cmd.CommandPath(), cmd.findSuggestions(args[0]))
+		}
+		var names []string
+		for _, sub := range cmd.Commands() {
+			if sub.IsAvailableCommand() && !sub.IsHelpCommand() {
+				names = append(names, sub.Name())
+			}
+		}
+		return fmt.Errorf("missing subcommand for %q, expected one of: %s", cmd.CommandPath(), strings.Join(names, ", "))
+	}
+}
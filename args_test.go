@@ -285,3 +285,68 @@ func TestChildTakesArgs(t *testing.T) {
 		t.Fatalf("Unexpected error: %v", err)
 	}
 }
+
+func TestArgsPolicy(t *testing.T) {
+	newTree := func(policy ArgsPolicy) *Command {
+		rootCmd := &Command{Use: "root", ArgsPolicy: policy, Run: emptyRun}
+		childCmd := &Command{Use: "child", Run: emptyRun}
+		childCmd.AddCommand(&Command{Use: "grandchild", Run: emptyRun})
+		rootCmd.AddCommand(childCmd)
+		return rootCmd
+	}
+
+	testcases := []struct {
+		policy ArgsPolicy
+		args   []string
+		err    string
+	}{
+		{LegacyArgsPolicy, []string{"unknown"}, `unknown command "unknown" for "root"`},
+		{LegacyArgsPolicy, []string{"child", "unknown"}, ""},
+		{ArbitraryArgsPolicy, []string{"unknown"}, ""},
+		{ArbitraryArgsPolicy, []string{"child", "unknown"}, ""},
+		{StrictArgsPolicy, []string{"unknown"}, `unknown command "unknown" for "root"`},
+		{StrictArgsPolicy, []string{"child", "unknown"}, `unknown command "unknown" for "root child"`},
+		{StrictArgsPolicy, []string{"child", "grandchild", "arg"}, ""},
+	}
+	for _, tc := range testcases {
+		_, err := executeCommand(newTree(tc.policy), tc.args...)
+		if tc.err == "" && err != nil {
+			t.Errorf("policy %d, %v: unexpected error: %v", tc.policy, tc.args, err)
+		}
+		if tc.err != "" && (err == nil || !strings.Contains(err.Error(), tc.err)) {
+			t.Errorf("policy %d, %v: expected error %q, got %v", tc.policy, tc.args, tc.err, err)
+		}
+	}
+}
+
+func TestRequireSubcommand(t *testing.T) {
+	newTree := func() *Command {
+		rootCmd := &Command{Use: "root", Args: RequireSubcommand(), Run: emptyRun}
+		groupCmd := &Command{Use: "group", Args: RequireSubcommand()}
+		groupCmd.AddCommand(&Command{Use: "list", Run: emptyRun}, &Command{Use: "create", Run: emptyRun})
+		rootCmd.AddCommand(groupCmd, &Command{Use: "hidden", Hidden: true, Run: emptyRun})
+		return rootCmd
+	}
+
+	testcases := []struct {
+		args []string
+		err  string
+	}{
+		{nil, `missing subcommand for "root", expected one of: group`},
+		{[]string{"group"}, `missing subcommand for "root group", expected one of: create, list`},
+		{[]string{"group", "lst"}, "unknown command \"lst\" for \"root group\"\n\nDid you mean this?\n\tlist\n"},
+		{[]string{"group", "list"}, ""},
+	}
+	for _, tc := range testcases {
+		output, err := executeCommand(newTree(), tc.args...)
+		if tc.err == "" && err != nil {
+			t.Errorf("%v: unexpected error: %v", tc.args, err)
+		}
+		if tc.err != "" && (err == nil || err.Error() != tc.err) {
+			t.Errorf("%v: expected error %q, got %v", tc.args, tc.err, err)
+		}
+		if tc.err != "" {
+			checkStringContains(t, output, "Error: "+tc.err)
+		}
+	}
+}
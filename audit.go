@@ -0,0 +1,194 @@
+package cobra
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/pflag"
+)
+
+// FlagSensitive is the annotation of flags holding secrets, like tokens or passwords.
+const FlagSensitive = "cobra_annotation_flag_sensitive"
+
+// RedactedValue replaces the value of sensitive flags.
+const RedactedValue = "<redacted>"
+
+// Invocation describes an execution of a command, as given to the function set by SetAuditFunc.
+type Invocation struct {
+	// CommandPath is the full path of the executed command.
+	CommandPath string
+	// Flags holds the values of the flags set on the command line, by flag name.
+	// The values of sensitive flags are replaced by RedactedValue.
+	Flags map[string]string
+	// Args holds the positional arguments.
+	Args []string
+	// Err is the error returned by the execution, if any. Displaying the help is not
+	// an error.
+	Err error
+	// ExitCode is the exit code suggested for the execution, like the one of the Result
+	// returned by ExecuteResult.
+	ExitCode int
+}
+
+// MarkFlagSensitive adds the FlagSensitive annotation to the named flag if it exists,
+// so that its value is redacted where cobra reports flag values.
+func (c *Command) MarkFlagSensitive(name string) error {
+	return MarkFlagSensitive(c.Flags(), name)
+}
+
+// MarkPersistentFlagSensitive adds the FlagSensitive annotation to the named persistent
+// flag if it exists, so that its value is redacted where cobra reports flag values.
+func (c *Command) MarkPersistentFlagSensitive(name string) error {
+	return MarkFlagSensitive(c.PersistentFlags(), name)
+}
+
+// MarkFlagSensitive adds the FlagSensitive annotation to the named flag if it exists,
+// so that its value is redacted where cobra reports flag values.
+func MarkFlagSensitive(flags *pflag.FlagSet, name string) error {
+	return flags.SetAnnotation(name, FlagSensitive, []string{"true"})
+}
+
+// isFlagSensitive returns true if the flag is annotated with FlagSensitive.
+func isFlagSensitive(f *pflag.Flag) bool {
+	return f != nil && len(f.Annotations[FlagSensitive]) > 0
+}
+
+// redactFlag returns a copy of the flag with its default value redacted if the flag
+// is sensitive, or the flag itself otherwise.
+func redactFlag(f *pflag.Flag) *pflag.Flag {
+	if !isFlagSensitive(f) || f.DefValue == "" {
+		return f
+	}
+	redacted := *f
+	redacted.DefValue = RedactedValue
+	return &redacted
+}
+
+// flagValueString returns the value of the flag, redacted if the flag is sensitive.
+func flagValueString(f *pflag.Flag) string {
+	if isFlagSensitive(f) {
+		return RedactedValue
+	}
+	return f.Value.String()
+}
+
+// redactFlagError replaces the values given to sensitive flags in args where they are
+// quoted by the flag parsing error err, as in `invalid argument "value" for`, leaving
+// the rest of the message alone.
+func (c *Command) redactFlagError(err error, args []string) error {
+	msg := err.Error()
+	redacted := msg
+	for _, value := range c.sensitiveArgValues(args) {
+		redacted = strings.Replace(redacted, strconv.Quote(value), strconv.Quote(RedactedValue), -1)
+	}
+	if redacted == msg {
+		return err
+	}
+	return errors.New(redacted)
+}
+
+// sensitiveArgValues returns the values given to the sensitive flags of c in args.
+func (c *Command) sensitiveArgValues(args []string) []string {
+	var values []string
+	c.visitSensitiveArgs(args, func(i, offset int) {
+		values = append(values, args[i][offset:])
+	})
+	return values
+}
+
+// redactSensitiveArgs returns a copy of args with the values given to the sensitive
+// flags of c replaced by RedactedValue, leaving the other arguments, and the flags
+// themselves, unchanged.
+func (c *Command) redactSensitiveArgs(args []string) []string {
+	redacted := append([]string(nil), args...)
+	c.visitSensitiveArgs(args, func(i, offset int) {
+		redacted[i] = args[i][:offset] + RedactedValue
+	})
+	return redacted
+}
+
+// visitSensitiveArgs calls visit with the position of each non-empty value given to a
+// sensitive flag of c in args: the index of the argument holding it, and the offset of
+// the value in that argument, e.g. after "--token=".
+func (c *Command) visitSensitiveArgs(args []string, visit func(i, offset int)) {
+	flags := c.Flags()
+	for i, arg := range args {
+		if arg == "--" {
+			break
+		}
+		if len(arg) < 2 || arg[0] != '-' {
+			continue
+		}
+		var f *pflag.Flag
+		offset, hasValue := 0, false
+		if arg[1] == '-' {
+			name := arg[2:]
+			if j := strings.Index(name, "="); j >= 0 {
+				name, offset, hasValue = name[:j], 2+j+1, true
+			}
+			f = flags.Lookup(name)
+		} else {
+			// Only the last shorthand of a group can take a value.
+			for j := 1; j < len(arg); j++ {
+				f = flags.ShorthandLookup(arg[j : j+1])
+				if f != nil && f.NoOptDefVal == "" {
+					offset, hasValue = j+1, j+1 < len(arg)
+					if hasValue && arg[offset] == '=' {
+						offset++
+					}
+					break
+				}
+			}
+		}
+		if !isFlagSensitive(f) {
+			continue
+		}
+		switch {
+		case hasValue && offset < len(arg):
+			visit(i, offset)
+		case !hasValue && f.NoOptDefVal == "" && i+1 < len(args) && args[i+1] != "":
+			visit(i+1, 0)
+		}
+	}
+}
+
+// SetAuditFunc sets the function called with the invocation once a command has been
+// executed, e.g. to write an audit trail. It applies to the subcommands of c too.
+func (c *Command) SetAuditFunc(f func(*Invocation)) {
+	c.auditFunc = f
+}
+
+// AuditFunc returns either the function set by SetAuditFunc for this command
+// or a parent, or nil.
+func (c *Command) AuditFunc() func(*Invocation) {
+	if c.auditFunc != nil {
+		return c.auditFunc
+	}
+	if c.HasParent() {
+		return c.Parent().AuditFunc()
+	}
+	return nil
+}
+
+// audit reports the execution of c to the audit function, if any.
+func (c *Command) audit(err error) {
+	f := c.AuditFunc()
+	if f == nil {
+		return
+	}
+	if err == pflag.ErrHelp {
+		err = nil
+	}
+	flags := make(map[string]string)
+	c.Flags().Visit(func(fl *pflag.Flag) {
+		flags[fl.Name] = flagValueString(fl)
+	})
+	f(&Invocation{
+		CommandPath: c.CommandPath(),
+		Flags:       flags,
+		Args:        c.Flags().Args(),
+		Err:         err,
+		ExitCode:    exitCode(c, err, err == nil && c.helpDisplayed()),
+	})
+}
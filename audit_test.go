@@ -0,0 +1,171 @@
+package cobra
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestAuditFunc(t *testing.T) {
+	rootCmd := &Command{Use: "root", Run: emptyRun}
+	rootCmd.PersistentFlags().String("token", "", "api token")
+	childCmd := &Command{
+		Use:  "child",
+		RunE: func(*Command, []string) error { return errors.New("failed") },
+	}
+	childCmd.Flags().Int("count", 0, "count")
+	childCmd.Flags().String("name", "", "name")
+	rootCmd.AddCommand(childCmd)
+	rootCmd.SilenceErrors = true
+	rootCmd.SilenceUsage = true
+
+	if err := rootCmd.MarkPersistentFlagSensitive("token"); err != nil {
+		t.Fatal(err)
+	}
+
+	var got *Invocation
+	rootCmd.SetAuditFunc(func(i *Invocation) { got = i })
+
+	_, err := executeCommand(rootCmd, "child", "--token", "s3cr3t", "--count=2", "a", "b")
+	if err == nil {
+		t.Fatal("Expected error")
+	}
+	if got == nil {
+		t.Fatal("Expected audit func to be called")
+	}
+	expected := &Invocation{
+		CommandPath: "root child",
+		Flags:       map[string]string{"token": RedactedValue, "count": "2"},
+		Args:        []string{"a", "b"},
+		Err:         err,
+		ExitCode:    ExitError,
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, got)
+	}
+
+	if _, err := executeCommand(rootCmd, "child", "--count=x"); err == nil || got.ExitCode != ExitUsage {
+		t.Errorf("Expected the usage exit code for invalid flags, got %+v", got)
+	}
+	if _, err := executeCommand(rootCmd, "child", "--help"); err != nil || got.Err != nil || got.ExitCode != ExitOK {
+		t.Errorf("Expected the help not to be reported as a failure, got %+v", got)
+	}
+}
+
+func TestSensitiveFlagRedaction(t *testing.T) {
+	rootCmd := &Command{Use: "root", Run: emptyRun}
+	rootCmd.PersistentFlags().String("token", "default-s3cr3t", "api token")
+	childCmd := &Command{Use: "child", Run: emptyRun}
+	childCmd.Flags().IntP("pin", "p", 1234, "pin code")
+	rootCmd.AddCommand(childCmd)
+
+	if err := rootCmd.MarkPersistentFlagSensitive("token"); err != nil {
+		t.Fatal(err)
+	}
+	if err := childCmd.MarkFlagSensitive("pin"); err != nil {
+		t.Fatal(err)
+	}
+
+	output, err := executeCommand(rootCmd, "child", "--help")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	checkStringOmits(t, output, "default-s3cr3t")
+	checkStringOmits(t, output, "1234")
+	checkStringContains(t, output, `(default "`+RedactedValue+`")`)
+
+	for _, args := range [][]string{
+		{"child", "--pin=98x76"},
+		{"child", "--pin", "98x76"},
+		{"child", "-p98x76"},
+		{"child", "-p", "98x76"},
+	} {
+		output, err = executeCommand(rootCmd, args...)
+		if err == nil {
+			t.Fatalf("Expected error for %v", args)
+		}
+		checkStringOmits(t, err.Error(), "98x76")
+		checkStringOmits(t, output, "98x76")
+		checkStringContains(t, err.Error(), RedactedValue)
+	}
+
+	_, err = executeCommand(rootCmd, "child", "--token", "s3cr3t")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	buf := new(bytes.Buffer)
+	rootCmd.SetOutput(buf)
+	rootCmd.DebugFlags()
+	checkStringOmits(t, buf.String(), "s3cr3t")
+	checkStringOmits(t, buf.String(), "1234")
+}
+
+func TestSensitiveFlagsKeepTheirValues(t *testing.T) {
+	rootCmd := &Command{Use: "root", Run: emptyRun}
+	rootCmd.PersistentFlags().String("token", "default-s3cr3t", "api token")
+	childCmd := &Command{Use: "child", Run: emptyRun}
+	childCmd.Flags().Int("pin", 1234, "pin code")
+	rootCmd.AddCommand(childCmd)
+	rootCmd.MarkPersistentFlagSensitive("token")
+	childCmd.MarkFlagSensitive("pin")
+
+	// Build the flag sets before parsing, as the help of a previous execution would.
+	childCmd.LocalFlags()
+	childCmd.InheritedFlags()
+
+	if _, err := executeCommand(rootCmd, "child", "--pin", "42", "--token", "s3cr3t"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	pin := childCmd.LocalFlags().Lookup("pin")
+	if !pin.Changed || pin.Value.String() != "42" || pin.DefValue != "1234" {
+		t.Errorf("Expected the local pin flag to be set to 42, got changed %v, value %q, default %q", pin.Changed, pin.Value, pin.DefValue)
+	}
+	token := childCmd.InheritedFlags().Lookup("token")
+	if !token.Changed || token.Value.String() != "s3cr3t" {
+		t.Errorf("Expected the inherited token flag to be set to s3cr3t, got changed %v, value %q", token.Changed, token.Value)
+	}
+	checkStringOmits(t, childCmd.LocalFlagUsages(), "1234")
+	checkStringOmits(t, childCmd.InheritedFlagUsages(), "default-s3cr3t")
+}
+
+func TestSensitiveFlagErrorRedactsOnlyTheValue(t *testing.T) {
+	rootCmd := &Command{Use: "root", Run: emptyRun}
+	rootCmd.Flags().Int("pin", 1234, "pin code")
+	rootCmd.MarkFlagSensitive("pin")
+
+	_, err := executeCommand(rootCmd, "--pin", "for")
+	if err == nil {
+		t.Fatal("Expected error")
+	}
+	expected := `invalid argument "` + RedactedValue + `" for "--pin" flag: strconv.ParseInt: parsing "` + RedactedValue + `": invalid syntax`
+	if err.Error() != expected {
+		t.Errorf("Expected error %q, got %q", expected, err.Error())
+	}
+}
+
+func TestRedactSensitiveArgs(t *testing.T) {
+	c := &Command{Use: "c", Run: emptyRun}
+	c.Flags().StringP("token", "t", "", "")
+	c.Flags().BoolP("verbose", "v", false, "")
+	c.Flags().String("env", "", "")
+	c.MarkFlagSensitive("token")
+
+	tests := []struct {
+		args     []string
+		expected []string
+	}{
+		{[]string{"--env", "e", "--token=e"}, []string{"--env", "e", "--token=" + RedactedValue}},
+		{[]string{"--token", "env", "env"}, []string{"--token", RedactedValue, "env"}},
+		{[]string{"-vt", "v"}, []string{"-vt", RedactedValue}},
+		{[]string{"-vtv"}, []string{"-vt" + RedactedValue}},
+		{[]string{"-t=v", "v"}, []string{"-t=" + RedactedValue, "v"}},
+		{[]string{"--token="}, []string{"--token="}},
+		{[]string{"--", "--token", "v"}, []string{"--", "--token", "v"}},
+	}
+	for _, tc := range tests {
+		if got := c.redactSensitiveArgs(tc.args); !reflect.DeepEqual(got, tc.expected) {
+			t.Errorf("Expected %q for %q, got %q", tc.expected, tc.args, got)
+		}
+	}
+}
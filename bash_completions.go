@@ -17,6 +17,7 @@ const (
 	BashCompCustom          = "cobra_annotation_bash_completion_custom"
 	BashCompOneRequiredFlag = "cobra_annotation_bash_completion_one_required_flag"
 	BashCompSubdirsInDir    = "cobra_annotation_bash_completion_subdirs_in_dir"
+	BashCompKeyValue        = "cobra_annotation_bash_completion_key_value"
 )
 
 func writePreamble(buf *bytes.Buffer, name string) {
@@ -37,6 +38,17 @@ __%[1]s_init_completion()
     _get_comp_words_by_ref "$@" cur prev words cword
 }
 
+# Words are not split on colons (see __start), so candidates containing colons,
+# like "cmd:colon" or URLs, must have the part before the last colon of $cur
+# removed as bash only replaces what follows it.
+__%[1]s_ltrim_colon_completions()
+{
+    # available in bash-completion >= 2, not always present on macOS
+    if declare -F __ltrim_colon_completions >/dev/null; then
+        __ltrim_colon_completions "$cur"
+    fi
+}
+
 __%[1]s_index_of_word()
 {
     local w word=$1
@@ -62,6 +74,11 @@ __%[1]s_handle_reply()
 {
     __%[1]s_debug "${FUNCNAME[0]}"
     local comp
+    # the words after -- are passed through, they are not flags nor commands
+    if [[ ${after_dash_dash} -eq 1 ]]; then
+        __%[1]s_handle_dash_dash
+        return
+    fi
     case $cur in
         -*)
             if [[ $(type -t compopt) = "builtin" ]]; then
@@ -73,6 +90,14 @@ __%[1]s_handle_reply()
             else
                 allflags=("${flags[*]} ${two_word_flags[*]}")
             fi
+            # leave out the flags excluded by a mutually exclusive flag already set
+            if [[ ${#excluded_flags[@]} -ne 0 ]]; then
+                local flag remaining=()
+                for flag in ${allflags[*]}; do
+                    __%[1]s_contains_word "${flag}" "${excluded_flags[@]}" || remaining+=("${flag}")
+                done
+                allflags=("${remaining[@]}")
+            fi
             while IFS='' read -r comp; do
                 COMPREPLY+=("$comp")
             done < <(compgen -W "${allflags[*]}" -- "$cur")
@@ -94,6 +119,7 @@ __%[1]s_handle_reply()
                     PREFIX=""
                     cur="${cur#*=}"
                     ${flags_completion[${index}]}
+                    __%[1]s_ltrim_colon_completions
                     if [ -n "${ZSH_VERSION}" ]; then
                         # zsh completion needs --flag= prefix
                         eval "COMPREPLY=( \"\${COMPREPLY[@]/#/${flag}=}\" )"
@@ -104,11 +130,18 @@ __%[1]s_handle_reply()
             ;;
     esac
 
-    # check if we are handling a flag with special work handling
-    local index
-    __%[1]s_index_of_word "${prev}" "${flags_with_completion[@]}"
-    if [[ ${index} -ge 0 ]]; then
+    # check if we are handling a flag with special work handling, flags with an
+    # optional value only take it in the --flag=value form
+    local index flag=${prev}
+    # "=" breaks words, so after "--flag key=" the previous word is "=" and the flag
+    # precedes the key
+    if [[ ${prev} == "=" && ${cword} -ge 3 && ${words[cword-2]} != -* ]]; then
+        flag=${words[cword-3]}
+    fi
+    __%[1]s_index_of_word "${flag}" "${flags_with_completion[@]}"
+    if [[ ${index} -ge 0 ]] && __%[1]s_contains_word "${flag}" "${two_word_flags[@]}"; then
         ${flags_completion[${index}]}
+        __%[1]s_ltrim_colon_completions
         return
     fi
 
@@ -117,6 +150,18 @@ __%[1]s_handle_reply()
         return
     fi
 
+    # complete the previous invocations for the first argument of the history command
+    if [[ -n ${complete_history} && ${#nouns[@]} -eq 0 ]]; then
+        __%[1]s_handle_history
+        return
+    fi
+
+    # complete directories for the positional arguments marked as such
+    if __%[1]s_contains_word "$(( ${#nouns[@]} + 1 ))" "${dirname_args[@]}"; then
+        _filedir -d
+        return
+    fi
+
     local completions
     completions=("${commands[@]}")
     if [[ ${#must_have_one_noun[@]} -ne 0 ]]; then
@@ -145,10 +190,7 @@ __%[1]s_handle_reply()
 		fi
     fi
 
-    # available in bash-completion >= 2, not always present on macOS
-    if declare -F __ltrim_colon_completions >/dev/null; then
-        __ltrim_colon_completions "$cur"
-    fi
+    __%[1]s_ltrim_colon_completions
 
     # If there is only 1 completion and it is a flag with an = it will be completed
     # but we don't want a space after the =
@@ -157,6 +199,17 @@ __%[1]s_handle_reply()
     fi
 }
 
+# The words after -- are completed by __%[1]s_dash_dash_func, if defined
+# in BashCompletionFunction, or as file names.
+__%[1]s_handle_dash_dash()
+{
+    if declare -F __%[1]s_dash_dash_func >/dev/null; then
+        __%[1]s_dash_dash_func
+    else
+        _filedir
+    fi
+}
+
 # The arguments should be in the form "ext1|ext2|extn"
 __%[1]s_handle_filename_extension_flag()
 {
@@ -170,6 +223,99 @@ __%[1]s_handle_subdirs_in_dir_flag()
     pushd "${dir}" >/dev/null 2>&1 && _filedir -d && popd >/dev/null 2>&1 || return
 }
 
+# The argument is the kind of the values, as given to MarkFlagCompletionKind
+__%[1]s_handle_completion_kind_flag()
+{
+    local IFS=$'\n'
+    case $1 in
+        hosts)
+            if declare -F _known_hosts_real >/dev/null; then
+                _known_hosts_real -- "$cur"
+            else
+                COMPREPLY+=( $(compgen -W "$(awk 'tolower($1) == "host" { for (i = 2; i <= NF; i++) if ($i !~ /[*?]/) print $i }' "${HOME}/.ssh/config" 2>/dev/null)" -- "$cur") )
+                COMPREPLY+=( $(compgen -A hostname -- "$cur") )
+            fi
+            ;;
+        urls)
+            COMPREPLY+=( $(compgen -W "$(grep -oE 'https?://[^[:space:]"'\'']+' "${HISTFILE:-${HOME}/.bash_history}" 2>/dev/null | sort -u)" -- "$cur") )
+            ;;
+        env-vars)
+            COMPREPLY+=( $(compgen -e -- "$cur") )
+            ;;
+        users)
+            COMPREPLY+=( $(compgen -u -- "$cur") )
+            ;;
+        groups)
+            COMPREPLY+=( $(compgen -g -- "$cur") )
+            ;;
+        durations)
+            COMPREPLY+=( $(compgen -W "30s 1m 5m 15m 1h 24h" -- "$cur") )
+            ;;
+        timestamps)
+            COMPREPLY+=( $(compgen -W "now today $(date -u +%%Y-%%m-%%dT%%H:%%M:%%SZ)" -- "$cur") )
+            ;;
+    esac
+}
+
+# The first argument is the name of the flag whose recent values are offered, the
+# other arguments are the other completion of the flag, if any, run first
+__%[1]s_handle_recent_values_flag()
+{
+    local flag=$1
+    shift
+    if [[ $# -gt 0 ]]; then
+        "$@"
+    fi
+    local dir="${XDG_CACHE_HOME:-${HOME}/.cache}"
+    if [[ $(uname -s) == Darwin ]]; then
+        dir="${HOME}/Library/Caches"
+    fi
+    local IFS=$'\n'
+    COMPREPLY+=( $(compgen -W "$(cat "${dir}/%[1]s/recent/${flag}" 2>/dev/null)" -- "$cur") )
+}
+
+# Offers the invocations remembered in the history, as whole arguments
+__%[1]s_handle_history()
+{
+    local dir="${XDG_CACHE_HOME:-${HOME}/.cache}" entry
+    if [[ $(uname -s) == Darwin ]]; then
+        dir="${HOME}/Library/Caches"
+    fi
+    while IFS='' read -r entry; do
+        [[ ${entry} == "${cur}"* ]] && COMPREPLY+=("${entry}")
+    done < <(cat "${dir}/%[1]s/history" 2>/dev/null)
+}
+
+# The arguments are the completions of the flag, in the form "key=value" or "key=".
+# The keys are completed first, then the values of the key.
+__%[1]s_handle_key_value_flag()
+{
+    local keys=() entry pair=$cur
+    # the key is a word of its own when "=" breaks words
+    if [[ $prev == "=" ]]; then
+        pair="${words[cword-2]}=${cur}"
+    fi
+    if [[ $pair == *=* ]]; then
+        while IFS='' read -r entry; do
+            # bash only replaces what follows the "=" breaking words
+            if [[ $COMP_WORDBREAKS == *=* ]]; then
+                entry=${entry#*=}
+            fi
+            COMPREPLY+=("$entry")
+        done < <(compgen -W "$*" -- "$pair")
+        return
+    fi
+    for entry in "$@"; do
+        if ! __%[1]s_contains_word "${entry%%%%=*}=" "${keys[@]}"; then
+            keys+=("${entry%%%%=*}=")
+        fi
+    done
+    COMPREPLY+=( $(compgen -W "${keys[*]}" -- "$cur") )
+    if [[ $(type -t compopt) = "builtin" ]]; then
+        compopt -o nospace
+    fi
+}
+
 __%[1]s_handle_flag()
 {
     __%[1]s_debug "${FUNCNAME[0]}: c is $c words[c] is ${words[c]}"
@@ -188,6 +334,14 @@ __%[1]s_handle_flag()
         must_have_one_flag=()
     fi
 
+    # once a flag of a group of mutually exclusive flags is set, don't offer the group
+    local group
+    for group in "${mutually_exclusive_flags[@]}"; do
+        if __%[1]s_contains_word "${flagname}" ${group}; then
+            excluded_flags+=(${group})
+        fi
+    done
+
     # if you set a flag which only applies to this command, don't show subcommands
     if __%[1]s_contains_word "${flagname}" "${local_nonpersistent_flags[@]}"; then
       commands=()
@@ -259,7 +413,12 @@ __%[1]s_handle_word()
         return
     fi
     __%[1]s_debug "${FUNCNAME[0]}: c is $c words[c] is ${words[c]}"
-    if [[ "${words[c]}" == -* ]]; then
+    if [[ ${after_dash_dash} -eq 1 ]]; then
+        __%[1]s_handle_noun
+    elif [[ "${words[c]}" == "--" ]]; then
+        after_dash_dash=1
+        c=$((c+1))
+    elif [[ "${words[c]}" == -* ]]; then
         __%[1]s_handle_flag
     elif __%[1]s_contains_word "${words[c]}" "${commands[@]}"; then
         __%[1]s_handle_command
@@ -290,15 +449,17 @@ func writePostscript(buf *bytes.Buffer, name string) {
     declare -A flaghash 2>/dev/null || :
     declare -A aliashash 2>/dev/null || :
     if declare -F _init_completion >/dev/null 2>&1; then
-        _init_completion -s || return
+        _init_completion -s -n : || return
     else
-        __%[1]s_init_completion -n "=" || return
+        __%[1]s_init_completion -n "=:" || return
     fi
 
     local c=0
     local flags=()
     local two_word_flags=()
     local local_nonpersistent_flags=()
+    local mutually_exclusive_flags=()
+    local excluded_flags=()
     local flags_with_completion=()
     local flags_completion=()
     local commands=("%[1]s")
@@ -306,6 +467,9 @@ func writePostscript(buf *bytes.Buffer, name string) {
     local must_have_one_noun=()
     local last_command
     local nouns=()
+    local after_dash_dash=0
+    local dirname_args=()
+    local complete_history=
 
     __%[1]s_handle_word
 }
@@ -324,48 +488,99 @@ fi
 func writeCommands(buf *bytes.Buffer, cmd *Command) {
 	buf.WriteString("    commands=()\n")
 	for _, c := range cmd.Commands() {
-		if !c.IsAvailableCommand() || c == cmd.helpCommand {
+		if c == cmd.helpCommand {
 			continue
 		}
-		buf.WriteString(fmt.Sprintf("    commands+=(%q)\n", c.Name()))
-		writeCmdAliases(buf, c)
+		if c.IsAvailableCommand() {
+			buf.WriteString(fmt.Sprintf("    commands+=(%q)\n", c.Name()))
+			writeCmdAliases(buf, c)
+		} else if hiddenCompletableCommand(c) {
+			writeHiddenGuardStart(buf, cmd)
+			buf.WriteString(fmt.Sprintf("    commands+=(%q)\n", c.Name()))
+			writeCmdAliases(buf, c)
+			writeHiddenGuardEnd(buf)
+		}
 	}
 	buf.WriteString("\n")
 }
 
-func writeFlagHandler(buf *bytes.Buffer, name string, annotations map[string][]string, cmd *Command) {
-	for key, value := range annotations {
+// CompletionShowHiddenEnvVar returns the name of the environment variable which,
+// when set to a non-empty value in the shell, makes the generated bash completion
+// also offer hidden commands and flags. It is the upper-cased name of the root
+// command followed by "_COMPLETION_SHOW_HIDDEN", e.g. MYCLI_COMPLETION_SHOW_HIDDEN.
+func (c *Command) CompletionShowHiddenEnvVar() string {
+	name := strings.Map(func(r rune) rune {
+		if (r >= 'A' && r <= 'Z') || (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			return r
+		}
+		return '_'
+	}, c.Root().Name())
+	return strings.ToUpper(name) + "_COMPLETION_SHOW_HIDDEN"
+}
+
+// hiddenCompletableCommand returns true for hidden commands which would
+// otherwise be available.
+func hiddenCompletableCommand(c *Command) bool {
+	return c.Hidden && len(c.Deprecated) == 0 && c.IsVisible() && (c.Runnable() || c.HasAvailableSubCommands())
+}
+
+func writeHiddenGuardStart(buf *bytes.Buffer, cmd *Command) {
+	buf.WriteString(fmt.Sprintf("    if [[ -n ${%s} ]]; then\n", cmd.CompletionShowHiddenEnvVar()))
+}
+
+func writeHiddenGuardEnd(buf *bytes.Buffer) {
+	buf.WriteString("    fi\n")
+}
+
+func writeFlagHandler(buf *bytes.Buffer, name string, flag *pflag.Flag, cmd *Command) {
+	handlers := flagCompletionHandlers(flag, cmd)
+	// The recent values of the flag are added to its other completion, if any.
+	if _, ok := flag.Annotations[FlagRememberRecent]; ok {
+		recent := fmt.Sprintf("__%s_handle_recent_values_flag %s", cmd.Root().Name(), flag.Name)
+		for i, handler := range handlers {
+			handlers[i] = recent + " " + handler
+		}
+		if len(handlers) == 0 {
+			handlers = []string{recent}
+		}
+	}
+	for _, handler := range handlers {
+		buf.WriteString(fmt.Sprintf("    flags_with_completion+=(%q)\n", name))
+		buf.WriteString(fmt.Sprintf("    flags_completion+=(%q)\n", handler))
+	}
+}
+
+// flagCompletionHandlers returns the commands completing the values of the flag.
+func flagCompletionHandlers(flag *pflag.Flag, cmd *Command) []string {
+	if kind := flagCompletionKind(flag); kind != "" {
+		return []string{fmt.Sprintf("__%s_handle_completion_kind_flag %s", cmd.Root().Name(), kind)}
+	}
+	var handlers []string
+	for key, value := range flag.Annotations {
 		switch key {
 		case BashCompFilenameExt:
-			buf.WriteString(fmt.Sprintf("    flags_with_completion+=(%q)\n", name))
-
-			var ext string
 			if len(value) > 0 {
-				ext = fmt.Sprintf("__%s_handle_filename_extension_flag ", cmd.Root().Name()) + strings.Join(value, "|")
+				handlers = append(handlers, fmt.Sprintf("__%s_handle_filename_extension_flag ", cmd.Root().Name())+strings.Join(value, "|"))
 			} else {
-				ext = "_filedir"
+				handlers = append(handlers, "_filedir")
 			}
-			buf.WriteString(fmt.Sprintf("    flags_completion+=(%q)\n", ext))
 		case BashCompCustom:
-			buf.WriteString(fmt.Sprintf("    flags_with_completion+=(%q)\n", name))
 			if len(value) > 0 {
-				handlers := strings.Join(value, "; ")
-				buf.WriteString(fmt.Sprintf("    flags_completion+=(%q)\n", handlers))
+				handlers = append(handlers, strings.Join(value, "; "))
 			} else {
-				buf.WriteString("    flags_completion+=(:)\n")
+				handlers = append(handlers, ":")
 			}
+		case BashCompKeyValue:
+			handlers = append(handlers, fmt.Sprintf("__%s_handle_key_value_flag %s", cmd.Root().Name(), strings.Join(value, " ")))
 		case BashCompSubdirsInDir:
-			buf.WriteString(fmt.Sprintf("    flags_with_completion+=(%q)\n", name))
-
-			var ext string
 			if len(value) == 1 {
-				ext = fmt.Sprintf("__%s_handle_subdirs_in_dir_flag ", cmd.Root().Name()) + value[0]
+				handlers = append(handlers, fmt.Sprintf("__%s_handle_subdirs_in_dir_flag ", cmd.Root().Name())+value[0])
 			} else {
-				ext = "_filedir -d"
+				handlers = append(handlers, "_filedir -d")
 			}
-			buf.WriteString(fmt.Sprintf("    flags_completion+=(%q)\n", ext))
 		}
 	}
+	return handlers
 }
 
 func writeShortFlag(buf *bytes.Buffer, flag *pflag.Flag, cmd *Command) {
@@ -376,7 +591,7 @@ func writeShortFlag(buf *bytes.Buffer, flag *pflag.Flag, cmd *Command) {
 	}
 	format += "flags+=(\"-%s\")\n"
 	buf.WriteString(fmt.Sprintf(format, name))
-	writeFlagHandler(buf, "-"+name, flag.Annotations, cmd)
+	writeFlagHandler(buf, "-"+name, flag, cmd)
 }
 
 func writeFlag(buf *bytes.Buffer, flag *pflag.Flag, cmd *Command) {
@@ -390,8 +605,23 @@ func writeFlag(buf *bytes.Buffer, flag *pflag.Flag, cmd *Command) {
 	if len(flag.NoOptDefVal) == 0 {
 		format = "    two_word_flags+=(\"--%s\")\n"
 		buf.WriteString(fmt.Sprintf(format, name))
+	} else if hasOptionalValue(flag) {
+		buf.WriteString(fmt.Sprintf("    flags+=(\"--%s=\")\n", name))
+	}
+	writeFlagHandler(buf, "--"+name, flag, cmd)
+}
+
+// writeSingleDashFlag writes the single-dash form of a long flag, accepted with
+// SingleDashLongFlags.
+func writeSingleDashFlag(buf *bytes.Buffer, flag *pflag.Flag, cmd *Command) {
+	name := flag.Name
+	if len(flag.NoOptDefVal) == 0 {
+		buf.WriteString(fmt.Sprintf("    flags+=(\"-%s=\")\n", name))
+		buf.WriteString(fmt.Sprintf("    two_word_flags+=(\"-%s\")\n", name))
+	} else {
+		buf.WriteString(fmt.Sprintf("    flags+=(\"-%s\")\n", name))
 	}
-	writeFlagHandler(buf, "--"+name, flag.Annotations, cmd)
+	writeFlagHandler(buf, "-"+name, flag, cmd)
 }
 
 func writeLocalNonPersistentFlag(buf *bytes.Buffer, flag *pflag.Flag) {
@@ -402,9 +632,13 @@ func writeLocalNonPersistentFlag(buf *bytes.Buffer, flag *pflag.Flag) {
 	}
 	format += "\")\n"
 	buf.WriteString(fmt.Sprintf(format, name))
+	if hasOptionalValue(flag) {
+		buf.WriteString(fmt.Sprintf("    local_nonpersistent_flags+=(\"--%s=\")\n", name))
+	}
 }
 
 func writeFlags(buf *bytes.Buffer, cmd *Command) {
+	cmd.InitDefaultYesFlag()
 	buf.WriteString(`    flags=()
     two_word_flags=()
     local_nonpersistent_flags=()
@@ -413,27 +647,30 @@ func writeFlags(buf *bytes.Buffer, cmd *Command) {
 
 `)
 	localNonPersistentFlags := cmd.LocalNonPersistentFlags()
-	cmd.NonInheritedFlags().VisitAll(func(flag *pflag.Flag) {
-		if nonCompletableFlag(flag) {
+	singleDash := cmd.singleDashLongFlags()
+	visit := func(flag *pflag.Flag) {
+		if len(flag.Deprecated) > 0 || cmd.flagFeatureDisabled(flag) {
 			return
 		}
+		if flag.Hidden {
+			writeHiddenGuardStart(buf, cmd)
+		}
 		writeFlag(buf, flag, cmd)
 		if len(flag.Shorthand) > 0 {
 			writeShortFlag(buf, flag, cmd)
 		}
+		if singleDash && isSingleDashLongFlag(flag.Name, cmd.Flags()) {
+			writeSingleDashFlag(buf, flag, cmd)
+		}
 		if localNonPersistentFlags.Lookup(flag.Name) != nil {
 			writeLocalNonPersistentFlag(buf, flag)
 		}
-	})
-	cmd.InheritedFlags().VisitAll(func(flag *pflag.Flag) {
-		if nonCompletableFlag(flag) {
-			return
+		if flag.Hidden {
+			writeHiddenGuardEnd(buf)
 		}
-		writeFlag(buf, flag, cmd)
-		if len(flag.Shorthand) > 0 {
-			writeShortFlag(buf, flag, cmd)
-		}
-	})
+	}
+	cmd.NonInheritedFlags().VisitAll(visit)
+	cmd.InheritedFlags().VisitAll(visit)
 
 	buf.WriteString("\n")
 }
@@ -463,6 +700,23 @@ func writeRequiredFlag(buf *bytes.Buffer, cmd *Command) {
 	})
 }
 
+// writeMutuallyExclusiveFlags writes the groups of mutually exclusive flags of cmd, each
+// as the forms of its flags separated by spaces.
+func writeMutuallyExclusiveFlags(buf *bytes.Buffer, cmd *Command) {
+	buf.WriteString("    mutually_exclusive_flags=()\n")
+	flags := cmd.Flags()
+	for _, group := range cmd.flagGroupsOf(flagGroupMutuallyExclusive) {
+		var forms []string
+		for _, name := range group {
+			forms = append(forms, "--"+name, "--"+name+"=")
+			if f := flags.Lookup(name); f != nil && len(f.Shorthand) > 0 {
+				forms = append(forms, "-"+f.Shorthand)
+			}
+		}
+		buf.WriteString(fmt.Sprintf("    mutually_exclusive_flags+=(%q)\n", strings.Join(forms, " ")))
+	}
+}
+
 func writeRequiredNouns(buf *bytes.Buffer, cmd *Command) {
 	buf.WriteString("    must_have_one_noun=()\n")
 	sort.Sort(sort.StringSlice(cmd.ValidArgs))
@@ -471,6 +725,24 @@ func writeRequiredNouns(buf *bytes.Buffer, cmd *Command) {
 	}
 }
 
+func writeDirnameArgs(buf *bytes.Buffer, cmd *Command) {
+	buf.WriteString("    dirname_args=()\n")
+	annotation, err := cmd.zshCompGetArgsAnnotations()
+	if err != nil {
+		return
+	}
+	var positions []int
+	for position, hint := range annotation {
+		if hint.Tipe == zshCompArgumentDirnameComp {
+			positions = append(positions, position)
+		}
+	}
+	sort.Ints(positions)
+	for _, position := range positions {
+		buf.WriteString(fmt.Sprintf("    dirname_args+=(%d)\n", position))
+	}
+}
+
 func writeCmdAliases(buf *bytes.Buffer, cmd *Command) {
 	if len(cmd.Aliases) == 0 {
 		return
@@ -496,7 +768,7 @@ func writeArgAliases(buf *bytes.Buffer, cmd *Command) {
 
 func gen(buf *bytes.Buffer, cmd *Command) {
 	for _, c := range cmd.Commands() {
-		if !c.IsAvailableCommand() || c == cmd.helpCommand {
+		if (!c.IsAvailableCommand() && !hiddenCompletableCommand(c)) || c == cmd.helpCommand {
 			continue
 		}
 		gen(buf, c)
@@ -519,8 +791,14 @@ func gen(buf *bytes.Buffer, cmd *Command) {
 	writeCommands(buf, cmd)
 	writeFlags(buf, cmd)
 	writeRequiredFlag(buf, cmd)
+	writeMutuallyExclusiveFlags(buf, cmd)
 	writeRequiredNouns(buf, cmd)
+	writeDirnameArgs(buf, cmd)
 	writeArgAliases(buf, cmd)
+	if cmd.Annotations[historyAnnotation] != "" {
+		buf.WriteString("    complete_history=1\n")
+	}
+	buf.WriteString(completionSnippet(cmd, BashCompSnippet, "    "))
 	buf.WriteString("}\n\n")
 }
 
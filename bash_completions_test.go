@@ -195,6 +195,10 @@ func TestBashCompletions(t *testing.T) {
 
 	checkOmit(t, output, deprecatedCmd.Name())
 
+	// check that words are not split on colons and candidates are trimmed accordingly
+	check(t, output, "_init_completion -s -n : || return")
+	checkNumOccurrences(t, output, "__root_ltrim_colon_completions", 4) // 1. definition, 2-4. invocations
+
 	// If available, run shellcheck against the script.
 	if err := exec.Command("which", "shellcheck").Run(); err != nil {
 		return
@@ -215,9 +219,27 @@ func TestBashCompletionHiddenFlag(t *testing.T) {
 	c.GenBashCompletion(buf)
 	output := buf.String()
 
-	if strings.Contains(output, flagName) {
-		t.Errorf("Expected completion to not include %q flag: Got %v", flagName, output)
+	// hidden flags are only offered when requested through the environment
+	checkRegex(t, output, `if \[\[ -n \$\{C_COMPLETION_SHOW_HIDDEN} \]\]; then\n    flags\+=\("--hiddenFlag"\)\n`)
+	checkNumOccurrences(t, output, flagName, 2) // flags and local_nonpersistent_flags
+}
+
+func TestBashCompletionHiddenCommand(t *testing.T) {
+	rootCmd := &Command{Use: "my-cli", Run: emptyRun}
+	hiddenCmd := &Command{Use: "experimental", Hidden: true, Run: emptyRun}
+	rootCmd.AddCommand(hiddenCmd, &Command{Use: "visible", Run: emptyRun})
+
+	if got := hiddenCmd.CompletionShowHiddenEnvVar(); got != "MY_CLI_COMPLETION_SHOW_HIDDEN" {
+		t.Errorf("Unexpected env var name %q", got)
 	}
+
+	buf := new(bytes.Buffer)
+	rootCmd.GenBashCompletion(buf)
+	output := buf.String()
+
+	check(t, output, `    commands+=("visible")`)
+	check(t, output, "    if [[ -n ${MY_CLI_COMPLETION_SHOW_HIDDEN} ]]; then\n    commands+=(\"experimental\")\n    fi\n")
+	check(t, output, "_my-cli_experimental()")
 }
 
 func TestBashCompletionDeprecatedFlag(t *testing.T) {
@@ -235,3 +257,150 @@ func TestBashCompletionDeprecatedFlag(t *testing.T) {
 		t.Errorf("expected completion to not include %q flag: Got %v", flagName, output)
 	}
 }
+
+func TestBashCompletionSnippet(t *testing.T) {
+	c := &Command{
+		Use:         "c",
+		Run:         emptyRun,
+		Annotations: map[string]string{BashCompSnippet: "compopt -o nospace\nlocal x=1"},
+	}
+
+	buf := new(bytes.Buffer)
+	c.GenBashCompletion(buf)
+	output := buf.String()
+
+	check(t, output, "    compopt -o nospace\n    local x=1\n}")
+}
+
+func TestBashCompletionDashDash(t *testing.T) {
+	c := &Command{Use: "c", Run: emptyRun}
+
+	buf := new(bytes.Buffer)
+	c.GenBashCompletion(buf)
+	output := buf.String()
+
+	check(t, output, `elif [[ "${words[c]}" == "--" ]]; then`)
+	check(t, output, "__c_handle_dash_dash()")
+	check(t, output, "__c_dash_dash_func")
+	check(t, output, "local after_dash_dash=0")
+}
+
+func TestBashCompletionOptionalValueFlag(t *testing.T) {
+	c := &Command{Use: "c", Run: emptyRun}
+	c.Flags().String("color", "", "")
+	c.Flags().Lookup("color").NoOptDefVal = "auto"
+	c.Flags().Bool("quiet", false, "")
+
+	buf := new(bytes.Buffer)
+	c.GenBashCompletion(buf)
+	output := buf.String()
+
+	check(t, output, `flags+=("--color")`)
+	check(t, output, `flags+=("--color=")`)
+	checkOmit(t, output, `two_word_flags+=("--color")`)
+	checkOmit(t, output, `flags+=("--quiet=")`)
+	check(t, output, `__c_contains_word "${flag}" "${two_word_flags[@]}"`)
+}
+
+func TestBashCompletionKeyValueFlag(t *testing.T) {
+	c := &Command{Use: "c", Run: emptyRun}
+	c.Flags().StringArray("set", nil, "")
+	if err := c.MarkFlagKeyValue("set", map[string][]string{"env": {"prod", "dev"}, "region": nil}); err != nil {
+		t.Fatal(err)
+	}
+
+	buf := new(bytes.Buffer)
+	c.GenBashCompletion(buf)
+	output := buf.String()
+
+	check(t, output, "__c_handle_key_value_flag()")
+	check(t, output, `flags_with_completion+=("--set")`)
+	check(t, output, `flags_completion+=("__c_handle_key_value_flag env=dev env=prod region=")`)
+}
+
+// runBashCompletion runs the bash completion script for the words of a command line,
+// the last one being completed, and returns the completions. The words are given as
+// bash-completion gives them: "=" breaks words unless the script asked not to, which
+// joined tells.
+func runBashCompletion(t *testing.T, script string, words ...string) []string {
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("bash is not installed")
+	}
+	driver := script + `
+compopt() { :; }
+_get_comp_words_by_ref() {
+    words=("${COMP_WORDS[@]}")
+    cword=${COMP_CWORD}
+    cur=${words[cword]}
+    prev=${words[cword-1]}
+}
+COMP_WORDS=(` + "'" + strings.Join(words, "' '") + "'" + `)
+COMP_CWORD=` + fmt.Sprint(len(words)-1) + `
+__start_c
+printf '%s\n' "${COMPREPLY[@]}"
+`
+	cmd := exec.Command("bash", "-c", driver)
+	cmd.Stderr = os.Stderr
+	out, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("Unexpected error running bash: %v", err)
+	}
+	return strings.Fields(string(out))
+}
+
+func TestBashCompletionKeyValueFlagInBash(t *testing.T) {
+	c := &Command{Use: "c", Run: emptyRun}
+	c.Flags().StringArray("set", nil, "")
+	c.MarkFlagKeyValue("set", map[string][]string{"env": {"prod", "dev"}, "region": nil})
+	buf := new(bytes.Buffer)
+	c.GenBashCompletion(buf)
+
+	tests := []struct {
+		words    []string
+		expected []string
+	}{
+		{[]string{"c", "--set", ""}, []string{"env=", "region="}},
+		{[]string{"c", "--set", "e"}, []string{"env="}},
+		// bash only replaces what follows the "=", which breaks words.
+		{[]string{"c", "--set", "env", "=", ""}, []string{"dev", "prod"}},
+		{[]string{"c", "--set", "env", "=", "d"}, []string{"dev"}},
+		{[]string{"c", "--set", "env=d"}, []string{"dev"}},
+	}
+	for _, tc := range tests {
+		got := runBashCompletion(t, buf.String(), tc.words...)
+		if strings.Join(got, " ") != strings.Join(tc.expected, " ") {
+			t.Errorf("Expected %q for %q, got %q", tc.expected, tc.words, got)
+		}
+	}
+}
+
+func TestBashCompletionKindFlag(t *testing.T) {
+	c := &Command{Use: "c", Run: emptyRun}
+	c.Flags().String("host", "", "")
+	if err := c.MarkFlagCompletionKind("host", CompleteHosts); err != nil {
+		t.Fatal(err)
+	}
+
+	buf := new(bytes.Buffer)
+	c.GenBashCompletion(buf)
+	output := buf.String()
+
+	check(t, output, "__c_handle_completion_kind_flag()")
+	check(t, output, `flags_with_completion+=("--host")`)
+	check(t, output, `flags_completion+=("__c_handle_completion_kind_flag hosts")`)
+}
+
+func TestBashCompletionDirnameFlag(t *testing.T) {
+	c := &Command{Use: "c", Run: emptyRun}
+	c.Flags().String("dir", "", "")
+	c.Flags().String("theme", "", "")
+	c.MarkFlagDirname("dir")
+	c.MarkFlagSubdirsInDir("theme", "themes")
+
+	buf := new(bytes.Buffer)
+	c.GenBashCompletion(buf)
+	output := buf.String()
+
+	check(t, output, `flags_with_completion+=("--dir")`+"\n"+`    flags_completion+=("_filedir -d")`)
+	check(t, output, `flags_with_completion+=("--theme")`+"\n"+`    flags_completion+=("__c_handle_subdirs_in_dir_flag themes")`)
+}
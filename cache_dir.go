@@ -0,0 +1,8 @@
+// +build go1.11
+
+package cobra
+
+import "os"
+
+// userCacheDir returns the directory holding the recent flag values and the history.
+var userCacheDir = os.UserCacheDir
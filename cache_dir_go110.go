@@ -0,0 +1,34 @@
+// +build !go1.11
+
+package cobra
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+// userCacheDir returns the directory holding the recent flag values and the history,
+// like os.UserCacheDir which needs Go 1.11.
+var userCacheDir = func() (string, error) {
+	switch runtime.GOOS {
+	case "windows":
+		if dir := os.Getenv("LocalAppData"); dir != "" {
+			return dir, nil
+		}
+		return "", errors.New("%LocalAppData% is not defined")
+	case "darwin":
+		if dir := os.Getenv("HOME"); dir != "" {
+			return filepath.Join(dir, "Library", "Caches"), nil
+		}
+		return "", errors.New("$HOME is not defined")
+	}
+	if dir := os.Getenv("XDG_CACHE_HOME"); dir != "" {
+		return dir, nil
+	}
+	if dir := os.Getenv("HOME"); dir != "" {
+		return filepath.Join(dir, ".cache"), nil
+	}
+	return "", errors.New("neither $XDG_CACHE_HOME nor $HOME are defined")
+}
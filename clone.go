@@ -0,0 +1,319 @@
+package cobra
+
+import (
+	"io"
+	"reflect"
+	"strings"
+
+	flag "github.com/spf13/pflag"
+)
+
+// Freeze makes the command tree of c a read-only template: it can no longer be executed
+// nor extended with AddCommand, and Instantiate returns fresh executable copies of it.
+// As executing a copy never touches the template, the copies can be executed
+// concurrently, e.g. once per request of a server. The tree must not be modified
+// after Freeze.
+func (c *Command) Freeze() {
+	c.Root().freeze()
+}
+
+func (c *Command) freeze() {
+	c.frozen = true
+	// Visiting the flags caches their sorted order now rather than while copying them.
+	noop := func(*flag.Flag) {}
+	if c.flags != nil {
+		c.flags.VisitAll(noop)
+	}
+	if c.pflags != nil {
+		c.pflags.VisitAll(noop)
+	}
+	for _, sub := range c.commands {
+		sub.freeze()
+	}
+}
+
+// Frozen returns true if the command tree of c has been frozen by Freeze.
+func (c *Command) Frozen() bool {
+	return c.frozen
+}
+
+// Clone returns a deep copy of c and its subcommands, without parent, so that tests and
+// REPLs can execute a command tree repeatedly from a pristine state. The flags are copied
+// with fresh values holding their defaults; values implementing a Clone() pflag.Value
+// method are copied with it. The hooks are shared, see Instantiate.
+func (c *Command) Clone() *Command {
+	return newCloneState().clone(c)
+}
+
+// Instantiate returns a fresh copy of the root of the command tree of c, ready to be
+// executed, with its own flag values reset to their defaults. The hooks are shared with
+// the template, so they must read flag values from the command they are given, e.g. with
+// cmd.Flags().GetString, rather than from variables bound to the flags of the template.
+func (c *Command) Instantiate() *Command {
+	return newCloneState().clone(c.Root())
+}
+
+// cloneState maps the commands and flags of a tree to their copies, as flags are shared
+// by the flag sets of a command and its subcommands.
+type cloneState struct {
+	commands map[*Command]*Command
+	flags    map[*flag.Flag]*flag.Flag
+}
+
+func newCloneState() *cloneState {
+	return &cloneState{
+		commands: make(map[*Command]*Command),
+		flags:    make(map[*flag.Flag]*flag.Flag),
+	}
+}
+
+// clone returns a copy of c and its subcommands, without parent.
+func (s *cloneState) clone(c *Command) *Command {
+	nc := s.cloneTree(c, nil)
+	// Values referring to other commands or values are pointed to their copies.
+	for _, nf := range s.flags {
+		switch v := nf.Value.(type) {
+		case *FileValue:
+			if cmd, ok := s.commands[v.cmd]; ok {
+				v.cmd = cmd
+			}
+		case *filePathValue:
+			if target, ok := s.flags[v.target]; ok {
+				v.target = target
+			}
+		}
+	}
+	return nc
+}
+
+func (s *cloneState) cloneTree(c *Command, parent *Command) *Command {
+	nc := new(Command)
+	*nc = *c
+	nc.parent = parent
+	nc.frozen = false
+	nc.commandCalledAs.name = ""
+	nc.commandCalledAs.called = false
+	nc.Aliases = append([]string(nil), c.Aliases...)
+	nc.SuggestFor = append([]string(nil), c.SuggestFor...)
+	nc.ValidArgs = append([]string(nil), c.ValidArgs...)
+	nc.ArgAliases = append([]string(nil), c.ArgAliases...)
+	nc.commandGroups = append([]*Group(nil), c.commandGroups...)
+	nc.flagGroups = append([]flagGroup(nil), c.flagGroups...)
+	nc.helpHooks = append(c.helpHooks[:0:0], c.helpHooks...)
+	nc.helpSections = append(c.helpSections[:0:0], c.helpSections...)
+	nc.args = append([]string(nil), c.args...)
+	if c.args == nil {
+		nc.args = nil
+	}
+	if c.Annotations != nil {
+		nc.Annotations = make(map[string]string, len(c.Annotations))
+		for k, v := range c.Annotations {
+			nc.Annotations[k] = v
+		}
+	}
+	if c.templateFuncs != nil {
+		nc.templateFuncs = make(map[string]interface{}, len(c.templateFuncs))
+		for k, v := range c.templateFuncs {
+			nc.templateFuncs[k] = v
+		}
+	}
+	if c.inheritedFlagOverlays != nil {
+		nc.inheritedFlagOverlays = make(map[string]*inheritedFlagOverlay, len(c.inheritedFlagOverlays))
+		for k, v := range c.inheritedFlagOverlays {
+			overlay := *v
+			nc.inheritedFlagOverlays[k] = &overlay
+		}
+	}
+
+	// The state of the last execution is not copied.
+	nc.flagErrorBuf = nil
+	nc.flagSources = nil
+	nc.phase = PhaseResolve
+	nc.pendingUpdate = nil
+	nc.ctx = nil
+	nc.env = nil
+	nc.flags = s.cloneFlagSet(c.flags, c.Name(), nc.flagOutput())
+	nc.pflags = s.cloneFlagSet(c.pflags, c.Name(), nc.flagOutput())
+	nc.lflags = nil
+	nc.iflags = nil
+	nc.parentsPflags = nil
+
+	nc.commands = make([]*Command, 0, len(c.commands))
+	nc.helpCommand = nil
+	for _, sub := range c.commands {
+		nsub := s.cloneTree(sub, nc)
+		if sub == c.helpCommand {
+			nc.helpCommand = nsub
+		}
+		nc.commands = append(nc.commands, nsub)
+	}
+	if c.helpCommand != nil && nc.helpCommand == nil {
+		nc.helpCommand = s.cloneTree(c.helpCommand, nil)
+	}
+	s.commands[c] = nc
+	return nc
+}
+
+// cloneFlagSet returns a copy of fs holding copies of its flags.
+func (s *cloneState) cloneFlagSet(fs *flag.FlagSet, name string, output io.Writer) *flag.FlagSet {
+	if fs == nil {
+		return nil
+	}
+	nfs := flag.NewFlagSet(name, flag.ContinueOnError)
+	nfs.SetOutput(output)
+	nfs.Usage = fs.Usage
+	nfs.SortFlags = fs.SortFlags
+	nfs.ParseErrorsWhitelist = fs.ParseErrorsWhitelist
+	nfs.SetNormalizeFunc(fs.GetNormalizeFunc())
+	nfs.SetInterspersed(flagsInterspersed(fs))
+	fs.VisitAll(func(f *flag.Flag) {
+		nf, ok := s.flags[f]
+		if !ok {
+			nf = cloneFlag(f)
+			s.flags[f] = nf
+		}
+		nfs.AddFlag(nf)
+	})
+	return nfs
+}
+
+func cloneFlag(f *flag.Flag) *flag.Flag {
+	nf := *f
+	nf.Value = cloneFlagValue(f)
+	nf.Changed = false
+	if f.Annotations != nil {
+		nf.Annotations = make(map[string][]string, len(f.Annotations))
+		for k, v := range f.Annotations {
+			nf.Annotations[k] = append([]string(nil), v...)
+		}
+	}
+	return &nf
+}
+
+// cloneFlagValue returns a fresh value for the flag, holding its default value.
+// Values whose storage cannot be copied, like maps or structs with unexported fields,
+// are shared with the flag.
+func cloneFlagValue(f *flag.Flag) flag.Value {
+	switch v := f.Value.(type) {
+	case interface{ Clone() flag.Value }:
+		return v.Clone()
+	case *FileValue:
+		inner := *f
+		inner.Value = v.Value
+		nv := *v
+		nv.Value = cloneFlagValue(&inner)
+		return &nv
+	case *filePathValue:
+		return &filePathValue{target: v.target}
+	}
+	if isPflagValue(f.Value) {
+		if v, ok := freshPflagValue(f.Value.Type(), f.DefValue); ok {
+			return v
+		}
+	}
+	rv := reflect.ValueOf(f.Value)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || !isCopyable(rv.Elem().Type()) {
+		return f.Value
+	}
+	nv := reflect.New(rv.Elem().Type())
+	nv.Elem().Set(rv.Elem())
+	if rv.Elem().Kind() == reflect.Slice && !rv.Elem().IsNil() {
+		s := reflect.MakeSlice(rv.Elem().Type(), rv.Elem().Len(), rv.Elem().Len())
+		reflect.Copy(s, rv.Elem())
+		nv.Elem().Set(s)
+	}
+	v, ok := nv.Interface().(flag.Value)
+	if !ok {
+		return f.Value
+	}
+	if isPflagValue(f.Value) {
+		// The scalar values of pflag are reset to their defaults.
+		v.Set(f.DefValue)
+	}
+	return v
+}
+
+// isCopyable returns true if a value of type t shares no mutable storage with its copy,
+// other than the backing arrays of slices.
+func isCopyable(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Chan, reflect.Func, reflect.Interface, reflect.UnsafePointer:
+		return false
+	case reflect.Array:
+		return isCopyable(t.Elem())
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if field.PkgPath != "" || !isCopyable(field.Type) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+var pflagPkgPath = reflect.TypeOf(flag.FlagSet{}).PkgPath()
+
+// isPflagValue returns true if v is one of the value types of pflag.
+func isPflagValue(v flag.Value) bool {
+	t := reflect.TypeOf(v)
+	return t.Kind() == reflect.Ptr && t.Elem().PkgPath() == pflagPkgPath
+}
+
+// freshPflagValue returns a new value of the given slice or map type of pflag, holding
+// the default value def. These values keep their storage behind unexported fields.
+func freshPflagValue(typ, def string) (flag.Value, bool) {
+	in := flag.NewFlagSet("default", flag.ContinueOnError)
+	out := flag.NewFlagSet("fresh", flag.ContinueOnError)
+	def = strings.TrimSuffix(strings.TrimPrefix(def, "["), "]")
+	var err error
+	parse := func() {
+		if def != "" {
+			err = in.Set("v", def)
+		}
+	}
+	switch typ {
+	case "stringSlice":
+		p := in.StringSlice("v", nil, "")
+		parse()
+		out.StringSlice("v", *p, "")
+	case "stringArray":
+		p := in.StringSlice("v", nil, "")
+		parse()
+		out.StringArray("v", *p, "")
+	case "intSlice":
+		p := in.IntSlice("v", nil, "")
+		parse()
+		out.IntSlice("v", *p, "")
+	case "uintSlice":
+		p := in.UintSlice("v", nil, "")
+		parse()
+		out.UintSlice("v", *p, "")
+	case "boolSlice":
+		p := in.BoolSlice("v", nil, "")
+		parse()
+		out.BoolSlice("v", *p, "")
+	case "durationSlice":
+		p := in.DurationSlice("v", nil, "")
+		parse()
+		out.DurationSlice("v", *p, "")
+	case "ipSlice":
+		p := in.IPSlice("v", nil, "")
+		parse()
+		out.IPSlice("v", *p, "")
+	case "stringToString":
+		p := in.StringToString("v", nil, "")
+		parse()
+		out.StringToString("v", *p, "")
+	case "stringToInt":
+		p := in.StringToInt("v", nil, "")
+		parse()
+		out.StringToInt("v", *p, "")
+	default:
+		return nil, false
+	}
+	if err != nil {
+		return nil, false
+	}
+	return out.Lookup("v").Value, true
+}
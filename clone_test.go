@@ -0,0 +1,172 @@
+package cobra
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/spf13/pflag"
+)
+
+func newGreetTemplate() *Command {
+	rootCmd := &Command{Use: "root", Run: emptyRun}
+	greetCmd := &Command{
+		Use: "greet",
+		RunE: func(c *Command, _ []string) error {
+			name, _ := c.Flags().GetString("name")
+			tags, _ := c.Flags().GetStringSlice("tag")
+			c.Print(name, " ", strings.Join(tags, ","))
+			return nil
+		},
+	}
+	greetCmd.Flags().String("name", "nobody", "name")
+	greetCmd.Flags().StringSlice("tag", []string{"a"}, "tags")
+	rootCmd.PersistentFlags().Bool("verbose", false, "verbose")
+	rootCmd.AddCommand(greetCmd)
+	return rootCmd
+}
+
+func TestFreeze(t *testing.T) {
+	rootCmd := newGreetTemplate()
+	rootCmd.Freeze()
+
+	if !rootCmd.Commands()[0].Frozen() {
+		t.Error("Expected subcommands to be frozen")
+	}
+	if _, err := executeCommand(rootCmd, "greet"); err == nil {
+		t.Error("Expected error executing a frozen command")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected AddCommand to panic on a frozen command")
+		}
+	}()
+	rootCmd.AddCommand(&Command{Use: "other"})
+}
+
+func TestInstantiate(t *testing.T) {
+	template := newGreetTemplate()
+	template.Freeze()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			c := template.Instantiate()
+			if c.Frozen() {
+				t.Error("Expected instance not to be frozen")
+				return
+			}
+			buf := new(bytes.Buffer)
+			c.SetOutput(buf)
+			name := fmt.Sprintf("user%d", i)
+			c.SetArgs([]string{"greet", "--verbose", "--name", name, "--tag", "b"})
+			if err := c.Execute(); err != nil {
+				t.Errorf("Unexpected error: %v", err)
+				return
+			}
+			if expected := name + " b"; buf.String() != expected {
+				t.Errorf("Expected %q, got %q", expected, buf.String())
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	output, err := executeCommand(template.Instantiate(), "greet")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if output != "nobody a" {
+		t.Errorf("Expected defaults, got %q", output)
+	}
+}
+
+type clonableValue struct {
+	values []string
+	clones *int
+}
+
+func (v *clonableValue) Set(s string) error { v.values = append(v.values, s); return nil }
+func (v *clonableValue) String() string     { return strings.Join(v.values, ",") }
+func (v *clonableValue) Type() string       { return "clonable" }
+func (v *clonableValue) Clone() pflag.Value {
+	*v.clones++
+	return &clonableValue{clones: v.clones}
+}
+
+func TestClone(t *testing.T) {
+	rootCmd := newGreetTemplate()
+	clones := 0
+	rootCmd.PersistentFlags().Var(&clonableValue{clones: &clones}, "custom", "custom value")
+	rootCmd.PersistentFlags().String("password", "", "password")
+	if err := rootCmd.MarkPersistentFlagValueFromFile("password"); err != nil {
+		t.Fatal(err)
+	}
+
+	c := rootCmd.Clone()
+	c.SetIn(strings.NewReader("s3cr3t\n"))
+	output, err := executeCommand(c, "greet", "--name", "alice", "--tag", "b", "--custom", "x", "--password-file", "-")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if output != "alice b" {
+		t.Errorf("Expected %q, got %q", "alice b", output)
+	}
+	if clones != 1 {
+		t.Errorf("Expected the custom value to be cloned once, got %d", clones)
+	}
+	if v, _ := c.PersistentFlags().GetString("password"); v != "s3cr3t" {
+		t.Errorf("Expected the password of the clone to be read from its input, got %q", v)
+	}
+	if v := rootCmd.PersistentFlags().Lookup("custom").Value.String(); v != "" {
+		t.Errorf("Expected the original custom value to be untouched, got %q", v)
+	}
+	if v, _ := rootCmd.PersistentFlags().GetString("password"); v != "" {
+		t.Errorf("Expected the original password to be untouched, got %q", v)
+	}
+
+	output, err = executeCommand(rootCmd.Clone(), "greet")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if output != "nobody a" {
+		t.Errorf("Expected defaults, got %q", output)
+	}
+	if name, _ := rootCmd.Commands()[0].Flags().GetString("name"); name != "nobody" {
+		t.Errorf("Expected the original to be untouched, got %q", name)
+	}
+}
+
+func TestCloneLeavesTemplateAlone(t *testing.T) {
+	rootCmd := &Command{Use: "root", Run: emptyRun}
+	rootCmd.Flags().SetInterspersed(false)
+	rootCmd.Flags().Bool("force", false, "force")
+	// The backing array has room for more sections, which the copies must not share.
+	rootCmd.helpSections = make([]func(*Command) (string, string), 0, 4)
+	rootCmd.AddHelpSection(func(*Command) (string, string) { return "Template", "template section" })
+	if _, err := executeCommand(rootCmd, "--force"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	c := rootCmd.Clone()
+	if c.phase != PhaseResolve || c.ctx != nil || c.env != nil || c.pendingUpdate != nil {
+		t.Error("Expected the state of the last execution not to be copied")
+	}
+	c.AddHelpSection(func(*Command) (string, string) { return "Copy", "copy section" })
+	rootCmd.Clone().AddHelpSection(func(*Command) (string, string) { return "Other", "other section" })
+	output, _ := executeCommand(c, "--help")
+	checkStringContains(t, output, "copy section")
+	checkStringOmits(t, output, "other section")
+
+	c = rootCmd.Clone()
+	if _, err := executeCommand(c, "arg", "--force"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if c.Flags().Changed("force") {
+		t.Error("Expected the copy to keep the interspersed setting")
+	}
+}
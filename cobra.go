@@ -17,11 +17,11 @@
 package cobra
 
 import (
-	"fmt"
 	"io"
 	"reflect"
 	"strconv"
 	"strings"
+	"sync"
 	"text/template"
 	"time"
 	"unicode"
@@ -33,10 +33,14 @@ var templateFuncs = template.FuncMap{
 	"trimTrailingWhitespaces": trimRightSpace,
 	"appendIfNotPresent":      appendIfNotPresent,
 	"rpad":                    rpad,
+	"wrap":                    wrap,
 	"gt":                      Gt,
 	"eq":                      Eq,
 }
 
+// templateFuncsMu guards templateFuncs and the template funcs of the commands.
+var templateFuncsMu sync.RWMutex
+
 var initializers []func()
 
 // EnablePrefixMatching allows to set automatic prefix matching. Automatic prefix matching can be a dangerous thing
@@ -44,6 +48,29 @@ var initializers []func()
 // Set this to true to enable it.
 var EnablePrefixMatching = false
 
+// EnableFlagPlacementHints makes unknown flag errors name the commands of the tree
+// that do accept the flag, which helps when a flag is given to the wrong command.
+// Set this to true to enable it.
+var EnableFlagPlacementHints = false
+
+// EnableAproposSuggestions makes unknown command errors also suggest the commands whose
+// descriptions mention the typed word, in addition to the commands with a close name.
+// Set this to true to enable it.
+var EnableAproposSuggestions = false
+
+// EnableFlagSuggestions makes unknown flag errors suggest the flags with a close name,
+// like unknown command errors do. The suggestions are given to the function set by
+// SetFlagErrorFunc either way.
+// Set this to true to enable it.
+var EnableFlagSuggestions = false
+
+// EnableCollisionChecks makes AddCommand panic when a command shadows the name or an
+// alias of a sibling command, and flag merging panic when a flag shadows a persistent
+// flag inherited from a parent. Set the AnnotationAllowOverride annotation on a
+// command, or call MarkFlagOverride on a flag, to allow an intentional override.
+// Set this to true to enable it.
+var EnableCollisionChecks = false
+
 // EnableCommandSorting controls sorting of the slice of commands, which is turned on by default.
 // To disable sorting, set it to false.
 var EnableCommandSorting = true
@@ -66,12 +93,16 @@ var MousetrapDisplayDuration = 5 * time.Second
 // AddTemplateFunc adds a template function that's available to Usage and Help
 // template generation.
 func AddTemplateFunc(name string, tmplFunc interface{}) {
+	templateFuncsMu.Lock()
+	defer templateFuncsMu.Unlock()
 	templateFuncs[name] = tmplFunc
 }
 
 // AddTemplateFuncs adds multiple template functions that are available to Usage and
 // Help template generation.
 func AddTemplateFuncs(tmplFuncs template.FuncMap) {
+	templateFuncsMu.Lock()
+	defer templateFuncsMu.Unlock()
 	for k, v := range tmplFuncs {
 		templateFuncs[k] = v
 	}
@@ -147,16 +178,26 @@ func appendIfNotPresent(s, stringToAppend string) string {
 	return s + " " + stringToAppend
 }
 
-// rpad adds padding to the right of a string.
+// rpad adds padding to the right of a string, up to a display width of padding
+// columns.
 func rpad(s string, padding int) string {
-	template := fmt.Sprintf("%%-%ds", padding)
-	return fmt.Sprintf(template, s)
+	if n := padding - displayWidth(s); n > 0 {
+		return s + strings.Repeat(" ", n)
+	}
+	return s
 }
 
 // tmpl executes the given template text on data, writing the result to w.
+// The template funcs of data are available if it is a command.
 func tmpl(w io.Writer, text string, data interface{}) error {
 	t := template.New("top")
-	t.Funcs(templateFuncs)
+	if c, ok := data.(*Command); ok {
+		t.Funcs(c.TemplateFuncs())
+	} else {
+		templateFuncsMu.RLock()
+		t.Funcs(templateFuncs)
+		templateFuncsMu.RUnlock()
+	}
 	template.Must(t.Parse(text))
 	return t.Execute(w, data)
 }
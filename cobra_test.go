@@ -1,6 +1,8 @@
 package cobra
 
 import (
+	"fmt"
+	"sync"
 	"testing"
 	"text/template"
 )
@@ -20,3 +22,115 @@ func TestAddTemplateFunctions(t *testing.T) {
 		t.Errorf("Expected UsageString: %v\nGot: %v", expected, got)
 	}
 }
+
+func TestCommandTemplateFunctions(t *testing.T) {
+	rootCmd := &Command{Use: "root", Run: emptyRun}
+	childCmd := &Command{Use: "child", Run: emptyRun}
+	otherCmd := &Command{Use: "other", Run: emptyRun}
+	rootCmd.AddCommand(childCmd)
+	rootCmd.SetUsageTemplate(`{{greet}} {{name}}`)
+	otherCmd.SetUsageTemplate(`{{if eq 1 1}}ok{{end}}`)
+
+	rootCmd.AddTemplateFunc("greet", func() string { return "Hello," })
+	rootCmd.AddTemplateFunc("name", func() string { return "root." })
+	childCmd.AddTemplateFuncs(template.FuncMap{"name": func() string { return "child." }})
+
+	if got := rootCmd.UsageString(); got != "Hello, root." {
+		t.Errorf("Expected %q, got %q", "Hello, root.", got)
+	}
+	if got := childCmd.UsageString(); got != "Hello, child." {
+		t.Errorf("Expected %q, got %q", "Hello, child.", got)
+	}
+	if _, ok := otherCmd.TemplateFuncs()["greet"]; ok {
+		t.Error("Expected the template funcs of a command not to leak to other trees")
+	}
+	if got := otherCmd.UsageString(); got != "ok" {
+		t.Errorf("Expected %q, got %q", "ok", got)
+	}
+}
+
+func TestTemplateFunctionsConcurrency(t *testing.T) {
+	rootCmd := &Command{Use: "root"}
+	rootCmd.SetUsageTemplate(`{{trim " x "}}`)
+	var children []*Command
+	for i := 0; i < 10; i++ {
+		c := &Command{Use: fmt.Sprintf("c%d", i)}
+		rootCmd.AddCommand(c)
+		c.UsageString()
+		children = append(children, c)
+	}
+
+	var wg sync.WaitGroup
+	for i, c := range children {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			rootCmd.AddTemplateFunc(fmt.Sprintf("f%d", i), func() string { return "" })
+			AddTemplateFunc(fmt.Sprintf("concurrent%d", i), func() string { return "" })
+		}(i)
+		go func(c *Command) {
+			defer wg.Done()
+			if got := c.UsageString(); got != "x" {
+				t.Errorf("Expected %q, got %q", "x", got)
+			}
+		}(c)
+	}
+	wg.Wait()
+}
+
+func TestRpadDisplayWidth(t *testing.T) {
+	tests := []struct {
+		s     string
+		width int
+		out   string
+	}{
+		{"list", 4, "list      "},
+		{"列表", 4, "列表      "},
+		{"リスト", 6, "リスト    "},
+		{"café", 4, "café      "},
+		{"cafe\u0301", 4, "cafe\u0301      "},
+		{"toolongname", 11, "toolongname"},
+	}
+	for _, tc := range tests {
+		if w := displayWidth(tc.s); w != tc.width {
+			t.Errorf("Expected display width %d for %q, got %d", tc.width, tc.s, w)
+		}
+		if out := rpad(tc.s, 10); out != tc.out {
+			t.Errorf("Expected %q padded to %q, got %q", tc.s, tc.out, out)
+		}
+	}
+}
+
+func TestDisplayWidthSkipsANSISequences(t *testing.T) {
+	tests := []struct {
+		s     string
+		width int
+	}{
+		{"\x1b[1;31mdeploy\x1b[0m", 6},
+		{"\x1b]8;;https://example.com\x1b\\docs\x1b]8;;\x1b\\", 4},
+		{"\x1b]8;;https://example.com\adocs\x1b]8;;\a", 4},
+		{"\x1b[32m列表\x1b[0m", 4},
+	}
+	for _, tc := range tests {
+		if w := displayWidth(tc.s); w != tc.width {
+			t.Errorf("Expected display width %d for %q, got %d", tc.width, tc.s, w)
+		}
+	}
+	if out := rpad("\x1b[1mrun\x1b[0m", 5); out != "\x1b[1mrun\x1b[0m  " {
+		t.Errorf("Expected rpad to ignore the ANSI sequences, got %q", out)
+	}
+}
+
+func TestWrapTemplateFunc(t *testing.T) {
+	c := &Command{Use: "c", Long: "\x1b[1mDeploy\x1b[0m the application to the \x1b[32mcluster\x1b[0m\n  of the current context"}
+	c.SetUsageTemplate(`{{wrap 20 .Long}}`)
+
+	expected := "\x1b[1mDeploy\x1b[0m the\n" +
+		"application to the\n" +
+		"\x1b[32mcluster\x1b[0m\n" +
+		"  of the current\n" +
+		"  context"
+	if got := c.UsageString(); got != expected {
+		t.Errorf("Expected:\n%q\nGot:\n%q", expected, got)
+	}
+}
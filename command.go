@@ -17,22 +17,52 @@ package cobra
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"io"
 	"os"
+	"path"
 	"path/filepath"
 	"sort"
 	"strings"
+	"text/template"
 
 	flag "github.com/spf13/pflag"
 )
 
 var ErrSubCommandRequired = errors.New("subcommand is required")
 
+// AnnotationAllowOverride is the annotation that lets a command or a flag replace
+// a sibling command or an inherited persistent flag of the same name when
+// EnableCollisionChecks is set.
+const AnnotationAllowOverride = "cobra_annotation_allow_override"
+
 // FParseErrWhitelist configures Flag parse errors to be ignored
 type FParseErrWhitelist flag.ParseErrorsWhitelist
 
+// HelpCommandOptions configures the default help command, e.g. to localize it.
+// Empty fields keep their default value.
+type HelpCommandOptions struct {
+	// Name is the name of the help command, "help" by default.
+	Name string
+	// Aliases are the aliases of the help command.
+	Aliases []string
+	// Short is the short description of the help command.
+	Short string
+	// Long is the long description of the help command.
+	Long string
+	// AllFlag adds an --all flag to the help command, printing the tree of the
+	// commands with their descriptions and flags, see HelpTree.
+	AllFlag bool
+	// SearchFlag adds a --search flag to the help command, listing the commands
+	// matching keywords, see SearchCommands.
+	SearchFlag bool
+	// GroupID is the ID of the group under which the help command is listed, see
+	// AddGroup.
+	GroupID string
+}
+
 // Command is just that, a command for your application.
 // E.g.  'go run ...' - 'run' is the command. Cobra requires
 // you to define the usage and description as part of your command
@@ -48,6 +78,10 @@ type Command struct {
 	// similar to aliases but only suggests.
 	SuggestFor []string
 
+	// GroupID is the ID of the group, added to the parent with AddGroup, under which
+	// the command is listed in the help of its parent.
+	GroupID string
+
 	// Short is the short description shown in the 'help' output.
 	Short string
 
@@ -63,6 +97,10 @@ type Command struct {
 	// Expected arguments
 	Args PositionalArgs
 
+	// ArgsPolicy defines how arguments are validated for commands of the tree which do
+	// not set Args. It is only consulted on the root command.
+	ArgsPolicy ArgsPolicy
+
 	// ArgAliases is List of aliases for ValidArgs.
 	// These are not suggested to the user in the bash completion,
 	// but accepted if entered manually.
@@ -114,6 +152,19 @@ type Command struct {
 	PersistentPostRun func(cmd *Command, args []string)
 	// PersistentPostRunE: PersistentPostRun but returns an error.
 	PersistentPostRunE func(cmd *Command, args []string) error
+	// RollbackE: undoes the effects of a successful execution when a later invocation
+	// of the same chain fails, see ExecuteChain. It is called with the args and the flags
+	// of the execution.
+	RollbackE func(cmd *Command, args []string) error
+
+	// Dangerous marks the command as destructive: its execution must be confirmed, either
+	// by the user answering a prompt or with the --yes flag. See SetConfirmFunc.
+	Dangerous bool
+
+	// MutatingPostRun marks the PostRun and PersistentPostRun hooks of this command as
+	// having side effects, so they are skipped when the --dry-run flag added by
+	// AddDryRunFlag is set.
+	MutatingPostRun bool
 
 	// SilenceErrors is an option to quiet errors down stream.
 	SilenceErrors bool
@@ -133,6 +184,15 @@ type Command struct {
 	// line of a command when printing help or generating docs
 	DisableFlagsInUseLine bool
 
+	// HelpFlagsLimit limits the number of flags listed in each section of the help of
+	// this command and of its subcommands, the other flags being counted on a last
+	// line. It keeps the help of commands with thousands of flags short and fast.
+	// Zero lists all the flags.
+	HelpFlagsLimit int
+	// HelpFlagsWidth wraps the usages of the flags in the help of this command and of
+	// its subcommands to that number of columns. Zero doesn't wrap them.
+	HelpFlagsWidth int
+
 	// DisableSuggestions disables the suggestions based on Levenshtein distance
 	// that go along with 'unknown command' messages.
 	DisableSuggestions bool
@@ -143,9 +203,50 @@ type Command struct {
 	// TraverseChildren parses flags on all parents before executing child command.
 	TraverseChildren bool
 
+	// WindowsFlagSyntax makes this command and its subcommands also accept flags written
+	// like the ones of Windows tools: "/name:value" for "--name=value", "/name" for
+	// "--name" and "/n" for "-n". Arguments which don't name a flag, e.g. paths, are
+	// left unchanged. See WindowsFlagUsages to display that syntax in the help.
+	WindowsFlagSyntax bool
+
+	// SingleDashLongFlags makes this command and its subcommands also accept long flags
+	// given with a single dash, e.g. "-output json" for "--output json", when they are
+	// unambiguous: arguments whose first letter is a shorthand are parsed as shorthands.
+	// The help and the bash completion show the accepted single-dash flags.
+	SingleDashLongFlags bool
+
+	// AbbreviatedFlags makes this command and its subcommands also accept unambiguous
+	// abbreviations of the long flags, like GNU tools: "--verb" for "--verbose" when no
+	// other flag starts with "verb". Ambiguous abbreviations are reported with their
+	// candidates. The completion still offers the full names.
+	AbbreviatedFlags bool
+
+	// Feature is the key of the feature flag gating this command and its subcommands,
+	// see SetFeatureProvider. When the feature is disabled, they are hidden and invoking
+	// them returns an error.
+	Feature string
+
+	// StrictPOSIX makes this command follow the POSIX utility conventions: the flags
+	// must precede the operands, everything following the first operand or "--" being
+	// an operand, and long flags can't be given with a single dash, e.g. "-name".
+	StrictPOSIX bool
+
+	// RunOnUnknownSubcommand makes a runnable command with subcommands run itself with
+	// the given arguments when the first one is not a subcommand. An unknown command
+	// error is still returned if the argument looks like a mistyped subcommand.
+	RunOnUnknownSubcommand bool
+
+	// FlagNamespace is prepended to the long names of the persistent flags of this
+	// command and its subcommands when it is added to a parent, and their shorthands
+	// are dropped. It avoids collisions when embedding a third-party command tree.
+	FlagNamespace string
+
 	//FParseErrWhitelist flag parse errors to be ignored
 	FParseErrWhitelist FParseErrWhitelist
 
+	// HelpCommandOptions configures the default help command added to this command.
+	HelpCommandOptions HelpCommandOptions
+
 	// commands is the list of commands supported by this program.
 	commands []*Command
 	// parent is a parent command for this command.
@@ -164,6 +265,10 @@ type Command struct {
 
 	// args is actual args parsed from flags.
 	args []string
+	// frozen is true once the command tree has been frozen by Freeze.
+	frozen bool
+	// flagsNamespaced is true once FlagNamespace has been applied.
+	flagsNamespaced bool
 	// flagErrorBuf contains all error messages from pflag.
 	flagErrorBuf *bytes.Buffer
 	// flags is full set of flags.
@@ -176,6 +281,8 @@ type Command struct {
 	iflags *flag.FlagSet
 	// parentsPflags is all persistent flags of cmd's parents.
 	parentsPflags *flag.FlagSet
+	// inheritedFlagOverlays overrides how inherited flags are displayed for this command.
+	inheritedFlagOverlays map[string]*inheritedFlagOverlay
 	// globNormFunc is the global normalization function
 	// that we can use on every pflag set and children commands
 	globNormFunc func(f *flag.FlagSet, name string) flag.NormalizedName
@@ -184,18 +291,61 @@ type Command struct {
 	usageFunc func(*Command) error
 	// usageTemplate is usage template defined by user.
 	usageTemplate string
+	// subtreeUsageTemplate is the usage template of the subtree defined by user.
+	subtreeUsageTemplate string
 	// flagErrorFunc is func defined by user and it's called when the parsing of
 	// flags returns an error.
 	flagErrorFunc func(*Command, error) error
 	// helpTemplate is help template defined by user.
 	helpTemplate string
+	// subtreeHelpTemplate is the help template of the subtree defined by user.
+	subtreeHelpTemplate string
 	// helpFunc is help func defined by user.
 	helpFunc func(*Command, []string)
+	// helpHooks are the funcs given to OnHelp.
+	helpHooks []func(*Command, []string)
+	// helpSections are the funcs given to AddHelpSection.
+	helpSections []func(*Command) (string, string)
 	// helpCommand is command with usage 'help'. If it's not defined by user,
 	// cobra uses default help command.
 	helpCommand *Command
+	// templateFuncs are the template funcs added to this command.
+	templateFuncs template.FuncMap
 	// versionTemplate is the version template defined by user.
 	versionTemplate string
+	// confirmFunc is confirm func defined by user.
+	confirmFunc func(*Command) (bool, error)
+	// auditFunc is audit func defined by user.
+	auditFunc func(*Invocation)
+	// presetDir is the directory of the presets given to AddPresetFlags.
+	presetDir string
+	// phase is the phase reached by the last execution.
+	phase Phase
+	// exitCodes are the exit codes given to SetExitCodes.
+	exitCodes *ExitCodes
+	// visibilityFunc is the func given to SetVisibilityFunc.
+	visibilityFunc func(*Command) bool
+	// featureProvider is the provider given to SetFeatureProvider.
+	featureProvider FeatureProvider
+	// updateCheck are the options given to AddUpdateCheck.
+	updateCheck *UpdateCheckOptions
+	// pendingUpdate is the update check started by the last execution, if any.
+	pendingUpdate *pendingUpdateCheck
+	// panicHandler is the func given to SetPanicHandler.
+	panicHandler func(interface{}, []byte, *Command) error
+	// commandGroups are the groups given to AddGroup.
+	commandGroups []*Group
+	// flagGroups are the groups given to MarkFlagsMutuallyExclusive and
+	// MarkFlagsRequiredTogether.
+	flagGroups []flagGroup
+	// flagSources are the sources given to SetFlagFromSource, by flag name.
+	flagSources map[string]string
+	// historySize is the size given to AddHistoryCommand.
+	historySize int
+	// ctx is the context given to ExecuteWithEnv.
+	ctx context.Context
+	// env is the environment given to ExecuteWithEnv.
+	env map[string]string
 
 	// inReader is a reader defined by the user that replaces stdin
 	inReader io.Reader
@@ -203,6 +353,10 @@ type Command struct {
 	outWriter io.Writer
 	// errWriter is a writer defined by the user that replaces stderr
 	errWriter io.Writer
+	// helpWriter is a writer defined by the user that receives the help
+	helpWriter io.Writer
+	// usageWriter is a writer defined by the user that receives the usage
+	usageWriter io.Writer
 }
 
 // SetArgs sets arguments for the command. It is set to os.Args[1:] by default, if desired, can be overridden
@@ -231,6 +385,19 @@ func (c *Command) SetErr(newErr io.Writer) {
 	c.errWriter = newErr
 }
 
+// SetHelpOutput sets the destination of the help of this command and its subcommands.
+// If newHelp is nil, the output set by SetOut is used.
+func (c *Command) SetHelpOutput(newHelp io.Writer) {
+	c.helpWriter = newHelp
+}
+
+// SetUsageOutput sets the destination of the usage of this command and its subcommands,
+// including the usage printed after an error.
+// If newUsage is nil, the output set by SetOut is used, falling back to Stderr.
+func (c *Command) SetUsageOutput(newUsage io.Writer) {
+	c.usageWriter = newUsage
+}
+
 // SetIn sets the source for input data
 // If newIn is nil, os.Stdin is used.
 func (c *Command) SetIn(newIn io.Reader) {
@@ -247,8 +414,16 @@ func (c *Command) SetUsageTemplate(s string) {
 	c.usageTemplate = s
 }
 
+// SetUsageTemplateForSubtree sets the usage template of this command and of all its
+// subcommands, taking precedence over the templates set with SetUsageTemplate on any
+// of them or on the parents, so that e.g. a plugin subtree keeps its look when the
+// root standardizes the templates of the other commands.
+func (c *Command) SetUsageTemplateForSubtree(s string) {
+	c.subtreeUsageTemplate = s
+}
+
 // SetFlagErrorFunc sets a function to generate an error when flag parsing
-// fails.
+// fails. The errors of the flag parser are given as a *FlagParseError.
 func (c *Command) SetFlagErrorFunc(f func(*Command, error) error) {
 	c.flagErrorFunc = f
 }
@@ -258,6 +433,22 @@ func (c *Command) SetHelpFunc(f func(*Command, []string)) {
 	c.helpFunc = f
 }
 
+// OnHelp registers functions called after the help of this command or of one of its
+// subcommands is displayed, by the help flag, the help command or Help, e.g. to log
+// help usage or append dynamic content to the help. The functions of the parents are
+// called first.
+func (c *Command) OnHelp(f ...func(cmd *Command, args []string)) {
+	c.helpHooks = append(c.helpHooks, f...)
+}
+
+// AddHelpSection registers functions returning sections appended to the default help
+// of this command and of its subcommands, after the help template, e.g. "Active
+// configuration". Sections with an empty body are omitted. The sections of the
+// parents come first.
+func (c *Command) AddHelpSection(f ...func(cmd *Command) (title, body string)) {
+	c.helpSections = append(c.helpSections, f...)
+}
+
 // SetHelpCommand sets help command.
 func (c *Command) SetHelpCommand(cmd *Command) {
 	c.helpCommand = cmd
@@ -268,6 +459,12 @@ func (c *Command) SetHelpTemplate(s string) {
 	c.helpTemplate = s
 }
 
+// SetHelpTemplateForSubtree sets the help template of this command and of all its
+// subcommands, like SetUsageTemplateForSubtree does for the usage template.
+func (c *Command) SetHelpTemplateForSubtree(s string) {
+	c.subtreeHelpTemplate = s
+}
+
 // SetVersionTemplate sets version template to be used. Application can use it to set custom template.
 func (c *Command) SetVersionTemplate(s string) {
 	c.versionTemplate = s
@@ -300,6 +497,28 @@ func (c *Command) ErrOrStderr() io.Writer {
 	return c.getErr(os.Stderr)
 }
 
+// HelpOutput returns the destination of the help, as set by SetHelpOutput on this
+// command or a parent, falling back to OutOrStdout.
+func (c *Command) HelpOutput() io.Writer {
+	for p := c; p != nil; p = p.parent {
+		if p.helpWriter != nil {
+			return p.helpWriter
+		}
+	}
+	return c.OutOrStdout()
+}
+
+// UsageOutput returns the destination of the usage, as set by SetUsageOutput on this
+// command or a parent, falling back to OutOrStderr.
+func (c *Command) UsageOutput() io.Writer {
+	for p := c; p != nil; p = p.parent {
+		if p.usageWriter != nil {
+			return p.usageWriter
+		}
+	}
+	return c.OutOrStderr()
+}
+
 // InOrStdin returns output to stderr
 func (c *Command) InOrStdin() io.Reader {
 	return c.getIn(os.Stdin)
@@ -346,9 +565,9 @@ func (c *Command) UsageFunc() (f func(*Command) error) {
 	}
 	return func(c *Command) error {
 		c.mergePersistentFlags()
-		err := tmpl(c.OutOrStderr(), c.UsageTemplate(), c)
+		err := tmpl(c.UsageOutput(), c.UsageTemplate(), c)
 		if err != nil {
-			c.Println(err)
+			c.PrintErrln(err)
 		}
 		return err
 	}
@@ -361,6 +580,48 @@ func (c *Command) Usage() error {
 	return c.UsageFunc()(c)
 }
 
+// AddTemplateFunc adds a template function available to the Usage, Help and Version
+// templates of this command and its subcommands. Unlike the package level
+// AddTemplateFunc, it does not affect the commands of other libraries.
+func (c *Command) AddTemplateFunc(name string, tmplFunc interface{}) {
+	c.AddTemplateFuncs(template.FuncMap{name: tmplFunc})
+}
+
+// AddTemplateFuncs adds multiple template functions available to the Usage, Help and
+// Version templates of this command and its subcommands.
+func (c *Command) AddTemplateFuncs(tmplFuncs template.FuncMap) {
+	templateFuncsMu.Lock()
+	defer templateFuncsMu.Unlock()
+	if c.templateFuncs == nil {
+		c.templateFuncs = make(template.FuncMap)
+	}
+	for k, v := range tmplFuncs {
+		c.templateFuncs[k] = v
+	}
+}
+
+// TemplateFuncs returns the template functions available to the templates of this
+// command: the package level ones, overridden by the ones added to its parents, then
+// by its own.
+func (c *Command) TemplateFuncs() template.FuncMap {
+	templateFuncsMu.RLock()
+	defer templateFuncsMu.RUnlock()
+	funcs := make(template.FuncMap, len(templateFuncs))
+	for k, v := range templateFuncs {
+		funcs[k] = v
+	}
+	var chain []*Command
+	for p := c; p != nil; p = p.parent {
+		chain = append(chain, p)
+	}
+	for i := len(chain) - 1; i >= 0; i-- {
+		for k, v := range chain[i].templateFuncs {
+			funcs[k] = v
+		}
+	}
+	return funcs
+}
+
 // HelpFunc returns either the function set by SetHelpFunc for this command
 // or a parent, or it returns a function with default help behavior.
 func (c *Command) HelpFunc() func(*Command, []string) {
@@ -374,10 +635,27 @@ func (c *Command) HelpFunc() func(*Command, []string) {
 		c.mergePersistentFlags()
 		// The help should be sent to stdout
 		// See https://github.com/spf13/cobra/issues/1002
-		err := tmpl(c.OutOrStdout(), c.HelpTemplate(), c)
+		err := tmpl(c.HelpOutput(), c.HelpTemplate(), c)
 		if err != nil {
-			c.Println(err)
+			c.PrintErrln(err)
 		}
+		c.writeHelpSections(c.HelpOutput(), c)
+	}
+}
+
+// writeHelpSections writes to w the sections of the help of cmd registered with
+// AddHelpSection on c and its parents, their bodies indented like the flags.
+func (c *Command) writeHelpSections(w io.Writer, cmd *Command) {
+	if c.HasParent() {
+		c.Parent().writeHelpSections(w, cmd)
+	}
+	for _, f := range c.helpSections {
+		title, body := f(cmd)
+		body = strings.TrimRight(body, "\n")
+		if body == "" {
+			continue
+		}
+		fmt.Fprintf(w, "\n%s:\n  %s\n", title, strings.Replace(body, "\n", "\n  ", -1))
 	}
 }
 
@@ -385,25 +663,44 @@ func (c *Command) HelpFunc() func(*Command, []string) {
 // Used when a user calls help [command].
 // Can be defined by user by overriding HelpFunc.
 func (c *Command) Help() error {
-	c.HelpFunc()(c, []string{})
+	c.displayHelp([]string{})
 	return nil
 }
 
+// displayHelp displays the help of c with its HelpFunc, then calls the functions
+// registered with OnHelp.
+func (c *Command) displayHelp(args []string) {
+	c.HelpFunc()(c, args)
+	c.runHelpHooks(c, args)
+}
+
+func (c *Command) runHelpHooks(cmd *Command, args []string) {
+	if c.HasParent() {
+		c.Parent().runHelpHooks(cmd, args)
+	}
+	for _, f := range c.helpHooks {
+		f(cmd, args)
+	}
+}
+
 // UsageString returns usage string.
 func (c *Command) UsageString() string {
 	// Storing normal writers
 	tmpOutput := c.outWriter
 	tmpErr := c.errWriter
+	tmpUsage := c.usageWriter
 
 	bb := new(bytes.Buffer)
 	c.outWriter = bb
 	c.errWriter = bb
+	c.usageWriter = bb
 
 	c.Usage()
 
 	// Setting things back to normal
 	c.outWriter = tmpOutput
 	c.errWriter = tmpErr
+	c.usageWriter = tmpUsage
 
 	return bb.String()
 }
@@ -456,6 +753,9 @@ func (c *Command) NamePadding() int {
 
 // UsageTemplate returns usage template for the command.
 func (c *Command) UsageTemplate() string {
+	if s := c.subtreeTemplate(func(p *Command) string { return p.subtreeUsageTemplate }); s != "" {
+		return s
+	}
 	if c.usageTemplate != "" {
 		return c.usageTemplate
 	}
@@ -473,14 +773,25 @@ Aliases:
 Examples:
 {{.Example}}{{end}}{{if .HasAvailableSubCommands}}
 
-Available Commands:{{range .Commands}}{{if (or .IsAvailableCommand (eq .Name "help"))}}
-  {{rpad .Name .NamePadding }} {{.Short}}{{end}}{{end}}{{end}}{{if .HasAvailableLocalFlags}}
+{{- $cmds := .Commands}}{{if eq (len .Groups) 0}}
+
+Available Commands:{{range $cmds}}{{if (or .IsAvailableCommand .IsHelpCommand)}}
+  {{rpad .Name .NamePadding }} {{.Short}}{{end}}{{end}}{{else}}{{range $group := .Groups}}
+
+{{.Title}}{{range $cmds}}{{if (and (eq .GroupID $group.ID) (or .IsAvailableCommand .IsHelpCommand))}}
+  {{rpad .Name .NamePadding }} {{.Short}}{{end}}{{end}}{{end}}{{if not .AllChildCommandsHaveGroup}}
+
+Additional Commands:{{range $cmds}}{{if (and (eq .GroupID "") (or .IsAvailableCommand .IsHelpCommand))}}
+  {{rpad .Name .NamePadding }} {{.Short}}{{end}}{{end}}{{end}}{{end}}{{end}}{{if .HasAvailableLocalFlags}}
 
 Flags:
-{{.LocalFlags.FlagUsages | trimTrailingWhitespaces}}{{end}}{{if .HasAvailableInheritedFlags}}
+{{.LocalFlagUsages | trimTrailingWhitespaces}}{{end}}{{if .HasAvailableInheritedFlags}}
 
 Global Flags:
-{{.InheritedFlags.FlagUsages | trimTrailingWhitespaces}}{{end}}{{if .HasHelpSubCommands}}
+{{.InheritedFlagUsages | trimTrailingWhitespaces}}{{end}}{{if .HasFlagGroups}}
+
+Flag Groups:
+{{.FlagGroupUsages | trimTrailingWhitespaces}}{{end}}{{if .HasHelpSubCommands}}
 
 Additional help topics:{{range .Commands}}{{if .IsAdditionalHelpTopicCommand}}
   {{rpad .CommandPath .CommandPathPadding}} {{.Short}}{{end}}{{end}}{{end}}{{if .HasAvailableSubCommands}}
@@ -491,6 +802,9 @@ Use "{{.CommandPath}} [command] --help" for more information about a command.{{e
 
 // HelpTemplate return help template for the command.
 func (c *Command) HelpTemplate() string {
+	if s := c.subtreeTemplate(func(p *Command) string { return p.subtreeHelpTemplate }); s != "" {
+		return s
+	}
 	if c.helpTemplate != "" {
 		return c.helpTemplate
 	}
@@ -503,6 +817,17 @@ func (c *Command) HelpTemplate() string {
 {{end}}{{if or .Runnable .HasSubCommands}}{{.UsageString}}{{end}}`
 }
 
+// subtreeTemplate returns the template returned by template for the nearest of c and
+// its parents, or an empty string if none was set for their subtrees.
+func (c *Command) subtreeTemplate(template func(*Command) string) string {
+	for p := c; p != nil; p = p.parent {
+		if s := template(p); s != "" {
+			return s
+		}
+	}
+	return ""
+}
+
 // VersionTemplate return version template for the command.
 func (c *Command) VersionTemplate() string {
 	if c.versionTemplate != "" {
@@ -544,34 +869,43 @@ func stripFlags(args []string, c *Command) []string {
 
 	commands := []string{}
 	flags := c.Flags()
+	for {
+		s, rest := nextNonFlagArg(args, flags)
+		if s == "" {
+			return commands
+		}
+		commands = append(commands, s)
+		args = rest
+	}
+}
 
-Loop:
+// nextNonFlagArg returns the first argument of args which is neither a flag nor the
+// value of a flag, and the arguments following it. It returns an empty string and no
+// arguments if there is none. The persistent flags must have been merged.
+func nextNonFlagArg(args []string, flags *flag.FlagSet) (string, []string) {
 	for len(args) > 0 {
 		s := args[0]
 		args = args[1:]
 		switch {
 		case s == "--":
 			// "--" terminates the flags
-			break Loop
+			return "", nil
 		case strings.HasPrefix(s, "--") && !strings.Contains(s, "=") && !hasNoOptDefVal(s[2:], flags):
 			// If '--flag arg' then
 			// delete arg from args.
 			fallthrough // (do the same as below)
 		case strings.HasPrefix(s, "-") && !strings.Contains(s, "=") && len(s) == 2 && !shortHasNoOptDefVal(s[1:], flags):
 			// If '-f arg' then
-			// delete 'arg' from args or break the loop if len(args) <= 1.
+			// delete 'arg' from args or stop if len(args) <= 1.
 			if len(args) <= 1 {
-				break Loop
-			} else {
-				args = args[1:]
-				continue
+				return "", nil
 			}
+			args = args[1:]
 		case s != "" && !strings.HasPrefix(s, "-"):
-			commands = append(commands, s)
+			return s, args
 		}
 	}
-
-	return commands
+	return "", nil
 }
 
 // argsMinusFirstX removes only the first x from args.  Otherwise, commands that look like
@@ -579,7 +913,7 @@ Loop:
 func argsMinusFirstX(args []string, x string) []string {
 	for i, y := range args {
 		if x == y {
-			ret := []string{}
+			ret := make([]string, 0, len(args)-1)
 			ret = append(ret, args[:i]...)
 			ret = append(ret, args[i+1:]...)
 			return ret
@@ -599,22 +933,35 @@ func (c *Command) Find(args []string) (*Command, []string, error) {
 	var innerfind func(*Command, []string) (*Command, []string)
 
 	innerfind = func(c *Command, innerArgs []string) (*Command, []string) {
-		argsWOflags := stripFlags(innerArgs, c)
-		if len(argsWOflags) == 0 {
+		if len(innerArgs) == 0 {
 			return c, innerArgs
 		}
-		nextSubCmd := argsWOflags[0]
+		// The subcommands normalize their arguments again, knowing all their flags.
+		normalized := c.normalizeFlagArgs(innerArgs)
+		c.mergePersistentFlags()
+		nextSubCmd, _ := nextNonFlagArg(normalized, c.Flags())
+		if nextSubCmd == "" {
+			return c, normalized
+		}
 
 		cmd := c.findNext(nextSubCmd)
 		if cmd != nil {
 			return innerfind(cmd, argsMinusFirstX(innerArgs, nextSubCmd))
 		}
-		return c, innerArgs
+		return c, normalized
 	}
 
 	commandFound, a := innerfind(c, args)
+	if commandFound.RunOnUnknownSubcommand && commandFound.Runnable() && commandFound.HasSubCommands() {
+		if argsWOflags := stripFlags(a, commandFound); len(argsWOflags) > 0 {
+			if suggestions := commandFound.findSuggestions(argsWOflags[0]); suggestions != "" {
+				return commandFound, a, fmt.Errorf("unknown command %q for %q%s", argsWOflags[0], commandFound.CommandPath(), suggestions)
+			}
+			return commandFound, a, nil
+		}
+	}
 	if commandFound.Args == nil {
-		return commandFound, a, legacyArgs(commandFound, stripFlags(a, commandFound))
+		return commandFound, a, c.Root().ArgsPolicy.validate(commandFound, stripFlags(a, commandFound))
 	}
 	return commandFound, a, nil
 }
@@ -633,9 +980,90 @@ func (c *Command) findSuggestions(arg string) string {
 			suggestionsString += fmt.Sprintf("\t%v\n", s)
 		}
 	}
+	if EnableAproposSuggestions {
+		if related := c.aproposSuggestionsFor(arg); len(related) > 0 {
+			if suggestionsString == "" {
+				suggestionsString = "\n"
+			}
+			suggestionsString += "\nRelated commands:\n"
+			for _, s := range related {
+				suggestionsString += fmt.Sprintf("\t%v\n", s)
+			}
+		}
+	}
 	return suggestionsString
 }
 
+// maxAproposSuggestions is the maximum number of related commands suggested.
+const maxAproposSuggestions = 5
+
+// aproposSuggestionsFor returns the paths, relative to c, of the commands of the tree
+// of c whose descriptions mention typedName, the most relevant first.
+func (c *Command) aproposSuggestionsFor(typedName string) []string {
+	if len(typedName) < 3 {
+		return nil
+	}
+	suggested := map[string]bool{}
+	for _, s := range c.SuggestionsFor(typedName) {
+		suggested[s] = true
+	}
+	var related []string
+	for _, cmd := range c.SearchCommands(typedName) {
+		path := strings.TrimPrefix(cmd.CommandPath(), c.CommandPath()+" ")
+		if cmd == c || suggested[path] {
+			continue
+		}
+		related = append(related, path)
+		if len(related) == maxAproposSuggestions {
+			break
+		}
+	}
+	return related
+}
+
+// addFlagPlacementHint extends an unknown flag error with the paths of the
+// commands which define that flag, if any.
+func (c *Command) addFlagPlacementHint(err error) error {
+	var name, shorthand string
+	msg := err.Error()
+	switch {
+	case strings.HasPrefix(msg, "unknown flag: --"):
+		name = strings.TrimPrefix(msg, "unknown flag: --")
+	case strings.HasPrefix(msg, "unknown shorthand flag: '"):
+		shorthand = msg[len("unknown shorthand flag: '"):]
+		shorthand = shorthand[:strings.Index(shorthand, "'")]
+	default:
+		return err
+	}
+
+	var paths []string
+	var visit func(*Command)
+	visit = func(x *Command) {
+		if x != c {
+			local := x.LocalFlags()
+			if (name != "" && local.Lookup(name) != nil) ||
+				(shorthand != "" && local.ShorthandLookup(shorthand) != nil) {
+				paths = append(paths, fmt.Sprintf("%q", x.CommandPath()))
+			}
+		}
+		for _, sub := range x.commands {
+			if sub.IsVisible() {
+				visit(sub)
+			}
+		}
+	}
+	visit(c.Root())
+
+	if len(paths) == 0 {
+		return err
+	}
+	typed := "--" + name
+	if shorthand != "" {
+		typed = "-" + shorthand
+	}
+	return fmt.Errorf("%s\n%q is accepted by %s", msg, typed, strings.Join(paths, ", "))
+}
+
 func (c *Command) findNext(next string) *Command {
 	matches := make([]*Command, 0)
 	for _, cmd := range c.commands {
@@ -658,6 +1086,9 @@ func (c *Command) findNext(next string) *Command {
 // Traverse the command tree to find the command, and parse args for
 // each parent.
 func (c *Command) Traverse(args []string) (*Command, []string, error) {
+	// The subcommands normalize their arguments again, knowing all their flags.
+	original := args
+	args = c.normalizeFlagArgs(args)
 	flags := []string{}
 	inFlag := false
 
@@ -693,7 +1124,7 @@ func (c *Command) Traverse(args []string) (*Command, []string, error) {
 		if err := c.ParseFlags(flags); err != nil {
 			return nil, args, err
 		}
-		return cmd.Traverse(args[i+1:])
+		return cmd.Traverse(original[i+1:])
 	}
 	return c, args, nil
 }
@@ -754,10 +1185,12 @@ func (c *Command) execute(a []string) (err error) {
 	// overriding
 	c.InitDefaultHelpFlag()
 	c.InitDefaultVersionFlag()
+	c.InitDefaultYesFlag()
 
+	c.phase = PhaseFlags
 	err = c.ParseFlags(a)
 	if err != nil {
-		return c.FlagErrorFunc()(c, err)
+		return c.FlagErrorFunc()(c, c.newFlagParseError(err, a))
 	}
 
 	// If help is called, regardless of other flags, return we want help.
@@ -766,7 +1199,7 @@ func (c *Command) execute(a []string) (err error) {
 	if err != nil {
 		// should be impossible to get here as we always declare a help
 		// flag in InitDefaultHelpFlag()
-		c.Println("\"help\" flag declared as non-bool. Please correct your code")
+		c.PrintErrln("\"help\" flag declared as non-bool. Please correct your code")
 		return err
 	}
 
@@ -778,33 +1211,51 @@ func (c *Command) execute(a []string) (err error) {
 	if c.Version != "" {
 		versionVal, err := c.Flags().GetBool("version")
 		if err != nil {
-			c.Println("\"version\" flag declared as non-bool. Please correct your code")
+			c.PrintErrln("\"version\" flag declared as non-bool. Please correct your code")
 			return err
 		}
 		if versionVal {
 			err := tmpl(c.OutOrStdout(), c.VersionTemplate(), c)
 			if err != nil {
-				c.Println(err)
+				c.PrintErrln(err)
 			}
 			return err
 		}
 	}
 
+	argWoFlags := c.Flags().Args()
+	if c.DisableFlagParsing {
+		argWoFlags = a
+	}
+
 	if !c.Runnable() {
+		// Validators like RequireSubcommand report a clearer error than the help.
+		if c.Args != nil {
+			c.phase = PhaseArgs
+			if err := c.ValidateArgs(argWoFlags); err != nil {
+				return err
+			}
+		}
+		c.phase = PhaseResolve
 		return ErrSubCommandRequired
 	}
 
-	c.preRun()
-
-	argWoFlags := c.Flags().Args()
-	if c.DisableFlagParsing {
-		argWoFlags = a
+	if err := c.applyPresets(); err != nil {
+		return err
+	}
+	if err := c.applyFlagEnv(); err != nil {
+		return err
 	}
+	c.startUpdateCheck()
 
+	c.preRun()
+
+	c.phase = PhaseArgs
 	if err := c.ValidateArgs(argWoFlags); err != nil {
 		return err
 	}
 
+	c.phase = PhaseRun
 	for p := c; p != nil; p = p.Parent() {
 		if p.PersistentPreRunE != nil {
 			if err := p.PersistentPreRunE(c, argWoFlags); err != nil {
@@ -824,9 +1275,24 @@ func (c *Command) execute(a []string) (err error) {
 		c.PreRun(c, argWoFlags)
 	}
 
+	c.phase = PhaseArgs
 	if err := c.validateRequiredFlags(); err != nil {
 		return err
 	}
+	if err := c.validateConditionalFlags(); err != nil {
+		return err
+	}
+	if err := c.validateFlagGroups(); err != nil {
+		return err
+	}
+	if err := c.validateFlagFeatures(); err != nil {
+		return err
+	}
+	c.phase = PhaseConfirm
+	if err := c.confirm(); err != nil {
+		return err
+	}
+	c.phase = PhaseRun
 	if c.RunE != nil {
 		if err := c.RunE(c, argWoFlags); err != nil {
 			return err
@@ -834,14 +1300,21 @@ func (c *Command) execute(a []string) (err error) {
 	} else {
 		c.Run(c, argWoFlags)
 	}
-	if c.PostRunE != nil {
-		if err := c.PostRunE(c, argWoFlags); err != nil {
-			return err
+	// post run hooks with side effects are skipped in dry-run mode
+	dryRun := c.DryRun()
+	if !dryRun || !c.MutatingPostRun {
+		if c.PostRunE != nil {
+			if err := c.PostRunE(c, argWoFlags); err != nil {
+				return err
+			}
+		} else if c.PostRun != nil {
+			c.PostRun(c, argWoFlags)
 		}
-	} else if c.PostRun != nil {
-		c.PostRun(c, argWoFlags)
 	}
 	for p := c; p != nil; p = p.Parent() {
+		if dryRun && p.MutatingPostRun && (p.PersistentPostRunE != nil || p.PersistentPostRun != nil) {
+			break
+		}
 		if p.PersistentPostRunE != nil {
 			if err := p.PersistentPostRunE(c, argWoFlags); err != nil {
 				return err
@@ -877,6 +1350,10 @@ func (c *Command) ExecuteC() (cmd *Command, err error) {
 		return c.Root().ExecuteC()
 	}
 
+	if c.frozen {
+		return c, fmt.Errorf("command %q is frozen, execute a copy returned by Instantiate", c.Name())
+	}
+
 	// windows hook
 	if preExecHookFn != nil {
 		preExecHookFn(c)
@@ -885,6 +1362,7 @@ func (c *Command) ExecuteC() (cmd *Command, err error) {
 	// initialize help as the last point possible to allow for user
 	// overriding
 	c.InitDefaultHelpCmd()
+	c.checkCommandGroups()
 
 	args := c.args
 
@@ -899,14 +1377,21 @@ func (c *Command) ExecuteC() (cmd *Command, err error) {
 	} else {
 		cmd, flags, err = c.Find(args)
 	}
+	if err == nil {
+		cmd, err = checkVisible(cmd)
+	}
+	if err == nil {
+		err = checkFeature(cmd)
+	}
 	if err != nil {
 		// If found parse to a subcommand and then failed, talk about the subcommand
 		if cmd != nil {
 			c = cmd
 		}
+		c.phase = PhaseResolve
 		if !c.SilenceErrors {
-			c.Println("Error:", err.Error())
-			c.Printf("Run '%v --help' for usage.\n", c.CommandPath())
+			c.PrintErrln("Error:", err.Error())
+			c.PrintErrf("Run '%v --help' for usage.\n", c.CommandPath())
 		}
 		return c, err
 	}
@@ -916,12 +1401,18 @@ func (c *Command) ExecuteC() (cmd *Command, err error) {
 		cmd.commandCalledAs.name = cmd.Name()
 	}
 
-	err = cmd.execute(flags)
+	err = cmd.executeRecovering(flags)
+	cmd.audit(err)
+	if err == nil {
+		cmd.rememberRecentFlagValues()
+		cmd.recordHistory(args)
+		cmd.checkForUpdate()
+	}
 	if err != nil {
 		// Always show help if requested, even if SilenceErrors is in
 		// effect
 		if err == flag.ErrHelp {
-			cmd.HelpFunc()(cmd, args)
+			cmd.displayHelp(args)
 			return cmd, nil
 		}
 
@@ -929,20 +1420,25 @@ func (c *Command) ExecuteC() (cmd *Command, err error) {
 		// This will result in apps by default returning a non-success exit code, but also gives them the option to
 		// handle specially.
 		if err == ErrSubCommandRequired {
-			cmd.HelpFunc()(cmd, args)
+			cmd.displayHelp(args)
 			return cmd, err
 		}
 
 		// If root command has SilentErrors flagged,
 		// all subcommands should respect it
 		if !cmd.SilenceErrors && !c.SilenceErrors {
-			c.Println("Error:", err.Error())
+			c.PrintErrln("Error:", err.Error())
+		}
+
+		// Declining or refusing a confirmation is not a usage error
+		if cmd.phase == PhaseConfirm {
+			return cmd, err
 		}
 
 		// If root command has SilentUsage flagged,
 		// all subcommands should respect it
 		if !cmd.SilenceUsage && !c.SilenceUsage {
-			c.Println(cmd.UsageString())
+			fmt.Fprintln(cmd.UsageOutput(), cmd.UsageString())
 		}
 	}
 	return cmd, err
@@ -1011,6 +1507,21 @@ func (c *Command) InitDefaultVersionFlag() {
 	}
 }
 
+// AddDryRunFlag adds a persistent "dry-run" flag to c, which the commands of its
+// subtree can consult with DryRun. If c already has a dry-run flag, it will do nothing.
+func (c *Command) AddDryRunFlag() {
+	if c.PersistentFlags().Lookup("dry-run") == nil {
+		c.PersistentFlags().Bool("dry-run", false, "only print the actions that would be taken, without executing them")
+	}
+}
+
+// DryRun returns true if the dry-run flag added by AddDryRunFlag is set for this command.
+// Run implementations with side effects should consult it.
+func (c *Command) DryRun() bool {
+	f := c.Flag("dry-run")
+	return f != nil && f.Value.Type() == "bool" && f.Value.String() == "true"
+}
+
 // InitDefaultHelpCmd adds default help command to c.
 // It is called automatically by executing the c or by calling help and usage.
 // If c already has help command or c has no subcommands, it will do nothing.
@@ -1020,28 +1531,70 @@ func (c *Command) InitDefaultHelpCmd() {
 	}
 
 	if c.helpCommand == nil {
+		opts := c.HelpCommandOptions
+		name := opts.Name
+		if name == "" {
+			name = "help"
+		}
+		short := opts.Short
+		if short == "" {
+			short = "Help about any command"
+		}
+		long := opts.Long
+		if long == "" {
+			long = `Help provides help for any command in the application.
+Simply type ` + c.Name() + ` ` + name + ` [path to command] for full details.`
+		}
 		c.helpCommand = &Command{
-			Use:   "help [command]",
-			Short: "Help about any command",
-			Long: `Help provides help for any command in the application.
-Simply type ` + c.Name() + ` help [path to command] for full details.`,
+			Use:     name + " [command]",
+			Aliases: append([]string(nil), opts.Aliases...),
+			Short:   short,
+			Long:    long,
+			GroupID: opts.GroupID,
 
 			Run: func(c *Command, args []string) {
 				cmd, _, e := c.Root().Find(args)
-				if cmd == nil || e != nil {
+				if cmd == nil || e != nil || !cmd.IsVisible() || checkFeature(cmd) != nil {
 					c.Printf("Unknown help topic %#q\n", args)
 					c.Root().Usage()
+				} else if interactive, _ := c.Flags().GetBool("interactive"); interactive {
+					if err := interactiveHelp(cmd, c.InOrStdin(), c.HelpOutput()); err != nil {
+						c.PrintErrln("Error:", err.Error())
+					}
+				} else if query, _ := c.Flags().GetString("search"); query != "" {
+					cmd.printSearchResults(c.HelpOutput(), query)
+				} else if all, _ := c.Flags().GetBool("all"); all {
+					cmd.HelpTree(c.HelpOutput())
 				} else {
 					cmd.InitDefaultHelpFlag() // make possible 'help' flag to be shown
 					cmd.Help()
 				}
 			},
 		}
+		if opts.AllFlag {
+			c.helpCommand.Flags().Bool("all", false, "print the tree of all the commands")
+		}
+		if opts.SearchFlag {
+			c.helpCommand.Flags().String("search", "", "list the commands matching the given keywords")
+		}
+		if interactiveHelp != nil {
+			c.helpCommand.Flags().Bool("interactive", false, "browse the commands in a full-screen browser")
+		}
+	}
+	if n := len(c.commands); n > 0 && c.commands[n-1] == c.helpCommand && c.helpCommand.parent == c {
+		// Already the last command, as left by a previous call.
+		return
 	}
 	c.RemoveCommand(c.helpCommand)
 	c.AddCommand(c.helpCommand)
 }
 
+// IsHelpCommand returns true if c is the help command of its parent, either the
+// default one or the one set by SetHelpCommand.
+func (c *Command) IsHelpCommand() bool {
+	return c.HasParent() && c.Parent().helpCommand == c
+}
+
 // ResetCommands delete parent, subcommand and help command from c.
 func (c *Command) ResetCommands() {
 	c.parent = nil
@@ -1073,17 +1626,27 @@ func (c *Command) AddCommand(cmds ...*Command) {
 		if cmds[i] == c {
 			panic("Command can't be a child of itself")
 		}
+		if c.frozen {
+			panic("Command can't be added to a frozen command, use Instantiate")
+		}
+		if x.FlagNamespace != "" && !x.flagsNamespaced {
+			x.namespacePersistentFlags(x.FlagNamespace)
+			x.flagsNamespaced = true
+		}
+		if EnableCollisionChecks {
+			c.checkCommandCollision(x)
+		}
 		cmds[i].parent = c
 		// update max lengths
-		usageLen := len(x.Use)
+		usageLen := displayWidth(x.Use)
 		if usageLen > c.commandsMaxUseLen {
 			c.commandsMaxUseLen = usageLen
 		}
-		commandPathLen := len(x.CommandPath())
+		commandPathLen := displayWidth(x.CommandPath())
 		if commandPathLen > c.commandsMaxCommandPathLen {
 			c.commandsMaxCommandPathLen = commandPathLen
 		}
-		nameLen := len(x.Name())
+		nameLen := displayWidth(x.Name())
 		if nameLen > c.commandsMaxNameLen {
 			c.commandsMaxNameLen = nameLen
 		}
@@ -1096,6 +1659,24 @@ func (c *Command) AddCommand(cmds ...*Command) {
 	}
 }
 
+// checkCommandCollision panics if x would shadow an existing subcommand of c,
+// unless x allows overrides, in which case the shadowed subcommand is removed.
+func (c *Command) checkCommandCollision(x *Command) {
+	names := append([]string{x.Name()}, x.Aliases...)
+	for _, existing := range c.commands {
+		for _, name := range names {
+			if existing.Name() != name && !existing.HasAlias(name) {
+				continue
+			}
+			if x.Annotations[AnnotationAllowOverride] != "true" {
+				panic(fmt.Sprintf("command %q collides with existing command %q of %q", name, existing.Name(), c.CommandPath()))
+			}
+			c.RemoveCommand(existing)
+			break
+		}
+	}
+}
+
 // RemoveCommand removes one or more commands from a parent command.
 func (c *Command) RemoveCommand(cmds ...*Command) {
 	commands := []*Command{}
@@ -1115,15 +1696,15 @@ main:
 	c.commandsMaxCommandPathLen = 0
 	c.commandsMaxNameLen = 0
 	for _, command := range c.commands {
-		usageLen := len(command.Use)
+		usageLen := displayWidth(command.Use)
 		if usageLen > c.commandsMaxUseLen {
 			c.commandsMaxUseLen = usageLen
 		}
-		commandPathLen := len(command.CommandPath())
+		commandPathLen := displayWidth(command.CommandPath())
 		if commandPathLen > c.commandsMaxCommandPathLen {
 			c.commandsMaxCommandPathLen = commandPathLen
 		}
-		nameLen := len(command.Name())
+		nameLen := displayWidth(command.Name())
 		if nameLen > c.commandsMaxNameLen {
 			c.commandsMaxNameLen = nameLen
 		}
@@ -1152,12 +1733,12 @@ func (c *Command) PrintErr(i ...interface{}) {
 
 // PrintErrln is a convenience method to Println to the defined Err output, fallback to Stderr if not set.
 func (c *Command) PrintErrln(i ...interface{}) {
-	c.Print(fmt.Sprintln(i...))
+	c.PrintErr(fmt.Sprintln(i...))
 }
 
 // PrintErrf is a convenience method to Printf to the defined Err output, fallback to Stderr if not set.
 func (c *Command) PrintErrf(format string, i ...interface{}) {
-	c.Print(fmt.Sprintf(format, i...))
+	c.PrintErr(fmt.Sprintf(format, i...))
 }
 
 // CommandPath returns the full path to this command.
@@ -1198,9 +1779,9 @@ func (c *Command) DebugFlags() {
 		if x.HasFlags() {
 			x.flags.VisitAll(func(f *flag.Flag) {
 				if x.HasPersistentFlags() && x.persistentFlag(f.Name) != nil {
-					c.Println("  -"+f.Shorthand+",", "--"+f.Name, "["+f.DefValue+"]", "", f.Value, "  [LP]")
+					c.Println("  -"+f.Shorthand+",", "--"+f.Name, "["+redactFlag(f).DefValue+"]", "", flagValueString(f), "  [LP]")
 				} else {
-					c.Println("  -"+f.Shorthand+",", "--"+f.Name, "["+f.DefValue+"]", "", f.Value, "  [L]")
+					c.Println("  -"+f.Shorthand+",", "--"+f.Name, "["+redactFlag(f).DefValue+"]", "", flagValueString(f), "  [L]")
 				}
 			})
 		}
@@ -1208,10 +1789,10 @@ func (c *Command) DebugFlags() {
 			x.pflags.VisitAll(func(f *flag.Flag) {
 				if x.HasFlags() {
 					if x.flags.Lookup(f.Name) == nil {
-						c.Println("  -"+f.Shorthand+",", "--"+f.Name, "["+f.DefValue+"]", "", f.Value, "  [P]")
+						c.Println("  -"+f.Shorthand+",", "--"+f.Name, "["+redactFlag(f).DefValue+"]", "", flagValueString(f), "  [P]")
 					}
 				} else {
-					c.Println("  -"+f.Shorthand+",", "--"+f.Name, "["+f.DefValue+"]", "", f.Value, "  [P]")
+					c.Println("  -"+f.Shorthand+",", "--"+f.Name, "["+redactFlag(f).DefValue+"]", "", flagValueString(f), "  [P]")
 				}
 			})
 		}
@@ -1294,11 +1875,11 @@ func (c *Command) HasSubCommands() bool {
 // IsAvailableCommand determines if a command is available as a non-help command
 // (this includes all non deprecated/hidden commands).
 func (c *Command) IsAvailableCommand() bool {
-	if len(c.Deprecated) != 0 || c.Hidden {
+	if len(c.Deprecated) != 0 || c.Hidden || !c.IsVisible() || !c.FeatureEnabled(c.Feature) {
 		return false
 	}
 
-	if c.HasParent() && c.Parent().helpCommand == c {
+	if c.IsHelpCommand() {
 		return false
 	}
 
@@ -1316,7 +1897,7 @@ func (c *Command) IsAvailableCommand() bool {
 // Concrete example: https://github.com/spf13/cobra/issues/393#issuecomment-282741924.
 func (c *Command) IsAdditionalHelpTopicCommand() bool {
 	// if a command is runnable, deprecated, or hidden it is not a 'help' command
-	if c.Runnable() || len(c.Deprecated) != 0 || c.Hidden {
+	if c.Runnable() || len(c.Deprecated) != 0 || c.Hidden || !c.IsVisible() {
 		return false
 	}
 
@@ -1377,10 +1958,7 @@ func (c *Command) GlobalNormalizationFunc() func(f *flag.FlagSet, name string) f
 func (c *Command) Flags() *flag.FlagSet {
 	if c.flags == nil {
 		c.flags = flag.NewFlagSet(c.Name(), flag.ContinueOnError)
-		if c.flagErrorBuf == nil {
-			c.flagErrorBuf = new(bytes.Buffer)
-		}
-		c.flags.SetOutput(c.flagErrorBuf)
+		c.flags.SetOutput(c.flagOutput())
 	}
 
 	return c.flags
@@ -1405,10 +1983,7 @@ func (c *Command) LocalFlags() *flag.FlagSet {
 
 	if c.lflags == nil {
 		c.lflags = flag.NewFlagSet(c.Name(), flag.ContinueOnError)
-		if c.flagErrorBuf == nil {
-			c.flagErrorBuf = new(bytes.Buffer)
-		}
-		c.lflags.SetOutput(c.flagErrorBuf)
+		c.lflags.SetOutput(c.flagOutput())
 	}
 	c.lflags.SortFlags = c.Flags().SortFlags
 	if c.globNormFunc != nil {
@@ -1421,7 +1996,9 @@ func (c *Command) LocalFlags() *flag.FlagSet {
 		}
 	}
 	c.Flags().VisitAll(addToLocal)
-	c.PersistentFlags().VisitAll(addToLocal)
+	if c.pflags != nil {
+		c.pflags.VisitAll(addToLocal)
+	}
 	return c.lflags
 }
 
@@ -1431,10 +2008,7 @@ func (c *Command) InheritedFlags() *flag.FlagSet {
 
 	if c.iflags == nil {
 		c.iflags = flag.NewFlagSet(c.Name(), flag.ContinueOnError)
-		if c.flagErrorBuf == nil {
-			c.flagErrorBuf = new(bytes.Buffer)
-		}
-		c.iflags.SetOutput(c.flagErrorBuf)
+		c.iflags.SetOutput(c.flagOutput())
 	}
 
 	local := c.LocalFlags()
@@ -1450,6 +2024,77 @@ func (c *Command) InheritedFlags() *flag.FlagSet {
 	return c.iflags
 }
 
+// inheritedFlagOverlay holds the display overrides of an inherited flag.
+type inheritedFlagOverlay struct {
+	usage    *string
+	defValue *string
+}
+
+// SetInheritedFlagUsage overrides the usage text displayed by this command's help
+// and docs for the named flag inherited from a parent, without redefining the flag.
+func (c *Command) SetInheritedFlagUsage(name, usage string) {
+	c.inheritedFlagOverlay(name).usage = &usage
+}
+
+// SetInheritedFlagDefault overrides the default value displayed by this command's
+// help and docs for the named flag inherited from a parent. It does not change the
+// actual default value of the flag.
+func (c *Command) SetInheritedFlagDefault(name, defValue string) {
+	c.inheritedFlagOverlay(name).defValue = &defValue
+}
+
+func (c *Command) inheritedFlagOverlay(name string) *inheritedFlagOverlay {
+	if c.inheritedFlagOverlays == nil {
+		c.inheritedFlagOverlays = make(map[string]*inheritedFlagOverlay)
+	}
+	overlay, ok := c.inheritedFlagOverlays[name]
+	if !ok {
+		overlay = &inheritedFlagOverlay{}
+		c.inheritedFlagOverlays[name] = overlay
+	}
+	return overlay
+}
+
+// overlayInheritedFlag returns f, or a copy of f with the display overrides of
+// this command applied.
+func (c *Command) overlayInheritedFlag(f *flag.Flag) *flag.Flag {
+	overlay, ok := c.inheritedFlagOverlays[f.Name]
+	if !ok {
+		return f
+	}
+	overlaid := *f
+	if overlay.usage != nil {
+		overlaid.Usage = *overlay.usage
+	}
+	if overlay.defValue != nil {
+		overlaid.DefValue = *overlay.defValue
+	}
+	return &overlaid
+}
+
+// DisplayFlags returns the flags of flags as displayed by the help and docs of c: the
+// default values of sensitive flags are redacted, the overrides set with
+// SetInheritedFlagUsage and SetInheritedFlagDefault are applied to the flags inherited
+// by c, and the flags whose feature is disabled are hidden. The returned flags are
+// copies, only meant to be rendered.
+func (c *Command) DisplayFlags(flags *flag.FlagSet) *flag.FlagSet {
+	inherited := c.InheritedFlags()
+	display := flag.NewFlagSet(c.Name(), flag.ContinueOnError)
+	display.SortFlags = flags.SortFlags
+	flags.VisitAll(func(f *flag.Flag) {
+		if inherited.Lookup(f.Name) == f {
+			f = c.overlayInheritedFlag(f)
+		}
+		// AddFlag writes the flag, which may be rendered by other goroutines.
+		copied := *redactFlag(f)
+		if c.flagFeatureDisabled(f) {
+			copied.Hidden = true
+		}
+		display.AddFlag(&copied)
+	})
+	return display
+}
+
 // NonInheritedFlags returns all flags which were not inherited from parent commands.
 func (c *Command) NonInheritedFlags() *flag.FlagSet {
 	return c.LocalFlags()
@@ -1459,10 +2104,7 @@ func (c *Command) NonInheritedFlags() *flag.FlagSet {
 func (c *Command) PersistentFlags() *flag.FlagSet {
 	if c.pflags == nil {
 		c.pflags = flag.NewFlagSet(c.Name(), flag.ContinueOnError)
-		if c.flagErrorBuf == nil {
-			c.flagErrorBuf = new(bytes.Buffer)
-		}
-		c.pflags.SetOutput(c.flagErrorBuf)
+		c.pflags.SetOutput(c.flagOutput())
 	}
 	return c.pflags
 }
@@ -1472,23 +2114,69 @@ func (c *Command) ResetFlags() {
 	c.flagErrorBuf = new(bytes.Buffer)
 	c.flagErrorBuf.Reset()
 	c.flags = flag.NewFlagSet(c.Name(), flag.ContinueOnError)
-	c.flags.SetOutput(c.flagErrorBuf)
+	c.flags.SetOutput(c.flagOutput())
 	c.pflags = flag.NewFlagSet(c.Name(), flag.ContinueOnError)
-	c.pflags.SetOutput(c.flagErrorBuf)
+	c.pflags.SetOutput(c.flagOutput())
 
 	c.lflags = nil
 	c.iflags = nil
 	c.parentsPflags = nil
 }
 
+// MarkFlagsHidden hides the named flags of the command from help, docs and completion.
+// The flags must be defined by the command: hiding a flag inherited from a parent would
+// hide it from the parent and its other children too, so it is an error.
+func (c *Command) MarkFlagsHidden(names ...string) error {
+	local := c.LocalFlags()
+	flags := make([]*flag.Flag, 0, len(names))
+	for _, name := range names {
+		f := local.Lookup(name)
+		if f == nil {
+			if c.InheritedFlags().Lookup(name) != nil {
+				return fmt.Errorf("flag -%v is inherited from a parent of %q", name, c.CommandPath())
+			}
+			return fmt.Errorf("no such flag -%v", name)
+		}
+		flags = append(flags, f)
+	}
+	for _, f := range flags {
+		f.Hidden = true
+	}
+	return nil
+}
+
+// SetFlagsHiddenMatching hides, or unhides if hidden is false, every flag defined by the
+// command and its children whose name matches pattern, using the syntax of
+// path.Match (e.g. "log-*"). The persistent flags inherited from the parents of the
+// command are left alone.
+func (c *Command) SetFlagsHiddenMatching(pattern string, hidden bool) error {
+	if _, err := path.Match(pattern, ""); err != nil {
+		return err
+	}
+	set := func(f *flag.Flag) {
+		if matched, _ := path.Match(pattern, f.Name); matched {
+			f.Hidden = hidden
+		}
+	}
+	var visit func(*Command)
+	visit = func(x *Command) {
+		x.LocalFlags().VisitAll(set)
+		for _, sub := range x.commands {
+			visit(sub)
+		}
+	}
+	visit(c)
+	return nil
+}
+
 // HasFlags checks if the command contains any flags (local plus persistent from the entire structure).
 func (c *Command) HasFlags() bool {
-	return c.Flags().HasFlags()
+	return c.flags != nil && c.flags.HasFlags()
 }
 
 // HasPersistentFlags checks if the command contains persistent flags.
 func (c *Command) HasPersistentFlags() bool {
-	return c.PersistentFlags().HasFlags()
+	return c.pflags != nil && c.pflags.HasFlags()
 }
 
 // HasLocalFlags checks if the command has flags specifically declared locally.
@@ -1504,12 +2192,12 @@ func (c *Command) HasInheritedFlags() bool {
 // HasAvailableFlags checks if the command contains any flags (local plus persistent from the entire
 // structure) which are not hidden or deprecated.
 func (c *Command) HasAvailableFlags() bool {
-	return c.Flags().HasAvailableFlags()
+	return c.flags != nil && c.flags.HasAvailableFlags()
 }
 
 // HasAvailablePersistentFlags checks if the command contains persistent flags which are not hidden or deprecated.
 func (c *Command) HasAvailablePersistentFlags() bool {
-	return c.PersistentFlags().HasAvailableFlags()
+	return c.pflags != nil && c.pflags.HasAvailableFlags()
 }
 
 // HasAvailableLocalFlags checks if the command has flags specifically declared locally which are not hidden
@@ -1524,6 +2212,44 @@ func (c *Command) HasAvailableInheritedFlags() bool {
 	return c.InheritedFlags().HasAvailableFlags()
 }
 
+// FlagInfo describes a flag applying to a command along with its provenance.
+type FlagInfo struct {
+	// Flag is a copy of the flag; its Value is shared with the original flag.
+	Flag flag.Flag
+	// Command is the command defining the flag.
+	Command *Command
+	// Persistent is true if the flag is a persistent flag of Command.
+	Persistent bool
+	// Inherited is true if Command is a parent of the command the flag applies to.
+	Inherited bool
+}
+
+// AllFlags returns all flags applying to this command, local and inherited,
+// together with the command defining each of them. The result follows the
+// order of Flags() and can be freely modified by the caller.
+func (c *Command) AllFlags() []FlagInfo {
+	c.mergePersistentFlags()
+
+	var infos []FlagInfo
+	c.Flags().VisitAll(func(f *flag.Flag) {
+		info := FlagInfo{Flag: *f, Command: c}
+		if c.PersistentFlags().Lookup(f.Name) == f {
+			info.Persistent = true
+		} else if c.parentsPflags.Lookup(f.Name) == f {
+			info.Persistent = true
+			info.Inherited = true
+			for p := c.Parent(); p != nil; p = p.Parent() {
+				if p.PersistentFlags().Lookup(f.Name) == f {
+					info.Command = p
+					break
+				}
+			}
+		}
+		infos = append(infos, info)
+	})
+	return infos
+}
+
 // Flag climbs up the command tree looking for matching flag.
 func (c *Command) Flag(name string) (flag *flag.Flag) {
 	flag = c.Flags().Lookup(name)
@@ -1554,18 +2280,30 @@ func (c *Command) ParseFlags(args []string) error {
 		return nil
 	}
 
-	if c.flagErrorBuf == nil {
-		c.flagErrorBuf = new(bytes.Buffer)
+	beforeErrorBufLen := 0
+	if c.flagErrorBuf != nil {
+		beforeErrorBufLen = c.flagErrorBuf.Len()
 	}
-	beforeErrorBufLen := c.flagErrorBuf.Len()
 	c.mergePersistentFlags()
+	args = c.normalizeFlagArgs(args)
+	if c.abbreviatedFlags() {
+		if err := c.checkAbbreviatedFlags(args); err != nil {
+			return err
+		}
+	}
+	if c.StrictPOSIX {
+		c.Flags().SetInterspersed(false)
+		if err := c.checkSingleDashLongFlags(args); err != nil {
+			return err
+		}
+	}
 
 	//do it here after merging all flags and just before parse
 	c.Flags().ParseErrorsWhitelist = flag.ParseErrorsWhitelist(c.FParseErrWhitelist)
 
 	err := c.Flags().Parse(args)
 	// Print warnings if they occurred (e.g. deprecated flag messages).
-	if c.flagErrorBuf.Len()-beforeErrorBufLen > 0 && err == nil {
+	if c.flagErrorBuf != nil && c.flagErrorBuf.Len()-beforeErrorBufLen > 0 && err == nil {
 		c.Print(c.flagErrorBuf.String())
 	}
 
@@ -1581,17 +2319,35 @@ func (c *Command) Parent() *Command {
 // and adds missing persistent flags of all parents.
 func (c *Command) mergePersistentFlags() {
 	c.updateParentsPflags()
-	c.Flags().AddFlagSet(c.PersistentFlags())
+	c.Flags().AddFlagSet(c.pflags)
 	c.Flags().AddFlagSet(c.parentsPflags)
 }
 
+// flagOutput is the output of the flag sets of a command. It collects the messages of
+// pflag, e.g. about deprecated flags, in the flagErrorBuf of the command, allocated on
+// the first message.
+type flagOutput Command
+
+func (o *flagOutput) Write(p []byte) (int, error) {
+	c := (*Command)(o)
+	if c.flagErrorBuf == nil {
+		c.flagErrorBuf = new(bytes.Buffer)
+	}
+	return c.flagErrorBuf.Write(p)
+}
+
+// flagOutput returns the output of the flag sets of c.
+func (c *Command) flagOutput() io.Writer {
+	return (*flagOutput)(c)
+}
+
 // updateParentsPflags updates c.parentsPflags by adding
 // new persistent flags of all parents.
 // If c.parentsPflags == nil, it makes new.
 func (c *Command) updateParentsPflags() {
 	if c.parentsPflags == nil {
 		c.parentsPflags = flag.NewFlagSet(c.Name(), flag.ContinueOnError)
-		c.parentsPflags.SetOutput(c.flagErrorBuf)
+		c.parentsPflags.SetOutput(c.flagOutput())
 		c.parentsPflags.SortFlags = false
 	}
 
@@ -1599,9 +2355,60 @@ func (c *Command) updateParentsPflags() {
 		c.parentsPflags.SetNormalizeFunc(c.globNormFunc)
 	}
 
-	c.Root().PersistentFlags().AddFlagSet(flag.CommandLine)
+	if flag.CommandLine.HasFlags() {
+		c.Root().PersistentFlags().AddFlagSet(flag.CommandLine)
+	}
+
+	c.VisitParents(func(parent *Command) {
+		c.parentsPflags.AddFlagSet(parent.pflags)
+	})
+
+	if EnableCollisionChecks {
+		c.checkFlagCollisions()
+	}
+}
+
+// checkFlagCollisions panics if a flag defined by c or by one of its parents
+// shadows a persistent flag of a more distant parent, unless the shadowing
+// flag was marked with MarkFlagOverride.
+func (c *Command) checkFlagCollisions() {
+	inherited := map[*flag.Flag]bool{}
+	c.VisitParents(func(parent *Command) {
+		parent.PersistentFlags().VisitAll(func(f *flag.Flag) {
+			inherited[f] = true
+		})
+	})
 
+	defined := map[string]*flag.Flag{}
+	definedBy := map[string]*Command{}
+	define := func(x *Command) func(*flag.Flag) {
+		return func(f *flag.Flag) {
+			if x == c && inherited[f] {
+				return
+			}
+			if g, ok := defined[f.Name]; ok {
+				if g != f && g.Annotations[AnnotationAllowOverride] == nil {
+					panic(fmt.Sprintf("flag %q of %q collides with persistent flag of %q",
+						f.Name, definedBy[f.Name].CommandPath(), x.CommandPath()))
+				}
+				return
+			}
+			defined[f.Name] = f
+			definedBy[f.Name] = x
+		}
+	}
+	c.Flags().VisitAll(define(c))
+	c.PersistentFlags().VisitAll(define(c))
 	c.VisitParents(func(parent *Command) {
-		c.parentsPflags.AddFlagSet(parent.PersistentFlags())
+		parent.PersistentFlags().VisitAll(define(parent))
 	})
 }
+
+// MarkFlagOverride allows the named flag to shadow a persistent flag of the same
+// name inherited from a parent when EnableCollisionChecks is set.
+func (c *Command) MarkFlagOverride(name string) error {
+	if c.PersistentFlags().Lookup(name) != nil {
+		return c.PersistentFlags().SetAnnotation(name, AnnotationAllowOverride, []string{"true"})
+	}
+	return c.Flags().SetAnnotation(name, AnnotationAllowOverride, []string{"true"})
+}
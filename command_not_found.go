@@ -0,0 +1,88 @@
+package cobra
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// commandNotFoundPatterns returns the case patterns of the shell handlers of a command
+// not found: the names and aliases of the available subcommands of the root, by
+// subcommand.
+func (c *Command) commandNotFoundPatterns() (subs []*Command, patterns []string) {
+	root := c.Root()
+	for _, sub := range root.Commands() {
+		if !sub.IsAvailableCommand() {
+			continue
+		}
+		names := append([]string{sub.Name()}, sub.Aliases...)
+		for i, name := range names {
+			names[i] = fmt.Sprintf("%q", name)
+		}
+		subs = append(subs, sub)
+		patterns = append(patterns, strings.Join(names, "|"))
+	}
+	return subs, patterns
+}
+
+// commandNotFoundFuncName returns the name of the function saving the previous
+// handler of a command not found.
+func (c *Command) commandNotFoundFuncName() string {
+	name := strings.Map(func(r rune) rune {
+		if (r >= 'A' && r <= 'Z') || (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			return r
+		}
+		return '_'
+	}, c.Root().Name())
+	return "__" + name + "_previous_command_not_found_handler"
+}
+
+// genCommandNotFoundHandler writes the handler named handler of a command not found,
+// suggesting the subcommands of the root typed as bare commands. The previous handler,
+// if any, saved by the shell specific prologue, is called for other commands.
+func (c *Command) genCommandNotFoundHandler(w io.Writer, prologue, handler string) error {
+	root := c.Root()
+	previous := c.commandNotFoundFuncName()
+	buf := new(bytes.Buffer)
+	buf.WriteString(fmt.Sprintf("# %s of %s: suggests \"%s <subcommand>\" for its subcommands typed as commands\n", handler, root.Name(), root.Name()))
+	// The previous handler is saved once, so that sourcing the handler twice doesn't
+	// make it call itself.
+	buf.WriteString(fmt.Sprintf("if [[ -z ${%s_saved} ]]; then\n    %s_saved=1\n", previous, previous))
+	buf.WriteString(fmt.Sprintf(prologue, previous))
+	buf.WriteString("fi\n")
+	buf.WriteString(fmt.Sprintf("%s() {\n    case \"$1\" in\n", handler))
+	subs, patterns := c.commandNotFoundPatterns()
+	for i, sub := range subs {
+		buf.WriteString(fmt.Sprintf("        %s)\n", patterns[i]))
+		buf.WriteString(fmt.Sprintf("            printf '%%s: command not found, did you mean \"%%s\"?\\n' \"$1\" %q >&2\n", sub.CommandPath()))
+		buf.WriteString("            return 127\n            ;;\n")
+	}
+	buf.WriteString("    esac\n")
+	buf.WriteString(fmt.Sprintf("    if typeset -f %s >/dev/null; then\n        %s \"$@\"\n        return $?\n    fi\n", previous, previous))
+	buf.WriteString("    printf '%s: command not found\\n' \"$1\" >&2\n    return 127\n}\n")
+
+	_, err := buf.WriteTo(w)
+	return err
+}
+
+// GenBashCommandNotFoundHandler generates a bash command_not_found_handle function,
+// to source from the bash init files, and writes it to the passed writer. When users
+// type a subcommand of the root as a bare command, e.g. "deploy", it suggests the
+// subcommand, e.g. "mycli deploy". Other commands are passed to the previous handler.
+func (c *Command) GenBashCommandNotFoundHandler(w io.Writer) error {
+	return c.genCommandNotFoundHandler(w, `    if typeset -f command_not_found_handle >/dev/null; then
+        eval "$(typeset -f command_not_found_handle | sed '1s/command_not_found_handle/%s/')"
+    fi
+`, "command_not_found_handle")
+}
+
+// GenZshCommandNotFoundHandler generates a zsh command_not_found_handler function,
+// to source from the zsh init files, and writes it to the passed writer. See
+// GenBashCommandNotFoundHandler.
+func (c *Command) GenZshCommandNotFoundHandler(w io.Writer) error {
+	return c.genCommandNotFoundHandler(w, `    if (( $+functions[command_not_found_handler] )); then
+        functions[%s]=$functions[command_not_found_handler]
+    fi
+`, "command_not_found_handler")
+}
@@ -0,0 +1,36 @@
+package cobra
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestGenCommandNotFoundHandlers(t *testing.T) {
+	rootCmd := &Command{Use: "my-cli", Run: emptyRun}
+	rootCmd.AddCommand(
+		&Command{Use: "deploy", Aliases: []string{"dep"}, Run: emptyRun},
+		&Command{Use: "status", Run: emptyRun},
+		&Command{Use: "secret", Hidden: true, Run: emptyRun},
+	)
+
+	buf := new(bytes.Buffer)
+	if err := rootCmd.GenBashCommandNotFoundHandler(buf); err != nil {
+		t.Fatal(err)
+	}
+	output := buf.String()
+	check(t, output, `eval "$(typeset -f command_not_found_handle | sed '1s/command_not_found_handle/__my_cli_previous_command_not_found_handler/')"`)
+	check(t, output, "command_not_found_handle() {\n")
+	check(t, output, "        \"deploy\"|\"dep\")\n            printf '%s: command not found, did you mean \"%s\"?\\n' \"$1\" \"my-cli deploy\" >&2\n")
+	check(t, output, `        "status")`)
+	checkOmit(t, output, "secret")
+	checkOmit(t, output, `"help"`)
+
+	buf.Reset()
+	if err := rootCmd.GenZshCommandNotFoundHandler(buf); err != nil {
+		t.Fatal(err)
+	}
+	output = buf.String()
+	check(t, output, "functions[__my_cli_previous_command_not_found_handler]=$functions[command_not_found_handler]")
+	check(t, output, "command_not_found_handler() {\n")
+	check(t, output, "        \"deploy\"|\"dep\")\n")
+}
@@ -419,6 +419,37 @@ func TestChildFlagWithParentLocalFlag(t *testing.T) {
 	}
 }
 
+func TestFlagPlacementHints(t *testing.T) {
+	EnableFlagPlacementHints = true
+	defer func() { EnableFlagPlacementHints = false }()
+
+	rootCmd := &Command{Use: "root", Run: emptyRun}
+	childCmd := &Command{Use: "child", Run: emptyRun}
+	grandchildCmd := &Command{Use: "grandchild", Run: emptyRun}
+	rootCmd.AddCommand(childCmd)
+	childCmd.AddCommand(grandchildCmd)
+	grandchildCmd.Flags().BoolP("force", "f", false, "")
+
+	_, err := executeCommand(rootCmd, "child", "--force")
+	if err == nil {
+		t.Fatal("Expected error")
+	}
+	checkStringContains(t, err.Error(), "unknown flag: --force")
+	checkStringContains(t, err.Error(), `"--force" is accepted by "root child grandchild"`)
+
+	_, err = executeCommand(rootCmd, "child", "-f")
+	if err == nil {
+		t.Fatal("Expected error")
+	}
+	checkStringContains(t, err.Error(), `"-f" is accepted by "root child grandchild"`)
+
+	_, err = executeCommand(rootCmd, "child", "--unknown")
+	if err == nil {
+		t.Fatal("Expected error")
+	}
+	checkStringOmits(t, err.Error(), "is accepted by")
+}
+
 func TestFlagInvalidInput(t *testing.T) {
 	rootCmd := &Command{Use: "root", Run: emptyRun}
 	rootCmd.Flags().IntP("intf", "i", -1, "")
@@ -755,6 +786,40 @@ func TestHelpCommandExecutedOnChild(t *testing.T) {
 	checkStringContains(t, output, childCmd.Long)
 }
 
+func TestHelpCommandOptions(t *testing.T) {
+	rootCmd := &Command{Use: "root", Run: emptyRun}
+	rootCmd.HelpCommandOptions = HelpCommandOptions{
+		Name:    "aide",
+		Aliases: []string{"?"},
+		Short:   "Aide sur une commande",
+	}
+	childCmd := &Command{Use: "child", Long: "Long description", Run: emptyRun}
+	rootCmd.AddCommand(childCmd)
+
+	output, err := executeCommand(rootCmd, "aide", "child")
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	checkStringContains(t, output, childCmd.Long)
+
+	output, err = executeCommand(rootCmd, "?", "child")
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	checkStringContains(t, output, childCmd.Long)
+
+	output, err = executeCommand(rootCmd, "--help")
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	checkStringContains(t, output, "aide        Aide sur une commande")
+	checkStringOmits(t, output, "Help about any command")
+
+	if _, err := executeCommand(rootCmd, "help"); err == nil {
+		t.Error("Expected an error for the renamed help command")
+	}
+}
+
 func TestSetHelpCommand(t *testing.T) {
 	c := &Command{Use: "c", Run: emptyRun}
 	c.AddCommand(&Command{Use: "empty", Run: emptyRun})
@@ -820,6 +885,147 @@ func TestHelpFlagInHelp(t *testing.T) {
 	checkStringContains(t, output, "[flags]")
 }
 
+func TestOnHelp(t *testing.T) {
+	rootCmd := &Command{Use: "root", Run: emptyRun}
+	childCmd := &Command{Use: "child", Long: "Long description", Run: emptyRun}
+	rootCmd.AddCommand(childCmd)
+
+	var calls []string
+	rootCmd.OnHelp(func(cmd *Command, args []string) {
+		calls = append(calls, "root:"+cmd.Name())
+		cmd.Println("Service status: up")
+	})
+	childCmd.OnHelp(func(cmd *Command, args []string) {
+		calls = append(calls, "child:"+cmd.Name())
+	})
+
+	output, err := executeCommand(rootCmd, "child", "--help")
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	checkStringContains(t, output, childCmd.Long+"\n")
+	if !strings.HasSuffix(output, "Service status: up\n") {
+		t.Errorf("Expected the hook output after the help, got:\n%s", output)
+	}
+
+	if _, err := executeCommand(rootCmd, "help", "child"); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if _, err := executeCommand(rootCmd, "--help"); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	expected := []string{"root:child", "child:child", "root:child", "child:child", "root:root"}
+	if !reflect.DeepEqual(calls, expected) {
+		t.Errorf("Expected calls %v, got %v", expected, calls)
+	}
+}
+
+func TestAddHelpSection(t *testing.T) {
+	rootCmd := &Command{Use: "root", Run: emptyRun}
+	childCmd := &Command{Use: "child", Run: emptyRun}
+	rootCmd.AddCommand(childCmd)
+
+	rootCmd.AddHelpSection(func(cmd *Command) (string, string) {
+		return "Active configuration", "profile: default\nregion: ca-central-1\n"
+	}, func(cmd *Command) (string, string) {
+		return "Empty", ""
+	})
+	childCmd.AddHelpSection(func(cmd *Command) (string, string) {
+		return "Detected environment", "command: " + cmd.Name()
+	})
+
+	output, err := executeCommand(rootCmd, "child", "--help")
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	expected := `
+Active configuration:
+  profile: default
+  region: ca-central-1
+
+Detected environment:
+  command: child
+`
+	if !strings.HasSuffix(output, expected) {
+		t.Errorf("Expected the help to end with:\n%s\nGot:\n%s", expected, output)
+	}
+	checkStringOmits(t, output, "Empty:")
+
+	output, err = executeCommand(rootCmd, "--help")
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	checkStringContains(t, output, "Active configuration:")
+	checkStringOmits(t, output, "Detected environment:")
+}
+
+func TestHelpAlignsWideCommandNames(t *testing.T) {
+	rootCmd := &Command{Use: "root", Run: emptyRun}
+	rootCmd.AddCommand(
+		&Command{Use: "列表", Short: "列出项目", Run: emptyRun},
+		&Command{Use: "create-project", Short: "create a project", Run: emptyRun},
+	)
+
+	output, err := executeCommand(rootCmd, "--help")
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	checkStringContains(t, output, "  create-project create a project\n")
+	checkStringContains(t, output, "  列表           列出项目\n")
+}
+
+func TestHelpGroups(t *testing.T) {
+	rootCmd := &Command{Use: "root", Run: emptyRun, HelpCommandOptions: HelpCommandOptions{GroupID: "other"}}
+	rootCmd.AddGroup(&Group{ID: "basic", Title: "Basic Commands:"}, &Group{ID: "other", Title: "Other Commands:"})
+	rootCmd.AddCommand(
+		&Command{Use: "get", Short: "get resources", GroupID: "basic", Run: emptyRun},
+		&Command{Use: "create", Short: "create resources", GroupID: "basic", Run: emptyRun},
+		&Command{Use: "version", Short: "print the version", GroupID: "other", Run: emptyRun},
+		&Command{Use: "legacy", Short: "ungrouped", Run: emptyRun},
+		&Command{Use: "secret", GroupID: "basic", Hidden: true, Run: emptyRun},
+	)
+
+	output, err := executeCommand(rootCmd, "--help")
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	checkStringContains(t, output, `
+Basic Commands:
+  create      create resources
+  get         get resources
+
+Other Commands:
+  help        Help about any command
+  version     print the version
+
+Additional Commands:
+  legacy      ungrouped
+`)
+	checkStringOmits(t, output, "Available Commands:")
+	checkStringOmits(t, output, "secret")
+
+	rootCmd = &Command{Use: "root", Run: emptyRun}
+	rootCmd.AddGroup(&Group{ID: "basic", Title: "Basic Commands:"})
+	rootCmd.AddCommand(&Command{Use: "get", GroupID: "basic", Run: emptyRun})
+	output, err = executeCommand(rootCmd, "--help")
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	checkStringContains(t, output, "Additional Commands:\n  help ")
+}
+
+func TestUndefinedGroupPanics(t *testing.T) {
+	rootCmd := &Command{Use: "root", Run: emptyRun}
+	rootCmd.AddCommand(&Command{Use: "get", GroupID: "basic", Run: emptyRun})
+	defer func() {
+		if r := recover(); r != `group id "basic" is not defined for subcommand "root get"` {
+			t.Errorf("Unexpected panic: %v", r)
+		}
+	}()
+	executeCommand(rootCmd)
+}
+
 func TestFlagsInUsage(t *testing.T) {
 	rootCmd := &Command{Use: "root", Args: NoArgs, Run: func(*Command, []string) {}}
 	output, err := executeCommand(rootCmd, "--help")
@@ -940,6 +1146,30 @@ func TestVisitParents(t *testing.T) {
 	}
 }
 
+func TestAproposSuggestions(t *testing.T) {
+	EnableAproposSuggestions = true
+	defer func() { EnableAproposSuggestions = false }()
+
+	rootCmd := &Command{Use: "root", Run: emptyRun}
+	configCmd := &Command{Use: "config", Short: "Manage the settings", Run: emptyRun}
+	viewCmd := &Command{Use: "view", Short: "Display the settings", Run: emptyRun}
+	setsCmd := &Command{Use: "sets", Short: "Manage sets", Run: emptyRun}
+	configCmd.AddCommand(viewCmd)
+	rootCmd.AddCommand(configCmd, setsCmd)
+
+	output, _ := executeCommand(rootCmd, "settings")
+	expected := "Error: unknown command \"settings\" for \"root\"\n\nRelated commands:\n\tconfig\n\tconfig view\n\nRun 'root --help' for usage.\n"
+	if output != expected {
+		t.Errorf("Unexpected response.\nExpected:\n %q\nGot:\n %q\n", expected, output)
+	}
+
+	output, _ = executeCommand(rootCmd, "set")
+	expected = "Error: unknown command \"set\" for \"root\"\n\nDid you mean this?\n\tsets\n\nRelated commands:\n\tconfig\n\tconfig view\n\nRun 'root --help' for usage.\n"
+	if output != expected {
+		t.Errorf("Unexpected response.\nExpected:\n %q\nGot:\n %q\n", expected, output)
+	}
+}
+
 func TestSuggestions(t *testing.T) {
 	rootCmd := &Command{Use: "root", Run: emptyRun}
 	timesCmd := &Command{
@@ -1405,6 +1635,84 @@ func TestSetIn(t *testing.T) {
 	}
 }
 
+func TestSetHelpAndUsageOutput(t *testing.T) {
+	rootCmd := &Command{Use: "root", Args: NoArgs, Run: emptyRun}
+	childCmd := &Command{Use: "child", Run: emptyRun}
+	rootCmd.AddCommand(childCmd)
+
+	out, errOut, help, usage := new(bytes.Buffer), new(bytes.Buffer), new(bytes.Buffer), new(bytes.Buffer)
+	rootCmd.SetOut(out)
+	rootCmd.SetErr(errOut)
+	rootCmd.SetHelpOutput(help)
+	rootCmd.SetUsageOutput(usage)
+
+	rootCmd.SetArgs([]string{"child", "--help"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	checkStringContains(t, help.String(), childCmd.Long)
+	checkStringContains(t, help.String(), "root child [flags]")
+
+	rootCmd.SetArgs([]string{"child", "--unknown"})
+	if err := rootCmd.Execute(); err == nil {
+		t.Errorf("Expected error")
+	}
+	checkStringContains(t, errOut.String(), "Error: unknown flag: --unknown")
+	checkStringContains(t, usage.String(), "root child [flags]")
+	if out.Len() != 0 {
+		t.Errorf("Expected nothing written to the output, got %q", out.String())
+	}
+}
+
+func TestTemplatesForSubtree(t *testing.T) {
+	rootCmd := &Command{Use: "root", Run: emptyRun}
+	pluginCmd := &Command{Use: "plugin", Run: emptyRun}
+	pluginChildCmd := &Command{Use: "child", Run: emptyRun}
+	otherCmd := &Command{Use: "other", Run: emptyRun}
+	pluginCmd.AddCommand(pluginChildCmd)
+	rootCmd.AddCommand(pluginCmd, otherCmd)
+
+	pluginCmd.SetUsageTemplateForSubtree("plugin usage {{.Name}}")
+	pluginCmd.SetHelpTemplateForSubtree("plugin help {{.Name}}")
+	// The root standardizes the templates of all its commands.
+	for _, c := range []*Command{rootCmd, pluginCmd, pluginChildCmd, otherCmd} {
+		c.SetUsageTemplate("root usage {{.Name}}")
+		c.SetHelpTemplate("root help {{.Name}}")
+	}
+
+	tests := []struct {
+		cmd   *Command
+		usage string
+		help  string
+	}{
+		{rootCmd, "root usage root", "root help root"},
+		{otherCmd, "root usage other", "root help other"},
+		{pluginCmd, "plugin usage plugin", "plugin help plugin"},
+		{pluginChildCmd, "plugin usage child", "plugin help child"},
+	}
+	for _, tc := range tests {
+		if got := tc.cmd.UsageString(); got != tc.usage {
+			t.Errorf("Expected usage %q, got %q", tc.usage, got)
+		}
+		buf := new(bytes.Buffer)
+		tc.cmd.SetHelpOutput(buf)
+		tc.cmd.Help()
+		if got := buf.String(); got != tc.help {
+			t.Errorf("Expected help %q, got %q", tc.help, got)
+		}
+	}
+}
+
+func TestUsageOutputDefaults(t *testing.T) {
+	c := &Command{}
+	if out := c.HelpOutput(); out != os.Stdout {
+		t.Errorf("Expected help output to default to stdout")
+	}
+	if out := c.UsageOutput(); out != os.Stderr {
+		t.Errorf("Expected usage output to default to stderr")
+	}
+}
+
 func TestUsageStringRedirected(t *testing.T) {
 	c := &Command{}
 
@@ -1773,3 +2081,391 @@ func TestFParseErrWhitelistSiblingCommand(t *testing.T) {
 	}
 	checkStringContains(t, output, "unknown flag: --unknown")
 }
+
+func TestCollisionChecksCommands(t *testing.T) {
+	EnableCollisionChecks = true
+	defer func() { EnableCollisionChecks = false }()
+
+	rootCmd := &Command{Use: "root", Run: emptyRun}
+	rootCmd.AddCommand(&Command{Use: "child", Aliases: []string{"c"}, Run: emptyRun})
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("Expected panic for command shadowing an alias")
+			}
+		}()
+		rootCmd.AddCommand(&Command{Use: "c", Run: emptyRun})
+	}()
+
+	replaced := false
+	override := &Command{
+		Use:         "child",
+		Annotations: map[string]string{AnnotationAllowOverride: "true"},
+		Run:         func(*Command, []string) { replaced = true },
+	}
+	rootCmd.AddCommand(override)
+	if len(rootCmd.Commands()) != 1 {
+		t.Errorf("Expected the overridden command to be removed, got %d commands", len(rootCmd.Commands()))
+	}
+	if _, err := executeCommand(rootCmd, "child"); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if !replaced {
+		t.Error("Overriding command should have been called")
+	}
+}
+
+func TestCollisionChecksFlags(t *testing.T) {
+	EnableCollisionChecks = true
+	defer func() { EnableCollisionChecks = false }()
+
+	rootCmd := &Command{Use: "root", Run: emptyRun}
+	childCmd := &Command{Use: "child", Run: emptyRun}
+	rootCmd.AddCommand(childCmd)
+	rootCmd.PersistentFlags().String("output", "", "")
+	childCmd.Flags().String("output", "", "")
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("Expected panic for flag shadowing a persistent flag")
+			}
+		}()
+		childCmd.InheritedFlags()
+	}()
+
+	if err := childCmd.MarkFlagOverride("output"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := executeCommand(rootCmd, "child", "--output=json"); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if v, _ := childCmd.Flags().GetString("output"); v != "json" {
+		t.Errorf("Expected local flag to be set, got %q", v)
+	}
+}
+
+func TestAllFlags(t *testing.T) {
+	rootCmd := &Command{Use: "root", Run: emptyRun}
+	childCmd := &Command{Use: "child", Run: emptyRun}
+	rootCmd.AddCommand(childCmd)
+	rootCmd.PersistentFlags().Bool("verbose", false, "")
+	rootCmd.Flags().Bool("root-only", false, "")
+	childCmd.PersistentFlags().String("output", "", "")
+	childCmd.Flags().Int("count", 0, "")
+
+	type provenance struct {
+		command    *Command
+		persistent bool
+		inherited  bool
+	}
+	expected := map[string]provenance{
+		"verbose": {rootCmd, true, true},
+		"output":  {childCmd, true, false},
+		"count":   {childCmd, false, false},
+	}
+
+	infos := childCmd.AllFlags()
+	if len(infos) != len(expected) {
+		t.Fatalf("Expected %d flags, got %d", len(expected), len(infos))
+	}
+	for _, info := range infos {
+		exp, ok := expected[info.Flag.Name]
+		if !ok {
+			t.Errorf("Unexpected flag %q", info.Flag.Name)
+			continue
+		}
+		got := provenance{info.Command, info.Persistent, info.Inherited}
+		if got != exp {
+			t.Errorf("Flag %q: expected %+v, got %+v", info.Flag.Name, exp, got)
+		}
+	}
+}
+
+func TestRunOnUnknownSubcommand(t *testing.T) {
+	var rootCmdArgs []string
+	rootCmd := &Command{
+		Use:                    "root",
+		RunOnUnknownSubcommand: true,
+		Run:                    func(_ *Command, args []string) { rootCmdArgs = args },
+	}
+	rootCmd.AddCommand(&Command{Use: "status", Run: emptyRun})
+
+	_, err := executeCommand(rootCmd, "foo.txt", "bar.txt")
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if got := strings.Join(rootCmdArgs, " "); got != "foo.txt bar.txt" {
+		t.Errorf("rootCmdArgs expected: %q, got: %q", "foo.txt bar.txt", got)
+	}
+
+	output, err := executeCommand(rootCmd, "stauts")
+	if err == nil {
+		t.Fatal("Expected an error for a mistyped subcommand")
+	}
+	checkStringContains(t, output, "Did you mean this?\n\tstatus")
+}
+
+func TestMarkFlagsHidden(t *testing.T) {
+	rootCmd := &Command{Use: "root", Run: emptyRun}
+	rootCmd.Flags().Bool("a", false, "")
+	rootCmd.PersistentFlags().Bool("b", false, "")
+	rootCmd.Flags().Bool("c", false, "")
+
+	if err := rootCmd.MarkFlagsHidden("a", "b"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !rootCmd.Flag("a").Hidden || !rootCmd.Flag("b").Hidden || rootCmd.Flag("c").Hidden {
+		t.Error("Expected only flags a and b to be hidden")
+	}
+	if err := rootCmd.MarkFlagsHidden("missing"); err == nil {
+		t.Error("Expected error for unknown flag")
+	}
+
+	childCmd := &Command{Use: "child", Run: emptyRun}
+	childCmd.Flags().Bool("d", false, "")
+	rootCmd.AddCommand(childCmd)
+	rootCmd.PersistentFlags().Bool("e", false, "")
+	err := childCmd.MarkFlagsHidden("d", "e")
+	if err == nil || err.Error() != `flag -e is inherited from a parent of "root child"` {
+		t.Errorf("Expected error for inherited flag, got %v", err)
+	}
+	if rootCmd.Flag("e").Hidden || childCmd.Flag("d").Hidden {
+		t.Error("Expected no flag to be hidden after an error")
+	}
+}
+
+func TestSetFlagsHiddenMatching(t *testing.T) {
+	rootCmd := &Command{Use: "root", Run: emptyRun}
+	childCmd := &Command{Use: "child", Run: emptyRun}
+	rootCmd.AddCommand(childCmd)
+	rootCmd.PersistentFlags().String("log-level", "", "")
+	childCmd.Flags().String("log-file", "", "")
+	childCmd.Flags().String("output", "", "")
+
+	if err := rootCmd.SetFlagsHiddenMatching("log-*", true); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	output, _ := executeCommand(rootCmd, "child", "--help")
+	checkStringOmits(t, output, "--log-level")
+	checkStringOmits(t, output, "--log-file")
+	checkStringContains(t, output, "--output")
+
+	if err := rootCmd.SetFlagsHiddenMatching("log-f*", false); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if childCmd.Flag("log-file").Hidden || !childCmd.Flag("log-level").Hidden {
+		t.Error("Expected only log-file to be unhidden")
+	}
+
+	if err := rootCmd.SetFlagsHiddenMatching("[", true); err == nil {
+		t.Error("Expected error for bad pattern")
+	}
+}
+
+func TestSetFlagsHiddenMatchingOnSubcommand(t *testing.T) {
+	rootCmd := &Command{Use: "root", Run: emptyRun}
+	childCmd := &Command{Use: "child", Run: emptyRun}
+	siblingCmd := &Command{Use: "sibling", Run: emptyRun}
+	rootCmd.AddCommand(childCmd, siblingCmd)
+	rootCmd.PersistentFlags().String("log-level", "", "")
+	childCmd.Flags().String("log-file", "", "")
+
+	// Merges the persistent flags of root into the flags of child.
+	childCmd.InheritedFlags()
+	if err := childCmd.SetFlagsHiddenMatching("log-*", true); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !childCmd.Flag("log-file").Hidden {
+		t.Error("Expected log-file to be hidden")
+	}
+	output, _ := executeCommand(rootCmd, "sibling", "--help")
+	checkStringContains(t, output, "--log-level")
+}
+
+func TestInheritedFlagOverlay(t *testing.T) {
+	rootCmd := &Command{Use: "root", Run: emptyRun}
+	childCmd := &Command{Use: "child", Run: emptyRun}
+	otherCmd := &Command{Use: "other", Run: emptyRun}
+	rootCmd.AddCommand(childCmd, otherCmd)
+	rootCmd.PersistentFlags().String("namespace", "default", "namespace to use")
+
+	childCmd.SetInheritedFlagUsage("namespace", "namespace of the deployment")
+	childCmd.SetInheritedFlagDefault("namespace", "current context")
+
+	output, err := executeCommand(rootCmd, "child", "--help")
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	checkStringContains(t, output, `namespace of the deployment (default "current context")`)
+	checkStringOmits(t, output, "namespace to use")
+
+	output, err = executeCommand(rootCmd, "other", "--help")
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	checkStringContains(t, output, `namespace to use (default "default")`)
+
+	if _, err := executeCommand(rootCmd, "child", "--namespace=prod"); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if v, _ := childCmd.Flags().GetString("namespace"); v != "prod" {
+		t.Errorf("Expected overlaid flag to still be parsed, got %q", v)
+	}
+	f := childCmd.InheritedFlags().Lookup("namespace")
+	if f != rootCmd.PersistentFlags().Lookup("namespace") {
+		t.Fatal("Expected the inherited flag to be the flag of the parent")
+	}
+	if !f.Changed || f.Value.String() != "prod" || f.DefValue != "default" || f.Usage != "namespace to use" {
+		t.Errorf("Expected the inherited flag to be set to prod, got changed %v, value %q, default %q, usage %q", f.Changed, f.Value, f.DefValue, f.Usage)
+	}
+}
+
+func TestDryRun(t *testing.T) {
+	var dryRunInRun bool
+	var postRuns []string
+	rootCmd := &Command{
+		Use:               "root",
+		MutatingPostRun:   true,
+		PersistentPostRun: func(*Command, []string) { postRuns = append(postRuns, "root") },
+	}
+	childCmd := &Command{
+		Use:             "child",
+		MutatingPostRun: true,
+		Run:             func(c *Command, _ []string) { dryRunInRun = c.DryRun() },
+		PostRun:         func(*Command, []string) { postRuns = append(postRuns, "child") },
+	}
+	rootCmd.AddCommand(childCmd)
+	rootCmd.AddDryRunFlag()
+
+	if _, err := executeCommand(rootCmd, "child", "--dry-run"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !dryRunInRun {
+		t.Error("Expected DryRun to be true")
+	}
+	if len(postRuns) != 0 {
+		t.Errorf("Expected mutating post runs to be skipped, got %v", postRuns)
+	}
+
+	childCmd.MutatingPostRun = false
+	if _, err := executeCommand(rootCmd, "child", "--dry-run=false"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if dryRunInRun {
+		t.Error("Expected DryRun to be false")
+	}
+	if got := strings.Join(postRuns, " "); got != "child root" {
+		t.Errorf("Expected post runs %q, got %q", "child root", got)
+	}
+}
+
+func TestFlagSetsCreatedLazily(t *testing.T) {
+	rootCmd := &Command{Use: "root", Run: emptyRun}
+	groupCmd := &Command{Use: "group", Run: emptyRun}
+	childCmd := &Command{Use: "child", Run: emptyRun}
+	childCmd.Flags().String("name", "", "a name")
+	siblingCmd := &Command{Use: "sibling", Run: emptyRun}
+	groupCmd.AddCommand(childCmd, siblingCmd)
+	rootCmd.AddCommand(groupCmd)
+
+	if _, err := executeCommand(rootCmd, "group", "child", "--name", "x"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if siblingCmd.flags != nil || siblingCmd.pflags != nil {
+		t.Error("Expected no flag sets for a command which was not executed")
+	}
+	if rootCmd.pflags != nil || groupCmd.pflags != nil {
+		t.Error("Expected no persistent flag sets for parents without persistent flags")
+	}
+	if childCmd.flagErrorBuf != nil {
+		t.Error("Expected no flag error buffer without flag messages")
+	}
+}
+
+// newBenchmarkTree returns a tree of groups of leaf commands each defining a flag.
+func newBenchmarkTree(groups, leaves int) *Command {
+	rootCmd := &Command{Use: "root"}
+	rootCmd.PersistentFlags().Bool("verbose", false, "verbose output")
+	for g := 0; g < groups; g++ {
+		groupCmd := &Command{Use: fmt.Sprintf("group%d", g)}
+		for l := 0; l < leaves; l++ {
+			leafCmd := &Command{Use: fmt.Sprintf("leaf%d", l), Run: emptyRun}
+			leafCmd.Flags().String("name", "", "a name")
+			groupCmd.AddCommand(leafCmd)
+		}
+		rootCmd.AddCommand(groupCmd)
+	}
+	return rootCmd
+}
+
+func BenchmarkLargeTreeConstructAndExecute(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		rootCmd := newBenchmarkTree(10, 100)
+		rootCmd.SetArgs([]string{"group5", "leaf50", "--name", "x"})
+		if err := rootCmd.Execute(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkExecuteLeafCommand(b *testing.B) {
+	rootCmd := &Command{Use: "root"}
+	rootCmd.PersistentFlags().Bool("verbose", false, "verbose output")
+	leafCmd := &Command{Use: "leaf", Run: emptyRun}
+	leafCmd.Flags().String("name", "", "a name")
+	leafCmd.Flags().IntP("count", "c", 0, "a count")
+	rootCmd.AddCommand(leafCmd, &Command{Use: "other", Run: emptyRun})
+	rootCmd.SetArgs([]string{"leaf", "--name", "x", "-c", "3", "arg"})
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := rootCmd.Execute(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkFind(b *testing.B) {
+	rootCmd := newBenchmarkTree(10, 100)
+	args := []string{"--verbose", "group5", "leaf50", "--name", "x", "arg"}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, _, err := rootCmd.Find(args); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestStrictPOSIX(t *testing.T) {
+	var gotArgs []string
+	rootCmd := &Command{
+		Use:         "root",
+		StrictPOSIX: true,
+		Run:         func(_ *Command, args []string) { gotArgs = args },
+	}
+	rootCmd.Flags().StringP("name", "n", "", "a name")
+	rootCmd.Flags().BoolP("all", "a", false, "all")
+
+	if _, err := executeCommand(rootCmd, "-a", "--name", "x", "one", "-n", "two", "--", "three"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if got := strings.Join(gotArgs, " "); got != "one -n two -- three" {
+		t.Errorf("Expected the arguments following the first operand to be operands, got %q", got)
+	}
+	if name, _ := rootCmd.Flags().GetString("name"); name != "x" {
+		t.Errorf("Expected name %q, got %q", "x", name)
+	}
+
+	if _, err := executeCommand(rootCmd, "-n", "-all", "-all"); err == nil || !strings.Contains(err.Error(), `long flag "-all" given with a single dash, use --all`) {
+		t.Errorf("Expected an error for a single-dash long flag, got %v", err)
+	}
+	if _, err := executeCommand(rootCmd, "--", "-all"); err != nil {
+		t.Errorf("Unexpected error for an operand following --: %v", err)
+	}
+}
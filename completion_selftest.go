@@ -0,0 +1,78 @@
+package cobra
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+
+	"github.com/spf13/pflag"
+)
+
+// FlagHasCompletion returns true if the shell completion offers values for the flag, or
+// if the flag takes no value.
+func FlagHasCompletion(f *pflag.Flag) bool {
+	if f.NoOptDefVal != "" && !hasOptionalValue(f) {
+		return true
+	}
+	if flagCompletionKind(f) != "" {
+		return true
+	}
+	for _, key := range []string{BashCompFilenameExt, BashCompCustom, BashCompSubdirsInDir, BashCompKeyValue, FlagRememberRecent} {
+		if _, ok := f.Annotations[key]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// HasArgsCompletion returns true if the shell completion offers values for the
+// positional arguments of the command: its subcommands, its ValidArgs or the
+// completions of its positional arguments. Commands accepting no arguments need none.
+func (c *Command) HasArgsCompletion() bool {
+	if c.HasAvailableSubCommands() || len(c.ValidArgs) > 0 {
+		return true
+	}
+	if _, ok := c.Annotations[zshCompArgumentAnnotation]; ok {
+		return true
+	}
+	return c.Args != nil && reflect.ValueOf(c.Args).Pointer() == reflect.ValueOf(NoArgs).Pointer()
+}
+
+// CompletionSelfTest writes to w a report of the completion coverage of the command
+// tree of c: the runnable commands whose arguments are not completed and the flags
+// whose values are not completed. It returns the number of issues found. It helps
+// authors audit the completion, e.g. from a hidden command or a test.
+func (c *Command) CompletionSelfTest(w io.Writer) int {
+	fmt.Fprintf(w, "Completion self-test of %q\n", c.CommandPath())
+	issues := c.completionSelfTest(w)
+	if issues == 0 {
+		fmt.Fprintln(w, "No issue found.")
+	} else {
+		fmt.Fprintf(w, "%d issue(s) found.\n", issues)
+	}
+	return issues
+}
+
+func (c *Command) completionSelfTest(w io.Writer) int {
+	issues := 0
+	if c.Runnable() && !c.HasArgsCompletion() {
+		fmt.Fprintf(w, "  %s: no completion of the arguments\n", c.CommandPath())
+		issues++
+	}
+	c.InitDefaultHelpFlag()
+	c.InitDefaultYesFlag()
+	c.LocalFlags().VisitAll(func(f *pflag.Flag) {
+		if nonCompletableFlag(f) || FlagHasCompletion(f) {
+			return
+		}
+		fmt.Fprintf(w, "  %s --%s: no completion of the value\n", c.CommandPath(), f.Name)
+		issues++
+	})
+	for _, sub := range c.Commands() {
+		if !sub.IsAvailableCommand() || sub == c.helpCommand {
+			continue
+		}
+		issues += sub.completionSelfTest(w)
+	}
+	return issues
+}
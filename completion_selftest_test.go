@@ -0,0 +1,33 @@
+package cobra
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestCompletionSelfTest(t *testing.T) {
+	rootCmd := &Command{Use: "root"}
+	getCmd := &Command{Use: "get", ValidArgs: []string{"pods"}, Run: emptyRun}
+	getCmd.Flags().String("output", "", "")
+	getCmd.Flags().Bool("watch", false, "")
+	getCmd.Flags().String("file", "", "")
+	getCmd.MarkFlagFilename("file")
+	logsCmd := &Command{Use: "logs", Run: emptyRun}
+	versionCmd := &Command{Use: "version", Args: NoArgs, Run: emptyRun}
+	rootCmd.AddCommand(getCmd, logsCmd, versionCmd)
+
+	buf := new(bytes.Buffer)
+	issues := rootCmd.CompletionSelfTest(buf)
+	output := buf.String()
+
+	if issues != 2 {
+		t.Errorf("Expected 2 issues, got %d:\n%s", issues, output)
+	}
+	checkStringContains(t, output, "  root get --output: no completion of the value\n")
+	checkStringContains(t, output, "  root logs: no completion of the arguments\n")
+	checkStringContains(t, output, "2 issue(s) found.\n")
+	checkStringOmits(t, output, "--watch")
+	checkStringOmits(t, output, "--file")
+	checkStringOmits(t, output, "root version")
+	checkStringOmits(t, output, "--help")
+}
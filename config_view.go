@@ -0,0 +1,66 @@
+package cobra
+
+import (
+	"fmt"
+	"text/tabwriter"
+
+	"github.com/spf13/pflag"
+)
+
+// AddConfigViewCommand adds the "config view" command to c, creating its "config"
+// subcommand if needed. "config view [command] [flags]" prints the effective value of
+// every flag of the command, given the flags, and its source, e.g. the command line, a
+// preset or the default, which helps debugging precedence problems. The values of
+// sensitive flags are redacted.
+func (c *Command) AddConfigViewCommand() {
+	configCmd := c.findNext("config")
+	if configCmd == nil {
+		configCmd = &Command{
+			Use:   "config",
+			Short: "Inspect the configuration",
+		}
+		c.AddCommand(configCmd)
+	}
+	configCmd.AddCommand(&Command{
+		Use:                "view [command] [flags]",
+		Short:              "Print the effective value and source of the flags of a command",
+		DisableFlagParsing: true,
+		RunE: func(cmd *Command, args []string) error {
+			target, flags, err := c.Root().Find(args)
+			if err != nil {
+				return err
+			}
+			return target.printFlagSources(cmd, flags)
+		},
+	})
+}
+
+// printFlagSources parses args as the flags of c, applies its presets, and prints to
+// the output of cmd the value and source of each flag of c.
+func (c *Command) printFlagSources(cmd *Command, args []string) error {
+	c.InitDefaultHelpFlag()
+	if err := c.ParseFlags(args); err != nil {
+		return err
+	}
+	if dir := c.presetDirectory(); dir != "" {
+		if name, err := c.Flags().GetString("preset"); err == nil && name != "" {
+			if err := c.applyPreset(dir, name); err != nil {
+				return err
+			}
+		}
+	}
+	if err := c.applyFlagEnv(); err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 4, 2, ' ', 0)
+	fmt.Fprintf(w, "FLAG\tVALUE\tSOURCE\n")
+	c.Flags().VisitAll(func(f *pflag.Flag) {
+		if f.Name == "help" || f.Hidden {
+			return
+		}
+		source, _ := c.FlagSource(f.Name)
+		fmt.Fprintf(w, "--%s\t%s\t%s\n", f.Name, flagValueString(f), source)
+	})
+	return w.Flush()
+}
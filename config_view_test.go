@@ -0,0 +1,66 @@
+package cobra
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestConfigView(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cobra-config-view")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if err := ioutil.WriteFile(filepath.Join(dir, "prod.json"), []byte(`{"region": "ca-central-1"}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	rootCmd := &Command{Use: "root", Run: emptyRun}
+	rootCmd.AddPresetFlags(dir)
+	rootCmd.PersistentFlags().String("token", "", "API token")
+	rootCmd.MarkPersistentFlagSensitive("token")
+	deployCmd := &Command{Use: "deploy", Run: emptyRun}
+	deployCmd.Flags().String("region", "us-east-1", "region")
+	deployCmd.Flags().Int("replicas", 1, "number of replicas")
+	rootCmd.AddCommand(deployCmd)
+	rootCmd.AddConfigViewCommand()
+
+	output, err := executeCommand(rootCmd, "config", "view", "deploy", "--preset", "prod", "--replicas", "3", "--token", "secret")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := `FLAG           VALUE         SOURCE
+--preset       prod          command line
+--region       ca-central-1  preset
+--replicas     3             command line
+--save-preset                default
+--token        <redacted>    command line
+`
+	if output != expected {
+		t.Errorf("Expected:\n%s\nGot:\n%s", expected, output)
+	}
+}
+
+func TestFlagSource(t *testing.T) {
+	c := &Command{Use: "c", Run: emptyRun}
+	c.Flags().String("region", "", "region")
+	c.Flags().String("zone", "", "zone")
+	c.Flags().String("name", "", "name")
+	if _, err := executeCommand(c, "--name", "x"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := c.SetFlagFromSource("zone", "a", "env"); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, expected := range map[string]string{"region": FlagSourceDefault, "zone": "env", "name": FlagSourceCommandLine} {
+		if source, err := c.FlagSource(name); err != nil || source != expected {
+			t.Errorf("Expected the source of %q to be %q, got %q, %v", name, expected, source, err)
+		}
+	}
+	if _, err := c.FlagSource("unknown"); err == nil {
+		t.Error("Expected an error for an unknown flag")
+	}
+}
@@ -0,0 +1,86 @@
+package cobra
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// ErrNotConfirmed is returned when the execution of a Dangerous command was not confirmed.
+var ErrNotConfirmed = errors.New("command not confirmed")
+
+// SetConfirmFunc sets the function asking for the confirmation of Dangerous commands.
+// It returns whether the execution is confirmed. The default function prompts the user
+// when the input is a terminal, and refuses the execution otherwise.
+func (c *Command) SetConfirmFunc(f func(*Command) (bool, error)) {
+	c.confirmFunc = f
+}
+
+// ConfirmFunc returns either the function set by SetConfirmFunc for this command
+// or a parent, or it returns a function prompting the user on a terminal.
+func (c *Command) ConfirmFunc() func(*Command) (bool, error) {
+	if c.confirmFunc != nil {
+		return c.confirmFunc
+	}
+	if c.HasParent() {
+		return c.Parent().ConfirmFunc()
+	}
+	return func(c *Command) (bool, error) {
+		if !isTerminal(c.InOrStdin()) {
+			return false, fmt.Errorf("refusing to run %q without confirmation, use --yes to confirm", c.CommandPath())
+		}
+		fmt.Fprintf(c.ErrOrStderr(), "Are you sure you want to run %q? [y/N]: ", c.CommandPath())
+		answer, err := bufio.NewReader(c.InOrStdin()).ReadString('\n')
+		if err != nil && err != io.EOF {
+			return false, err
+		}
+		answer = strings.ToLower(strings.TrimSpace(answer))
+		return answer == "y" || answer == "yes", nil
+	}
+}
+
+// InitDefaultYesFlag adds default yes flag to c.
+// It is called automatically by executing the c or by generating completions.
+// If c already has a yes flag, it will do nothing.
+// If c is not Dangerous, it will do nothing.
+func (c *Command) InitDefaultYesFlag() {
+	if !c.Dangerous {
+		return
+	}
+
+	c.mergePersistentFlags()
+	if c.Flags().Lookup("yes") == nil {
+		c.Flags().Bool("yes", false, "run without asking for confirmation")
+	}
+}
+
+// confirm asks for the confirmation of a Dangerous command, unless --yes was given.
+func (c *Command) confirm() error {
+	if !c.Dangerous {
+		return nil
+	}
+	if yes, err := c.Flags().GetBool("yes"); err == nil && yes {
+		return nil
+	}
+	confirmed, err := c.ConfirmFunc()(c)
+	if err != nil {
+		return err
+	}
+	if !confirmed {
+		return ErrNotConfirmed
+	}
+	return nil
+}
+
+// isTerminal returns true if r is a character device, like a terminal.
+func isTerminal(r io.Reader) bool {
+	f, ok := r.(*os.File)
+	if !ok {
+		return false
+	}
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
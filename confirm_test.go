@@ -0,0 +1,86 @@
+package cobra
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestDangerousCommand(t *testing.T) {
+	var ran bool
+	rootCmd := &Command{Use: "root", Run: emptyRun}
+	deleteCmd := &Command{
+		Use:       "delete",
+		Dangerous: true,
+		Run:       func(*Command, []string) { ran = true },
+	}
+	rootCmd.AddCommand(deleteCmd)
+	rootCmd.SetIn(new(bytes.Buffer))
+
+	// The input is not a terminal, so no prompt can be shown.
+	output, err := executeCommand(rootCmd, "delete")
+	if err == nil || !strings.Contains(err.Error(), "use --yes") {
+		t.Errorf("Expected error asking for --yes, got: %v", err)
+	}
+	checkStringOmits(t, output, "Usage:")
+	if ran {
+		t.Error("Expected command not to run without confirmation")
+	}
+
+	if _, err := executeCommand(rootCmd, "delete", "--yes"); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if !ran {
+		t.Error("Expected command to run with --yes")
+	}
+}
+
+func TestSetConfirmFunc(t *testing.T) {
+	var ran bool
+	rootCmd := &Command{Use: "root", Run: emptyRun}
+	deleteCmd := &Command{
+		Use:       "delete",
+		Dangerous: true,
+		Run:       func(*Command, []string) { ran = true },
+	}
+	rootCmd.AddCommand(deleteCmd)
+
+	var asked string
+	confirmed := false
+	rootCmd.SetConfirmFunc(func(c *Command) (bool, error) {
+		asked = c.CommandPath()
+		return confirmed, nil
+	})
+
+	output, err := executeCommand(rootCmd, "delete")
+	if err != ErrNotConfirmed {
+		t.Errorf("Expected ErrNotConfirmed, got: %v", err)
+	}
+	if asked != "root delete" {
+		t.Errorf("Expected confirmation of %q, got %q", "root delete", asked)
+	}
+	if ran {
+		t.Error("Expected command not to run when declined")
+	}
+	checkStringOmits(t, output, "Usage:")
+
+	confirmed = true
+	if _, err := executeCommand(rootCmd, "delete"); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if !ran {
+		t.Error("Expected command to run when confirmed")
+	}
+}
+
+func TestDangerousCommandCompletion(t *testing.T) {
+	rootCmd := &Command{Use: "root", Run: emptyRun}
+	deleteCmd := &Command{Use: "delete", Dangerous: true, Run: emptyRun}
+	rootCmd.AddCommand(deleteCmd)
+
+	buf := new(bytes.Buffer)
+	if err := rootCmd.GenBashCompletion(buf); err != nil {
+		t.Fatal(err)
+	}
+	checkStringContains(t, buf.String(), `flags+=("--yes")`)
+}
@@ -1,6 +1,9 @@
 package doc
 
 import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -84,3 +87,55 @@ func checkStringOmits(t *testing.T, got, expected string) {
 		t.Errorf("Expected to not contain: \n %v\nGot: %v", expected, got)
 	}
 }
+
+// newLargeTree returns a command tree with width subcommands per command, depth
+// levels deep, each with a few flags, to test and benchmark the generation of trees.
+func newLargeTree(width, depth int) *cobra.Command {
+	var build func(name string, level int) *cobra.Command
+	build = func(name string, level int) *cobra.Command {
+		c := &cobra.Command{
+			Use:     name + " [args]",
+			Short:   "Short description of " + name,
+			Long:    "Long description of " + name + ".",
+			Example: name + " --flag value",
+			Run:     emptyRun,
+		}
+		c.Flags().String("flag", "", "a flag of "+name)
+		c.PersistentFlags().Bool("verbose-"+name, false, "a persistent flag of "+name)
+		if level < depth {
+			for i := 0; i < width; i++ {
+				c.AddCommand(build(fmt.Sprintf("%s%d", name, i), level+1))
+			}
+		}
+		return c
+	}
+	return build("root", 0)
+}
+
+// checkSameDirs checks that the directories hold the same files with the same contents.
+func checkSameDirs(t *testing.T, expected, got string) {
+	files, err := ioutil.ReadDir(expected)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gotFiles, err := ioutil.ReadDir(got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != len(gotFiles) {
+		t.Fatalf("Expected %d files, got %d", len(files), len(gotFiles))
+	}
+	for _, f := range files {
+		want, err := ioutil.ReadFile(filepath.Join(expected, f.Name()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		content, err := ioutil.ReadFile(filepath.Join(got, f.Name()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(want) != string(content) {
+			t.Errorf("Expected %s to be:\n%s\nGot:\n%s", f.Name(), want, content)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package doc
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// completionGenerators returns the generators of the completion scripts of cmd, by the
+// name of their golden file.
+func completionGenerators(cmd *cobra.Command) []struct {
+	name string
+	gen  func(io.Writer) error
+} {
+	name := cmd.Root().Name()
+	return []struct {
+		name string
+		gen  func(io.Writer) error
+	}{
+		{name + ".bash", cmd.Root().GenBashCompletion},
+		{name + ".zsh", cmd.Root().GenZshCompletion},
+		{name + ".ps1", cmd.Root().GenPowerShellCompletion},
+	}
+}
+
+// GenCompletionGoldenFiles writes the bash, zsh and PowerShell completion scripts of the
+// command tree of cmd to dir, as the golden files checked by VerifyCompletionGoldenFiles.
+// The files are named after the root command, e.g. "root.bash", "root.zsh" and "root.ps1".
+func GenCompletionGoldenFiles(cmd *cobra.Command, dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	for _, g := range completionGenerators(cmd) {
+		buf := new(bytes.Buffer)
+		if err := g.gen(buf); err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(filepath.Join(dir, g.name), buf.Bytes(), 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// VerifyCompletionGoldenFiles checks that the completion scripts of the command tree of
+// cmd match the golden files written to dir by GenCompletionGoldenFiles, so that tests
+// detect unintended changes of the completion, e.g. when upgrading cobra. The returned
+// error tells the first difference of each script.
+func VerifyCompletionGoldenFiles(cmd *cobra.Command, dir string) error {
+	var diffs []string
+	for _, g := range completionGenerators(cmd) {
+		buf := new(bytes.Buffer)
+		if err := g.gen(buf); err != nil {
+			return err
+		}
+		filename := filepath.Join(dir, g.name)
+		golden, err := ioutil.ReadFile(filename)
+		if err != nil {
+			return err
+		}
+		if diff := firstDifference(string(golden), buf.String()); diff != "" {
+			diffs = append(diffs, fmt.Sprintf("%s: %s", filename, diff))
+		}
+	}
+	if len(diffs) > 0 {
+		return fmt.Errorf("completion scripts differ from their golden files, regenerate them with GenCompletionGoldenFiles if intended:\n%s",
+			strings.Join(diffs, "\n"))
+	}
+	return nil
+}
+
+// firstDifference describes the first line differing between the golden and the
+// generated contents, or returns an empty string if they are equal.
+func firstDifference(golden, generated string) string {
+	if golden == generated {
+		return ""
+	}
+	want := strings.Split(golden, "\n")
+	got := strings.Split(generated, "\n")
+	for i := 0; i < len(want) || i < len(got); i++ {
+		switch {
+		case i >= len(want):
+			return fmt.Sprintf("line %d: unexpected %q", i+1, got[i])
+		case i >= len(got):
+			return fmt.Sprintf("line %d: missing %q", i+1, want[i])
+		case want[i] != got[i]:
+			return fmt.Sprintf("line %d: got %q, want %q", i+1, got[i], want[i])
+		}
+	}
+	return ""
+}
@@ -0,0 +1,45 @@
+package doc
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCompletionGoldenFiles(t *testing.T) {
+	tmpdir, err := ioutil.TempDir("", "test-gen-completion-golden")
+	if err != nil {
+		t.Fatalf("Failed to create tmpdir: %v", err)
+	}
+	defer os.RemoveAll(tmpdir)
+
+	if err := GenCompletionGoldenFiles(rootCmd, tmpdir); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"root.bash", "root.zsh", "root.ps1"} {
+		if _, err := os.Stat(filepath.Join(tmpdir, name)); err != nil {
+			t.Errorf("Expected golden file %s: %v", name, err)
+		}
+	}
+	if err := VerifyCompletionGoldenFiles(rootCmd, tmpdir); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	golden := filepath.Join(tmpdir, "root.zsh")
+	b, err := ioutil.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	changed := strings.Replace(string(b), "echo", "ECHO", 1)
+	if err := ioutil.WriteFile(golden, []byte(changed), 0644); err != nil {
+		t.Fatal(err)
+	}
+	err = VerifyCompletionGoldenFiles(rootCmd, tmpdir)
+	if err == nil {
+		t.Fatal("Expected error for a changed golden file")
+	}
+	checkStringContains(t, err.Error(), golden+": line ")
+	checkStringOmits(t, err.Error(), "root.bash")
+}
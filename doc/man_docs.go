@@ -49,14 +49,12 @@ func GenManTreeFromOpts(cmd *cobra.Command, opts GenManTreeOptions) error {
 	if header == nil {
 		header = &GenManHeader{}
 	}
-	for _, c := range cmd.Commands() {
-		if !c.IsAvailableCommand() || c.IsAdditionalHelpTopicCommand() {
-			continue
-		}
-		if err := GenManTreeFromOpts(c, opts); err != nil {
-			return err
-		}
-	}
+	return genTree(cmd, opts.Workers, func(c *cobra.Command) error {
+		return genManFile(c, header, opts)
+	})
+}
+
+func genManFile(cmd *cobra.Command, header *GenManHeader, opts GenManTreeOptions) error {
 	section := "1"
 	if header.Section != "" {
 		section = header.Section
@@ -68,14 +66,12 @@ func GenManTreeFromOpts(cmd *cobra.Command, opts GenManTreeOptions) error {
 	}
 	basename := strings.Replace(cmd.CommandPath(), " ", separator, -1)
 	filename := filepath.Join(opts.Path, basename+"."+section)
-	f, err := os.Create(filename)
-	if err != nil {
+	buf := new(bytes.Buffer)
+	headerCopy := *header
+	if err := GenMan(cmd, &headerCopy, buf); err != nil {
 		return err
 	}
-	defer f.Close()
-
-	headerCopy := *header
-	return GenMan(cmd, &headerCopy, f)
+	return writeFileIfChanged(filename, buf.Bytes())
 }
 
 // GenManTreeOptions is the options for generating the man pages.
@@ -84,6 +80,11 @@ type GenManTreeOptions struct {
 	Header           *GenManHeader
 	Path             string
 	CommandSeparator string
+	// Workers is the number of pages generated concurrently, which speeds up the
+	// generation for large command trees. The pages are generated one by one if it
+	// is zero or one. The Translate function of the header must then be safe for
+	// concurrent use.
+	Workers int
 }
 
 // GenManHeader is a lot like the .TH header at the start of man pages. These
@@ -97,6 +98,24 @@ type GenManHeader struct {
 	date    string
 	Source  string
 	Manual  string
+
+	// Locale selects the titles of the sections of the page in ManSectionTitlesByLocale,
+	// e.g. "fr" or "fr_CA". The English titles are used if it is unset or unknown.
+	Locale string
+	titles ManSectionTitles
+	// Translate, if set, returns the translation of a text of the page of cmd, e.g.
+	// from a message catalog. The field is "Short", "Long" or "Example" for the texts
+	// of the command, or "flag:<name>" for the usage of a flag. It returns text to
+	// keep it untranslated.
+	Translate func(cmd *cobra.Command, field, text string) string
+}
+
+// translate returns the translation of the field of cmd by header.Translate, if set.
+func (header *GenManHeader) translate(cmd *cobra.Command, field, text string) string {
+	if header.Translate == nil || text == "" {
+		return text
+	}
+	return header.Translate(cmd, field, text)
 }
 
 // GenMan will generate a man page for the given command and write it to
@@ -133,31 +152,35 @@ func fillHeader(header *GenManHeader, name string) error {
 		header.Date = &now
 	}
 	header.date = (*header.Date).Format("Jan 2006")
+	header.titles = manSectionTitlesFor(header.Locale)
 	if header.Source == "" {
-		header.Source = "Auto generated by spf13/cobra"
+		header.Source = header.titles.AutoGenerated
 	}
 	return nil
 }
 
 func manPreamble(buf *bytes.Buffer, header *GenManHeader, cmd *cobra.Command, dashedName string) {
-	description := cmd.Long
+	short := header.translate(cmd, "Short", cmd.Short)
+	description := header.translate(cmd, "Long", cmd.Long)
 	if len(description) == 0 {
-		description = cmd.Short
+		description = short
 	}
 
 	buf.WriteString(fmt.Sprintf(`%% %s(%s)%s
 %% %s
 %% %s
-# NAME
-`, header.Title, header.Section, header.date, header.Source, header.Manual))
-	buf.WriteString(fmt.Sprintf("%s \\- %s\n\n", dashedName, cmd.Short))
-	buf.WriteString("# SYNOPSIS\n")
+# %s
+`, header.Title, header.Section, header.date, header.Source, header.Manual, header.titles.Name))
+	buf.WriteString(fmt.Sprintf("%s \\- %s\n\n", dashedName, short))
+	buf.WriteString("# " + header.titles.Synopsis + "\n")
 	buf.WriteString(fmt.Sprintf("**%s**\n\n", cmd.UseLine()))
-	buf.WriteString("# DESCRIPTION\n")
+	buf.WriteString("# " + header.titles.Description + "\n")
 	buf.WriteString(description + "\n\n")
 }
 
-func manPrintFlags(buf *bytes.Buffer, flags *pflag.FlagSet) {
+// manPrintFlags writes the flags to buf. The usage of the flags is given by usage, or
+// is their Usage field if usage is nil.
+func manPrintFlags(buf *bytes.Buffer, flags *pflag.FlagSet, usage func(*pflag.Flag) string) {
 	flags.VisitAll(func(flag *pflag.Flag) {
 		if len(flag.Deprecated) > 0 || flag.Hidden {
 			return
@@ -181,21 +204,28 @@ func manPrintFlags(buf *bytes.Buffer, flags *pflag.FlagSet) {
 			format += "]"
 		}
 		format += "\n\t%s\n\n"
-		buf.WriteString(fmt.Sprintf(format, flag.DefValue, flag.Usage))
+		text := flag.Usage
+		if usage != nil {
+			text = usage(flag)
+		}
+		buf.WriteString(fmt.Sprintf(format, flag.DefValue, text))
 	})
 }
 
-func manPrintOptions(buf *bytes.Buffer, command *cobra.Command) {
-	flags := command.NonInheritedFlags()
+func manPrintOptions(buf *bytes.Buffer, header *GenManHeader, command *cobra.Command) {
+	usage := func(flag *pflag.Flag) string {
+		return header.translate(command, "flag:"+flag.Name, flag.Usage)
+	}
+	flags := command.DisplayFlags(command.NonInheritedFlags())
 	if flags.HasAvailableFlags() {
-		buf.WriteString("# OPTIONS\n")
-		manPrintFlags(buf, flags)
+		buf.WriteString("# " + header.titles.Options + "\n")
+		manPrintFlags(buf, flags, usage)
 		buf.WriteString("\n")
 	}
-	flags = command.InheritedFlags()
+	flags = command.DisplayFlags(command.InheritedFlags())
 	if flags.HasAvailableFlags() {
-		buf.WriteString("# OPTIONS INHERITED FROM PARENT COMMANDS\n")
-		manPrintFlags(buf, flags)
+		buf.WriteString("# " + header.titles.InheritedOptions + "\n")
+		manPrintFlags(buf, flags, usage)
 		buf.WriteString("\n")
 	}
 }
@@ -210,13 +240,13 @@ func genMan(cmd *cobra.Command, header *GenManHeader) []byte {
 	buf := new(bytes.Buffer)
 
 	manPreamble(buf, header, cmd, dashCommandName)
-	manPrintOptions(buf, cmd)
+	manPrintOptions(buf, header, cmd)
 	if len(cmd.Example) > 0 {
-		buf.WriteString("# EXAMPLE\n")
-		buf.WriteString(fmt.Sprintf("```\n%s\n```\n", cmd.Example))
+		buf.WriteString("# " + header.titles.Example + "\n")
+		buf.WriteString(fmt.Sprintf("```\n%s\n```\n", header.translate(cmd, "Example", cmd.Example)))
 	}
 	if hasSeeAlso(cmd) {
-		buf.WriteString("# SEE ALSO\n")
+		buf.WriteString("# " + header.titles.SeeAlso + "\n")
 		seealsos := make([]string, 0)
 		if cmd.HasParent() {
 			parentPath := cmd.Parent().CommandPath()
@@ -224,7 +254,7 @@ func genMan(cmd *cobra.Command, header *GenManHeader) []byte {
 			seealso := fmt.Sprintf("**%s(%s)**", dashParentPath, header.Section)
 			seealsos = append(seealsos, seealso)
 			cmd.VisitParents(func(c *cobra.Command) {
-				if c.DisableAutoGenTag {
+				if c.DisableAutoGenTag && !cmd.DisableAutoGenTag {
 					cmd.DisableAutoGenTag = c.DisableAutoGenTag
 				}
 			})
@@ -241,7 +271,7 @@ func genMan(cmd *cobra.Command, header *GenManHeader) []byte {
 		buf.WriteString(strings.Join(seealsos, ", ") + "\n")
 	}
 	if !cmd.DisableAutoGenTag {
-		buf.WriteString(fmt.Sprintf("# HISTORY\n%s Auto generated by spf13/cobra\n", header.Date.Format("2-Jan-2006")))
+		buf.WriteString(fmt.Sprintf("# %s\n%s %s\n", header.titles.History, header.Date.Format("2-Jan-2006"), header.titles.AutoGenerated))
 	}
 	return buf.Bytes()
 }
@@ -9,6 +9,7 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/spf13/cobra"
 )
@@ -128,13 +129,61 @@ func TestGenManSeeAlso(t *testing.T) {
 	}
 }
 
+func TestGenManLocalized(t *testing.T) {
+	cmd := &cobra.Command{Use: "app", Short: "short", Long: "long", Example: "app --name x", Run: emptyRun}
+	cmd.Flags().String("name", "", "the name")
+	catalog := map[string]string{
+		"Short":     "court",
+		"Long":      "long en français",
+		"flag:name": "le nom",
+	}
+	header := &GenManHeader{
+		Locale: "fr_CA.UTF-8",
+		Translate: func(c *cobra.Command, field, text string) string {
+			if tr, ok := catalog[field]; ok {
+				return tr
+			}
+			return text
+		},
+	}
+	buf := new(bytes.Buffer)
+	if err := GenMan(cmd, header, buf); err != nil {
+		t.Fatal(err)
+	}
+	output := buf.String()
+
+	for _, s := range []string{"NOM", "EXEMPLE", "HISTORIQUE", "Généré automatiquement", "app \\- court", "long en français", "le nom", "app \\-\\-name x"} {
+		checkStringContains(t, output, s)
+	}
+	for _, s := range []string{"NAME", "EXAMPLE", "the name"} {
+		checkStringOmits(t, output, s)
+	}
+}
+
+func TestManSectionTitlesFor(t *testing.T) {
+	tests := map[string]string{
+		"":            "NAME",
+		"en_US.UTF-8": "NAME",
+		"fr":          "NOM",
+		"fr_CA":       "NOM",
+		"FR-ca":       "NOM",
+		"es_MX":       "NOMBRE",
+		"xx_YY":       "NAME",
+	}
+	for locale, expected := range tests {
+		if got := manSectionTitlesFor(locale).Name; got != expected {
+			t.Errorf("Expected %q for locale %q, got %q", expected, locale, got)
+		}
+	}
+}
+
 func TestManPrintFlagsHidesShortDeperecated(t *testing.T) {
 	c := &cobra.Command{}
 	c.Flags().StringP("foo", "f", "default", "Foo flag")
 	c.Flags().MarkShorthandDeprecated("foo", "don't use it no more")
 
 	buf := new(bytes.Buffer)
-	manPrintFlags(buf, c.Flags())
+	manPrintFlags(buf, c.Flags(), nil)
 
 	got := buf.String()
 	expected := "**--foo**=\"default\"\n\tFoo flag\n\n"
@@ -196,6 +245,51 @@ func assertNextLineEquals(scanner *bufio.Scanner, expectedLine string) error {
 	return fmt.Errorf("hit EOF before finding %v", expectedLine)
 }
 
+func TestGenManTreeWorkers(t *testing.T) {
+	tmpdir, err := ioutil.TempDir("", "test-gen-man-tree-workers")
+	if err != nil {
+		t.Fatalf("Failed to create tmpdir: %s", err.Error())
+	}
+	defer os.RemoveAll(tmpdir)
+
+	sequential, parallel := filepath.Join(tmpdir, "sequential"), filepath.Join(tmpdir, "parallel")
+	for _, dir := range []string{sequential, parallel} {
+		if err := os.Mkdir(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	date := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	header := &GenManHeader{Date: &date}
+	if err := GenManTreeFromOpts(newLargeTree(3, 3), GenManTreeOptions{Header: header, Path: sequential}); err != nil {
+		t.Fatal(err)
+	}
+	opts := GenManTreeOptions{Header: header, Path: parallel, Workers: 8}
+	if err := GenManTreeFromOpts(newLargeTree(3, 3), opts); err != nil {
+		t.Fatal(err)
+	}
+	checkSameDirs(t, sequential, parallel)
+}
+
+func BenchmarkGenManTree(b *testing.B) {
+	tmpdir, err := ioutil.TempDir("", "bench-gen-man-tree")
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer os.RemoveAll(tmpdir)
+
+	cmd := newLargeTree(10, 2)
+	for _, workers := range []int{1, 8} {
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+			opts := GenManTreeOptions{Path: tmpdir, Workers: workers}
+			for i := 0; i < b.N; i++ {
+				if err := GenManTreeFromOpts(cmd, opts); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
 func BenchmarkGenManToFile(b *testing.B) {
 	file, err := ioutil.TempFile("", "")
 	if err != nil {
@@ -0,0 +1,80 @@
+package doc
+
+import "strings"
+
+// ManSectionTitles holds the titles of the sections of the man pages, and the
+// mention of their generation.
+type ManSectionTitles struct {
+	Name             string
+	Synopsis         string
+	Description      string
+	Options          string
+	InheritedOptions string
+	Example          string
+	SeeAlso          string
+	History          string
+	AutoGenerated    string
+}
+
+// ManSectionTitlesByLocale holds the titles of the sections of the man pages by
+// language, as selected by GenManHeader.Locale. Titles of other languages can be added.
+var ManSectionTitlesByLocale = map[string]ManSectionTitles{
+	"en": {
+		Name:             "NAME",
+		Synopsis:         "SYNOPSIS",
+		Description:      "DESCRIPTION",
+		Options:          "OPTIONS",
+		InheritedOptions: "OPTIONS INHERITED FROM PARENT COMMANDS",
+		Example:          "EXAMPLE",
+		SeeAlso:          "SEE ALSO",
+		History:          "HISTORY",
+		AutoGenerated:    "Auto generated by spf13/cobra",
+	},
+	"fr": {
+		Name:             "NOM",
+		Synopsis:         "SYNOPSIS",
+		Description:      "DESCRIPTION",
+		Options:          "OPTIONS",
+		InheritedOptions: "OPTIONS HÉRITÉES DES COMMANDES PARENTES",
+		Example:          "EXEMPLE",
+		SeeAlso:          "VOIR AUSSI",
+		History:          "HISTORIQUE",
+		AutoGenerated:    "Généré automatiquement par spf13/cobra",
+	},
+	"es": {
+		Name:             "NOMBRE",
+		Synopsis:         "SINOPSIS",
+		Description:      "DESCRIPCIÓN",
+		Options:          "OPCIONES",
+		InheritedOptions: "OPCIONES HEREDADAS DE LOS COMANDOS PADRES",
+		Example:          "EJEMPLO",
+		SeeAlso:          "VÉASE TAMBIÉN",
+		History:          "HISTORIAL",
+		AutoGenerated:    "Generado automáticamente por spf13/cobra",
+	},
+	"de": {
+		Name:             "NAME",
+		Synopsis:         "ÜBERSICHT",
+		Description:      "BESCHREIBUNG",
+		Options:          "OPTIONEN",
+		InheritedOptions: "VON ÜBERGEORDNETEN BEFEHLEN GEERBTE OPTIONEN",
+		Example:          "BEISPIEL",
+		SeeAlso:          "SIEHE AUCH",
+		History:          "GESCHICHTE",
+		AutoGenerated:    "Automatisch generiert von spf13/cobra",
+	},
+}
+
+// manSectionTitlesFor returns the titles of the locale, falling back to its language,
+// e.g. "fr" for "fr_CA.UTF-8", then to English.
+func manSectionTitlesFor(locale string) ManSectionTitles {
+	if titles, ok := ManSectionTitlesByLocale[locale]; ok {
+		return titles
+	}
+	if i := strings.IndexAny(locale, "_-."); i > 0 {
+		if titles, ok := ManSectionTitlesByLocale[strings.ToLower(locale[:i])]; ok {
+			return titles
+		}
+	}
+	return ManSectionTitlesByLocale["en"]
+}
@@ -17,17 +17,19 @@ import (
 	"bytes"
 	"fmt"
 	"io"
-	"os"
 	"path/filepath"
 	"sort"
 	"strings"
+	"text/template"
 	"time"
+	"unicode"
 
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 )
 
 func printOptions(buf *bytes.Buffer, cmd *cobra.Command, name string) error {
-	flags := cmd.NonInheritedFlags()
+	flags := cmd.DisplayFlags(cmd.NonInheritedFlags())
 	flags.SetOutput(buf)
 	if flags.HasAvailableFlags() {
 		buf.WriteString("### Options\n\n```\n")
@@ -35,7 +37,7 @@ func printOptions(buf *bytes.Buffer, cmd *cobra.Command, name string) error {
 		buf.WriteString("```\n\n")
 	}
 
-	parentFlags := cmd.InheritedFlags()
+	parentFlags := cmd.DisplayFlags(cmd.InheritedFlags())
 	parentFlags.SetOutput(buf)
 	if parentFlags.HasAvailableFlags() {
 		buf.WriteString("### Options inherited from parent commands\n\n```\n")
@@ -45,19 +47,105 @@ func printOptions(buf *bytes.Buffer, cmd *cobra.Command, name string) error {
 	return nil
 }
 
+// printEnvVars lists the environment variables bound to the flags of cmd.
+func printEnvVars(buf *bytes.Buffer, cmd *cobra.Command) {
+	vars := cmd.EnvVars()
+	if len(vars) == 0 {
+		return
+	}
+	buf.WriteString("### Environment variables\n\n")
+	for _, v := range vars {
+		buf.WriteString(fmt.Sprintf("* `%s`: sets `--%s`", v.Name, v.Flag.Name))
+		if v.Flag.Usage != "" {
+			buf.WriteString(", " + v.Flag.Usage)
+		}
+		buf.WriteString("\n")
+	}
+	buf.WriteString("\n")
+}
+
+func printCompletionCoverage(buf *bytes.Buffer, cmd *cobra.Command) {
+	status := func(completed bool) string {
+		if completed {
+			return "completed"
+		}
+		return "not completed"
+	}
+	var lines []string
+	if cmd.Runnable() {
+		lines = append(lines, "* arguments: "+status(cmd.HasArgsCompletion()))
+	}
+	cmd.NonInheritedFlags().VisitAll(func(f *pflag.Flag) {
+		if f.Hidden || f.Deprecated != "" {
+			return
+		}
+		lines = append(lines, fmt.Sprintf("* `--%s`: %s", f.Name, status(cobra.FlagHasCompletion(f))))
+	})
+	if len(lines) == 0 {
+		return
+	}
+	buf.WriteString("### Completion\n\n")
+	buf.WriteString(strings.Join(lines, "\n") + "\n\n")
+}
+
 // GenMarkdown creates markdown output.
 func GenMarkdown(cmd *cobra.Command, w io.Writer) error {
 	return GenMarkdownCustom(cmd, w, func(s string) string { return s })
 }
 
+// MarkdownOptions customizes the markdown output.
+type MarkdownOptions struct {
+	// FrontMatter is a text/template executed with each command, whose output is
+	// prepended to its markdown, e.g. to set the weight and categories of the page:
+	//   ---
+	//   title: "{{.CommandPath}}"
+	//   weight: {{index .Annotations "weight"}}
+	//   ---
+	// The anchor template function returns the anchor of a heading, see MarkdownAnchor.
+	FrontMatter string
+	// LinkHandler customizes the links to the commands, given a filename.
+	// Use AnchorLinkHandler to link to headings of a single file.
+	LinkHandler func(string) string
+	// DisableAutoGenTag omits the dated "Auto generated by spf13/cobra" footer.
+	DisableAutoGenTag bool
+	// CompletionCoverage adds a Completion section telling whether the shell completion
+	// offers values for the arguments and for each flag of the command, to track the
+	// completion coverage of a command tree.
+	CompletionCoverage bool
+	// Workers is the number of pages GenMarkdownTreeWithOptions generates concurrently,
+	// which speeds up the generation for large command trees. The pages are generated
+	// one by one if it is zero or one. The LinkHandler must then be safe for
+	// concurrent use.
+	Workers int
+}
+
 // GenMarkdownCustom creates custom markdown output.
 func GenMarkdownCustom(cmd *cobra.Command, w io.Writer, linkHandler func(string) string) error {
+	return GenMarkdownWithOptions(cmd, w, MarkdownOptions{LinkHandler: linkHandler})
+}
+
+// GenMarkdownWithOptions creates markdown output customized by opts.
+func GenMarkdownWithOptions(cmd *cobra.Command, w io.Writer, opts MarkdownOptions) error {
+	linkHandler := opts.LinkHandler
+	if linkHandler == nil {
+		linkHandler = func(s string) string { return s }
+	}
 	cmd.InitDefaultHelpCmd()
 	cmd.InitDefaultHelpFlag()
 
 	buf := new(bytes.Buffer)
 	name := cmd.CommandPath()
 
+	if opts.FrontMatter != "" {
+		t, err := template.New("front matter").Funcs(template.FuncMap{"anchor": MarkdownAnchor}).Parse(opts.FrontMatter)
+		if err != nil {
+			return err
+		}
+		if err := t.Execute(buf, cmd); err != nil {
+			return err
+		}
+	}
+
 	short := cmd.Short
 	long := cmd.Long
 	if len(long) == 0 {
@@ -81,6 +169,10 @@ func GenMarkdownCustom(cmd *cobra.Command, w io.Writer, linkHandler func(string)
 	if err := printOptions(buf, cmd, name); err != nil {
 		return err
 	}
+	printEnvVars(buf, cmd)
+	if opts.CompletionCoverage {
+		printCompletionCoverage(buf, cmd)
+	}
 	if hasSeeAlso(cmd) {
 		buf.WriteString("### SEE ALSO\n\n")
 		if cmd.HasParent() {
@@ -90,7 +182,7 @@ func GenMarkdownCustom(cmd *cobra.Command, w io.Writer, linkHandler func(string)
 			link = strings.Replace(link, " ", "_", -1)
 			buf.WriteString(fmt.Sprintf("* [%s](%s)\t - %s\n", pname, linkHandler(link), parent.Short))
 			cmd.VisitParents(func(c *cobra.Command) {
-				if c.DisableAutoGenTag {
+				if c.DisableAutoGenTag && !cmd.DisableAutoGenTag {
 					cmd.DisableAutoGenTag = c.DisableAutoGenTag
 				}
 			})
@@ -110,7 +202,7 @@ func GenMarkdownCustom(cmd *cobra.Command, w io.Writer, linkHandler func(string)
 		}
 		buf.WriteString("\n")
 	}
-	if !cmd.DisableAutoGenTag {
+	if !cmd.DisableAutoGenTag && !opts.DisableAutoGenTag {
 		buf.WriteString("###### Auto generated by spf13/cobra on " + time.Now().Format("2-Jan-2006") + "\n")
 	}
 	_, err := buf.WriteTo(w)
@@ -132,28 +224,49 @@ func GenMarkdownTree(cmd *cobra.Command, dir string) error {
 // GenMarkdownTreeCustom is the the same as GenMarkdownTree, but
 // with custom filePrepender and linkHandler.
 func GenMarkdownTreeCustom(cmd *cobra.Command, dir string, filePrepender, linkHandler func(string) string) error {
-	for _, c := range cmd.Commands() {
-		if !c.IsAvailableCommand() || c.IsAdditionalHelpTopicCommand() {
-			continue
-		}
-		if err := GenMarkdownTreeCustom(c, dir, filePrepender, linkHandler); err != nil {
-			return err
-		}
-	}
+	return genMarkdownTree(cmd, dir, filePrepender, MarkdownOptions{LinkHandler: linkHandler})
+}
+
+// GenMarkdownTreeWithOptions is the the same as GenMarkdownTree, but
+// with the markdown output customized by opts.
+func GenMarkdownTreeWithOptions(cmd *cobra.Command, dir string, opts MarkdownOptions) error {
+	return genMarkdownTree(cmd, dir, func(string) string { return "" }, opts)
+}
 
+func genMarkdownTree(cmd *cobra.Command, dir string, filePrepender func(string) string, opts MarkdownOptions) error {
+	return genTree(cmd, opts.Workers, func(c *cobra.Command) error {
+		return genMarkdownFile(c, dir, filePrepender, opts)
+	})
+}
+
+func genMarkdownFile(cmd *cobra.Command, dir string, filePrepender func(string) string, opts MarkdownOptions) error {
 	basename := strings.Replace(cmd.CommandPath(), " ", "_", -1) + ".md"
 	filename := filepath.Join(dir, basename)
-	f, err := os.Create(filename)
-	if err != nil {
+	buf := bytes.NewBufferString(filePrepender(filename))
+	if err := GenMarkdownWithOptions(cmd, buf, opts); err != nil {
 		return err
 	}
-	defer f.Close()
+	return writeFileIfChanged(filename, buf.Bytes())
+}
 
-	if _, err := io.WriteString(f, filePrepender(filename)); err != nil {
-		return err
-	}
-	if err := GenMarkdownCustom(cmd, f, linkHandler); err != nil {
-		return err
+// AnchorLinkHandler is a link handler turning the link to the markdown file of a
+// command into a link to its heading, for the documentation of several commands
+// in a single file.
+func AnchorLinkHandler(link string) string {
+	return "#" + MarkdownAnchor(strings.Replace(strings.TrimSuffix(link, ".md"), "_", " ", -1))
+}
+
+// MarkdownAnchor returns the anchor GitHub generates for a heading: lower case, with
+// spaces replaced by dashes and punctuation removed.
+func MarkdownAnchor(heading string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(heading) {
+		switch {
+		case r == ' ':
+			b.WriteRune('-')
+		case r == '-' || r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r):
+			b.WriteRune(r)
+		}
 	}
-	return nil
+	return b.String()
 }
@@ -2,10 +2,13 @@ package doc
 
 import (
 	"bytes"
+	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/spf13/cobra"
 )
@@ -81,6 +84,98 @@ func TestGenMdTree(t *testing.T) {
 	}
 }
 
+func TestGenMdTreeWorkers(t *testing.T) {
+	tmpdir, err := ioutil.TempDir("", "test-gen-md-tree-workers")
+	if err != nil {
+		t.Fatalf("Failed to create tmpdir: %v", err)
+	}
+	defer os.RemoveAll(tmpdir)
+
+	sequential, parallel := filepath.Join(tmpdir, "sequential"), filepath.Join(tmpdir, "parallel")
+	for _, dir := range []string{sequential, parallel} {
+		if err := os.Mkdir(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	opts := MarkdownOptions{DisableAutoGenTag: true}
+	if err := GenMarkdownTreeWithOptions(newLargeTree(3, 3), sequential, opts); err != nil {
+		t.Fatal(err)
+	}
+	opts.Workers = 8
+	if err := GenMarkdownTreeWithOptions(newLargeTree(3, 3), parallel, opts); err != nil {
+		t.Fatal(err)
+	}
+	checkSameDirs(t, sequential, parallel)
+
+	err = GenMarkdownTreeWithOptions(newLargeTree(3, 3), filepath.Join(tmpdir, "missing"), opts)
+	if err == nil {
+		t.Error("Expected an error for a missing directory")
+	}
+}
+
+func TestGenMdTreeKeepsUnchangedFiles(t *testing.T) {
+	tmpdir, err := ioutil.TempDir("", "test-gen-md-tree-unchanged")
+	if err != nil {
+		t.Fatalf("Failed to create tmpdir: %v", err)
+	}
+	defer os.RemoveAll(tmpdir)
+
+	c := &cobra.Command{Use: "do", Short: "Do something", Run: emptyRun}
+	sub := &cobra.Command{Use: "sub", Short: "Sub something", Run: emptyRun}
+	c.AddCommand(sub)
+	opts := MarkdownOptions{DisableAutoGenTag: true}
+	if err := GenMarkdownTreeWithOptions(c, tmpdir, opts); err != nil {
+		t.Fatal(err)
+	}
+
+	filename := filepath.Join(tmpdir, "do_sub.md")
+	old := time.Now().Add(-time.Hour).Truncate(time.Second)
+	if err := os.Chtimes(filename, old, old); err != nil {
+		t.Fatal(err)
+	}
+	if err := GenMarkdownTreeWithOptions(c, tmpdir, opts); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !info.ModTime().Equal(old) {
+		t.Error("Expected unchanged do_sub.md not to be rewritten")
+	}
+
+	sub.Short = "Sub something else"
+	if err := GenMarkdownTreeWithOptions(c, tmpdir, opts); err != nil {
+		t.Fatal(err)
+	}
+	if info, err = os.Stat(filename); err != nil {
+		t.Fatal(err)
+	}
+	if info.ModTime().Equal(old) {
+		t.Error("Expected changed do_sub.md to be rewritten")
+	}
+}
+
+func BenchmarkGenMarkdownTree(b *testing.B) {
+	tmpdir, err := ioutil.TempDir("", "bench-gen-md-tree")
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer os.RemoveAll(tmpdir)
+
+	cmd := newLargeTree(10, 2)
+	for _, workers := range []int{1, 8} {
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+			opts := MarkdownOptions{Workers: workers}
+			for i := 0; i < b.N; i++ {
+				if err := GenMarkdownTreeWithOptions(cmd, tmpdir, opts); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
 func BenchmarkGenMarkdownToFile(b *testing.B) {
 	file, err := ioutil.TempFile("", "")
 	if err != nil {
@@ -96,3 +191,92 @@ func BenchmarkGenMarkdownToFile(b *testing.B) {
 		}
 	}
 }
+
+func TestGenMdWithOptions(t *testing.T) {
+	buf := new(bytes.Buffer)
+	opts := MarkdownOptions{
+		FrontMatter:       "---\ntitle: \"{{.CommandPath}}\"\nslug: {{anchor .CommandPath}}\n---\n",
+		LinkHandler:       AnchorLinkHandler,
+		DisableAutoGenTag: true,
+	}
+	if err := GenMarkdownWithOptions(echoCmd, buf, opts); err != nil {
+		t.Fatal(err)
+	}
+	output := buf.String()
+
+	if !strings.HasPrefix(output, "---\ntitle: \"root echo\"\nslug: root-echo\n---\n## root echo\n") {
+		t.Errorf("Expected front matter, got:\n%s", output)
+	}
+	checkStringContains(t, output, "[root echo times](#root-echo-times)")
+	checkStringOmits(t, output, "Auto generated by spf13/cobra")
+}
+
+func TestGenMdCompletionCoverage(t *testing.T) {
+	buf := new(bytes.Buffer)
+	if err := GenMarkdownWithOptions(timesCmd, buf, MarkdownOptions{CompletionCoverage: true}); err != nil {
+		t.Fatal(err)
+	}
+	output := buf.String()
+
+	checkStringContains(t, output, "### Completion\n\n")
+	checkStringContains(t, output, "* arguments: not completed\n")
+	checkStringContains(t, output, "* `--inttwo`: not completed\n")
+	checkStringContains(t, output, "* `--booltwo`: completed\n")
+
+	buf.Reset()
+	if err := GenMarkdown(timesCmd, buf); err != nil {
+		t.Fatal(err)
+	}
+	checkStringOmits(t, buf.String(), "### Completion")
+}
+
+func TestGenMdTreeWithOptions(t *testing.T) {
+	c := &cobra.Command{Use: "do [OPTIONS] arg1 arg2", Annotations: map[string]string{"weight": "10"}}
+	tmpdir, err := ioutil.TempDir("", "test-gen-md-tree-options")
+	if err != nil {
+		t.Fatalf("Failed to create tmpdir: %v", err)
+	}
+	defer os.RemoveAll(tmpdir)
+
+	opts := MarkdownOptions{FrontMatter: "weight: {{index .Annotations \"weight\"}}\n"}
+	if err := GenMarkdownTreeWithOptions(c, tmpdir, opts); err != nil {
+		t.Fatalf("GenMarkdownTreeWithOptions failed: %v", err)
+	}
+	b, err := ioutil.ReadFile(filepath.Join(tmpdir, "do.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(b), "weight: 10\n") {
+		t.Errorf("Expected front matter, got:\n%s", b)
+	}
+}
+
+func TestMarkdownAnchor(t *testing.T) {
+	tests := map[string]string{
+		"root echo":        "root-echo",
+		"Root Echo_Times":  "root-echo_times",
+		"root echo (beta)": "root-echo-beta",
+		"root sub-command": "root-sub-command",
+	}
+	for heading, expected := range tests {
+		if got := MarkdownAnchor(heading); got != expected {
+			t.Errorf("MarkdownAnchor(%q): expected %q, got %q", heading, expected, got)
+		}
+	}
+}
+
+func TestGenMdEnvVars(t *testing.T) {
+	root := &cobra.Command{Use: "root"}
+	root.PersistentFlags().String("token", "", "API token")
+	root.MarkPersistentFlagEnv("token", "APP_TOKEN")
+	deploy := &cobra.Command{Use: "deploy", Run: emptyRun}
+	deploy.Flags().String("region", "", "AWS region")
+	deploy.MarkFlagEnv("region", "APP_REGION")
+	root.AddCommand(deploy)
+
+	buf := new(bytes.Buffer)
+	if err := GenMarkdown(deploy, buf); err != nil {
+		t.Fatal(err)
+	}
+	checkStringContains(t, buf.String(), "### Environment variables\n\n* `APP_REGION`: sets `--region`, AWS region\n* `APP_TOKEN`: sets `--token`, API token\n\n")
+}
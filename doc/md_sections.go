@@ -0,0 +1,123 @@
+package doc
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+const (
+	markdownSectionBegin = "<!-- cobra:begin"
+	markdownSectionEnd   = "<!-- cobra:end -->"
+)
+
+var markdownSectionBeginRe = regexp.MustCompile(`^<!-- cobra:begin((?: [^ ]+)*) -->$`)
+
+// UpdateMarkdownSections rewrites the delimited sections of the markdown file with the
+// reference of the commands of the tree of cmd, preserving the rest of the file.
+// A section starts with a "<!-- cobra:begin -->" line, for the reference of cmd and
+// its subcommands, or with a "<!-- cobra:begin root sub -->" line, for the reference of
+// the named command and its subcommands. It ends with a "<!-- cobra:end -->" line.
+// The links between the commands point to the headings of the file.
+func UpdateMarkdownSections(filename string, cmd *cobra.Command) error {
+	info, err := os.Stat(filename)
+	if err != nil {
+		return err
+	}
+	content, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+	updated, err := updateMarkdownSections(content, cmd)
+	if err != nil {
+		return fmt.Errorf("%s: %v", filename, err)
+	}
+	if bytes.Equal(content, updated) {
+		return nil
+	}
+	return ioutil.WriteFile(filename, updated, info.Mode())
+}
+
+func updateMarkdownSections(content []byte, cmd *cobra.Command) ([]byte, error) {
+	out := new(bytes.Buffer)
+	lines := strings.SplitAfter(string(content), "\n")
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+		if !strings.HasPrefix(trimmed, markdownSectionBegin) {
+			out.WriteString(line)
+			continue
+		}
+		m := markdownSectionBeginRe.FindStringSubmatch(trimmed)
+		if m == nil {
+			return nil, fmt.Errorf("line %d: invalid section start %q", i+1, trimmed)
+		}
+		section, err := findCommandByPath(cmd, strings.Fields(m[1]))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", i+1, err)
+		}
+		end := i + 1
+		for end < len(lines) && strings.TrimSpace(lines[end]) != markdownSectionEnd {
+			end++
+		}
+		if end == len(lines) {
+			return nil, fmt.Errorf("line %d: section is not ended by %q", i+1, markdownSectionEnd)
+		}
+
+		out.WriteString(line)
+		out.WriteString("\n")
+		if err := genMarkdownSection(section, out); err != nil {
+			return nil, err
+		}
+		out.WriteString(lines[end])
+		i = end
+	}
+	return out.Bytes(), nil
+}
+
+// findCommandByPath returns the command of the tree of cmd with the given path, or cmd
+// if the path is empty.
+func findCommandByPath(cmd *cobra.Command, path []string) (*cobra.Command, error) {
+	if len(path) == 0 {
+		return cmd, nil
+	}
+	c := cmd.Root()
+	if path[0] != c.Name() {
+		return nil, fmt.Errorf("unknown command %q", strings.Join(path, " "))
+	}
+next:
+	for _, name := range path[1:] {
+		for _, sub := range c.Commands() {
+			if sub.Name() == name {
+				c = sub
+				continue next
+			}
+		}
+		return nil, fmt.Errorf("unknown command %q", strings.Join(path, " "))
+	}
+	return c, nil
+}
+
+// genMarkdownSection writes the reference of cmd and its subcommands, without the
+// auto generated tags whose dates would change the file at each update.
+func genMarkdownSection(cmd *cobra.Command, out *bytes.Buffer) error {
+	opts := MarkdownOptions{LinkHandler: AnchorLinkHandler, DisableAutoGenTag: true}
+	if err := GenMarkdownWithOptions(cmd, out, opts); err != nil {
+		return err
+	}
+
+	for _, c := range cmd.Commands() {
+		if !c.IsAvailableCommand() || c.IsAdditionalHelpTopicCommand() {
+			continue
+		}
+		if err := genMarkdownSection(c, out); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package doc
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestUpdateMarkdownSections(t *testing.T) {
+	tmpdir, err := ioutil.TempDir("", "test-gen-md-sections")
+	if err != nil {
+		t.Fatalf("Failed to create tmpdir: %v", err)
+	}
+	defer os.RemoveAll(tmpdir)
+
+	readme := filepath.Join(tmpdir, "README.md")
+	original := "# Title\n\nSome prose.\n\n<!-- cobra:begin root echo -->\nstale content\n<!-- cobra:end -->\n\nMore prose.\n"
+	if err := ioutil.WriteFile(readme, []byte(original), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := UpdateMarkdownSections(readme, rootCmd); err != nil {
+		t.Fatal(err)
+	}
+	b, err := ioutil.ReadFile(readme)
+	if err != nil {
+		t.Fatal(err)
+	}
+	output := string(b)
+
+	if !strings.HasPrefix(output, "# Title\n\nSome prose.\n\n<!-- cobra:begin root echo -->\n") {
+		t.Errorf("Expected the prose before the section to be preserved, got:\n%s", output)
+	}
+	if !strings.HasSuffix(output, "<!-- cobra:end -->\n\nMore prose.\n") {
+		t.Errorf("Expected the prose after the section to be preserved, got:\n%s", output)
+	}
+	checkStringOmits(t, output, "stale content")
+	checkStringContains(t, output, "## root echo\n")
+	checkStringContains(t, output, "## root echo times\n")
+	checkStringContains(t, output, "[root echo times](#root-echo-times)")
+	checkStringOmits(t, output, "## root print")
+	checkStringOmits(t, output, "Auto generated by spf13/cobra")
+
+	// Updating again must not change anything.
+	if err := UpdateMarkdownSections(readme, rootCmd); err != nil {
+		t.Fatal(err)
+	}
+	again, err := ioutil.ReadFile(readme)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(again) != output {
+		t.Errorf("Expected updates to be stable, got:\n%s", again)
+	}
+}
+
+func TestUpdateMarkdownSectionsErrors(t *testing.T) {
+	for _, content := range []string{
+		"<!-- cobra:begin -->\nnever ended\n",
+		"<!-- cobra:begin root unknown -->\n<!-- cobra:end -->\n",
+	} {
+		if _, err := updateMarkdownSections([]byte(content), rootCmd); err == nil {
+			t.Errorf("Expected error for %q", content)
+		}
+	}
+}
@@ -17,7 +17,6 @@ import (
 	"bytes"
 	"fmt"
 	"io"
-	"os"
 	"path/filepath"
 	"sort"
 	"strings"
@@ -27,7 +26,7 @@ import (
 )
 
 func printOptionsReST(buf *bytes.Buffer, cmd *cobra.Command, name string) error {
-	flags := cmd.NonInheritedFlags()
+	flags := cmd.DisplayFlags(cmd.NonInheritedFlags())
 	flags.SetOutput(buf)
 	if flags.HasAvailableFlags() {
 		buf.WriteString("Options\n")
@@ -36,7 +35,7 @@ func printOptionsReST(buf *bytes.Buffer, cmd *cobra.Command, name string) error
 		buf.WriteString("\n")
 	}
 
-	parentFlags := cmd.InheritedFlags()
+	parentFlags := cmd.DisplayFlags(cmd.InheritedFlags())
 	parentFlags.SetOutput(buf)
 	if parentFlags.HasAvailableFlags() {
 		buf.WriteString("Options inherited from parent commands\n")
@@ -153,19 +152,11 @@ func GenReSTTreeCustom(cmd *cobra.Command, dir string, filePrepender func(string
 
 	basename := strings.Replace(cmd.CommandPath(), " ", "_", -1) + ".rst"
 	filename := filepath.Join(dir, basename)
-	f, err := os.Create(filename)
-	if err != nil {
+	buf := bytes.NewBufferString(filePrepender(filename))
+	if err := GenReSTCustom(cmd, buf, linkHandler); err != nil {
 		return err
 	}
-	defer f.Close()
-
-	if _, err := io.WriteString(f, filePrepender(filename)); err != nil {
-		return err
-	}
-	if err := GenReSTCustom(cmd, f, linkHandler); err != nil {
-		return err
-	}
-	return nil
+	return writeFileIfChanged(filename, buf.Bytes())
 }
 
 // adapted from: https://github.com/kr/text/blob/main/indent.go
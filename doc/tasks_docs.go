@@ -0,0 +1,161 @@
+package doc
+
+import (
+	"encoding/json"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// Tasks is the content of a tasks file in the format of the tasks.json files of
+// VSCode, which terminal applications import to offer the commands in their palettes.
+type Tasks struct {
+	Version string      `json:"version"`
+	Tasks   []Task      `json:"tasks"`
+	Inputs  []TaskInput `json:"inputs,omitempty"`
+}
+
+// Task is a common invocation of the CLI.
+type Task struct {
+	Label   string `json:"label"`
+	Type    string `json:"type"`
+	Command string `json:"command"`
+	Detail  string `json:"detail,omitempty"`
+	// ProblemMatcher is always empty, it keeps VSCode from asking to scan the output.
+	ProblemMatcher []string `json:"problemMatcher"`
+}
+
+// TaskInput is a value prompted when running a task, referenced as ${input:<id>} in
+// its command.
+type TaskInput struct {
+	ID          string `json:"id"`
+	Type        string `json:"type"`
+	Description string `json:"description,omitempty"`
+	Default     string `json:"default,omitempty"`
+}
+
+// GenTasks generates the tasks of cmd and its available descendants, see GenTasksJSON.
+// The examples of a command invoking the root command give its tasks, described by the
+// comment lines preceding them. The runnable commands without such examples get a task
+// invoking them with their required flags, whose values are prompted.
+func GenTasks(cmd *cobra.Command) *Tasks {
+	tasks := &Tasks{Version: "2.0.0", Tasks: []Task{}}
+	inputs := make(map[string]TaskInput)
+	genTasks(cmd, tasks, inputs)
+
+	ids := make([]string, 0, len(inputs))
+	for id := range inputs {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		tasks.Inputs = append(tasks.Inputs, inputs[id])
+	}
+	return tasks
+}
+
+// GenTasksJSON writes the tasks of cmd and its available descendants to w, in the
+// format of the tasks.json files of VSCode.
+func GenTasksJSON(cmd *cobra.Command, w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	return enc.Encode(GenTasks(cmd))
+}
+
+func genTasks(cmd *cobra.Command, tasks *Tasks, inputs map[string]TaskInput) {
+	examples := exampleInvocations(cmd)
+	for _, e := range examples {
+		detail := e.comment
+		if detail == "" {
+			detail = cmd.Short
+		}
+		tasks.Tasks = append(tasks.Tasks, Task{
+			Label:          e.line,
+			Type:           "shell",
+			Command:        e.line,
+			Detail:         detail,
+			ProblemMatcher: []string{},
+		})
+	}
+	if len(examples) == 0 && cmd.Runnable() {
+		command := cmd.CommandPath()
+		cmd.Flags().VisitAll(func(f *pflag.Flag) {
+			if len(f.Annotations[cobra.BashCompOneRequiredFlag]) == 0 || f.Hidden {
+				return
+			}
+			id := taskInputID(cmd, f)
+			command += " --" + f.Name + " ${input:" + id + "}"
+			inputs[id] = TaskInput{
+				ID:          id,
+				Type:        "promptString",
+				Description: f.Usage,
+				Default:     f.DefValue,
+			}
+		})
+		tasks.Tasks = append(tasks.Tasks, Task{
+			Label:          cmd.CommandPath(),
+			Type:           "shell",
+			Command:        command,
+			Detail:         cmd.Short,
+			ProblemMatcher: []string{},
+		})
+	}
+
+	for _, c := range cmd.Commands() {
+		if !c.IsAvailableCommand() || c.IsAdditionalHelpTopicCommand() {
+			continue
+		}
+		genTasks(c, tasks, inputs)
+	}
+}
+
+// taskInputID returns the id of the input prompting the value of the flag f of cmd,
+// its command path without the root command joined with the flag name by dashes,
+// e.g. "deploy-env", so that the flags of different commands get their own inputs.
+func taskInputID(cmd *cobra.Command, f *pflag.Flag) string {
+	path := strings.Fields(cmd.CommandPath())[1:]
+	return strings.Join(append(path, f.Name), "-")
+}
+
+type exampleInvocation struct {
+	line    string
+	comment string
+}
+
+// exampleInvocations returns the lines of the examples of cmd invoking the root command,
+// optionally prefixed with "$ ", joining the lines continued with a backslash, with the
+// comment lines preceding them.
+func exampleInvocations(cmd *cobra.Command) []exampleInvocation {
+	root := cmd.Root().Name()
+	var invocations []exampleInvocation
+	var comments []string
+	var continued string
+	for _, line := range strings.Split(cmd.Example, "\n") {
+		line = strings.TrimSpace(line)
+		if continued != "" {
+			line = continued + " " + line
+			continued = ""
+		}
+		if strings.HasSuffix(line, `\`) {
+			continued = strings.TrimSpace(strings.TrimSuffix(line, `\`))
+			continue
+		}
+		switch {
+		case line == "":
+			comments = nil
+		case strings.HasPrefix(line, "#"):
+			comments = append(comments, strings.TrimSpace(strings.TrimLeft(line, "#")))
+		default:
+			line = strings.TrimPrefix(line, "$ ")
+			if fields := strings.Fields(line); fields[0] == root {
+				invocations = append(invocations, exampleInvocation{line, strings.Join(comments, " ")})
+			}
+			comments = nil
+		}
+	}
+	return invocations
+}
@@ -0,0 +1,60 @@
+package doc
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func TestGenTasks(t *testing.T) {
+	root := &cobra.Command{Use: "app"}
+	get := &cobra.Command{
+		Use:   "get",
+		Short: "Get a resource",
+		Example: `  # Get the pods
+  $ app get pods
+
+  app get nodes \
+    --all
+  other get pods`,
+		Run: emptyRun,
+	}
+	deploy := &cobra.Command{Use: "deploy", Short: "Deploy a release", Run: emptyRun}
+	deploy.Flags().String("env", "staging", "Target environment")
+	deploy.Flags().Bool("dry-run", false, "Only print")
+	deploy.MarkFlagRequired("env")
+	hidden := &cobra.Command{Use: "secret", Hidden: true, Run: emptyRun}
+	root.AddCommand(get, deploy, hidden)
+
+	got := GenTasks(root)
+	expected := &Tasks{
+		Version: "2.0.0",
+		Tasks: []Task{
+			{Label: "app deploy", Type: "shell", Command: "app deploy --env ${input:deploy-env}", Detail: "Deploy a release", ProblemMatcher: []string{}},
+			{Label: "app get pods", Type: "shell", Command: "app get pods", Detail: "Get the pods", ProblemMatcher: []string{}},
+			{Label: "app get nodes --all", Type: "shell", Command: "app get nodes --all", Detail: "Get a resource", ProblemMatcher: []string{}},
+		},
+		Inputs: []TaskInput{
+			{ID: "deploy-env", Type: "promptString", Description: "Target environment", Default: "staging"},
+		},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected %+v, got %+v", expected, got)
+	}
+
+	buf := new(bytes.Buffer)
+	if err := GenTasksJSON(root, buf); err != nil {
+		t.Fatal(err)
+	}
+	var decoded Tasks
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(&decoded, expected) {
+		t.Errorf("expected %+v, got %+v", expected, &decoded)
+	}
+	checkStringContains(t, buf.String(), `"command": "app deploy --env ${input:deploy-env}"`)
+}
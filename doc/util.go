@@ -14,7 +14,10 @@
 package doc
 
 import (
+	"bytes"
+	"io/ioutil"
 	"strings"
+	"sync"
 
 	"github.com/spf13/cobra"
 )
@@ -35,6 +38,81 @@ func hasSeeAlso(cmd *cobra.Command) bool {
 	return false
 }
 
+// genTree calls gen for cmd and its documented descendants, the subcommands before
+// their parent. If workers is greater than one, gen is called from that many
+// goroutines, once the tree has been prepared sequentially: generating the
+// documentation of a command initializes its help command and flags, which its
+// subcommands read. It returns the error of the first failed command in that order.
+func genTree(cmd *cobra.Command, workers int, gen func(*cobra.Command) error) error {
+	if workers <= 1 {
+		for _, c := range cmd.Commands() {
+			if !c.IsAvailableCommand() || c.IsAdditionalHelpTopicCommand() {
+				continue
+			}
+			if err := genTree(c, workers, gen); err != nil {
+				return err
+			}
+		}
+		return gen(cmd)
+	}
+
+	cmds := prepareTree(cmd, nil)
+	errs := make([]error, len(cmds))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				errs[i] = gen(cmds[i])
+			}
+		}()
+	}
+	for i := range cmds {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// prepareTree initializes the help command and the flags of cmd and of its documented
+// descendants, propagates DisableAutoGenTag to them like the generators do, and
+// appends them to cmds, the subcommands before their parent.
+func prepareTree(cmd *cobra.Command, cmds []*cobra.Command) []*cobra.Command {
+	cmd.InitDefaultHelpCmd()
+	cmd.InitDefaultHelpFlag()
+	cmd.LocalFlags()
+	cmd.InheritedFlags()
+	if cmd.HasParent() && cmd.Parent().DisableAutoGenTag {
+		cmd.DisableAutoGenTag = true
+	}
+	for _, c := range cmd.Commands() {
+		if !c.IsAvailableCommand() || c.IsAdditionalHelpTopicCommand() {
+			continue
+		}
+		cmds = prepareTree(c, cmds)
+	}
+	return append(cmds, cmd)
+}
+
+// writeFileIfChanged writes content to filename, unless the file already holds that
+// content, so that regenerating the documentation keeps the modification time of the
+// unchanged pages.
+func writeFileIfChanged(filename string, content []byte) error {
+	if existing, err := ioutil.ReadFile(filename); err == nil && bytes.Equal(existing, content) {
+		return nil
+	}
+	return ioutil.WriteFile(filename, content, 0666)
+}
+
 // Temporary workaround for yaml lib generating incorrect yaml with long strings
 // that do not contain \n.
 func forceMultiLine(s string) string {
@@ -14,6 +14,7 @@
 package doc
 
 import (
+	"bytes"
 	"fmt"
 	"io"
 	"os"
@@ -67,19 +68,11 @@ func GenYamlTreeCustom(cmd *cobra.Command, dir string, filePrepender, linkHandle
 
 	basename := strings.Replace(cmd.CommandPath(), " ", "_", -1) + ".yaml"
 	filename := filepath.Join(dir, basename)
-	f, err := os.Create(filename)
-	if err != nil {
-		return err
-	}
-	defer f.Close()
-
-	if _, err := io.WriteString(f, filePrepender(filename)); err != nil {
+	buf := bytes.NewBufferString(filePrepender(filename))
+	if err := GenYamlCustom(cmd, buf, linkHandler); err != nil {
 		return err
 	}
-	if err := GenYamlCustom(cmd, f, linkHandler); err != nil {
-		return err
-	}
-	return nil
+	return writeFileIfChanged(filename, buf.Bytes())
 }
 
 // GenYaml creates yaml output.
@@ -102,11 +95,11 @@ func GenYamlCustom(cmd *cobra.Command, w io.Writer, linkHandler func(string) str
 		yamlDoc.Example = cmd.Example
 	}
 
-	flags := cmd.NonInheritedFlags()
+	flags := cmd.DisplayFlags(cmd.NonInheritedFlags())
 	if flags.HasFlags() {
 		yamlDoc.Options = genFlagResult(flags)
 	}
-	flags = cmd.InheritedFlags()
+	flags = cmd.DisplayFlags(cmd.InheritedFlags())
 	if flags.HasFlags() {
 		yamlDoc.InheritedOptions = genFlagResult(flags)
 	}
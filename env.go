@@ -0,0 +1,67 @@
+package cobra
+
+import (
+	"context"
+	"os"
+	"sort"
+)
+
+// ExecuteWithEnv executes the command like Execute with the given arguments, reading
+// the environment variables looked up by cobra, and by LookupEnv and Getenv, from env
+// rather than from the process environment during the execution. The commands can get
+// ctx with Context. It lets tests of a command tree run in parallel with their own
+// environments.
+func (c *Command) ExecuteWithEnv(ctx context.Context, args []string, env map[string]string) error {
+	root := c.Root()
+	if env == nil {
+		env = map[string]string{}
+	}
+	prevCtx, prevEnv := root.ctx, root.env
+	root.ctx, root.env = ctx, env
+	defer func() { root.ctx, root.env = prevCtx, prevEnv }()
+
+	root.SetArgs(args)
+	return root.Execute()
+}
+
+// Context returns the context given to ExecuteWithEnv, or context.Background() out of
+// such an execution.
+func (c *Command) Context() context.Context {
+	if ctx := c.Root().ctx; ctx != nil {
+		return ctx
+	}
+	return context.Background()
+}
+
+// LookupEnv returns the value of the environment variable key and whether it is set,
+// from the environment given to ExecuteWithEnv or from the process environment.
+func (c *Command) LookupEnv(key string) (string, bool) {
+	if env := c.Root().env; env != nil {
+		value, ok := env[key]
+		return value, ok
+	}
+	return os.LookupEnv(key)
+}
+
+// Getenv returns the value of the environment variable key, like LookupEnv, or an
+// empty string if it is not set.
+func (c *Command) Getenv(key string) string {
+	value, _ := c.LookupEnv(key)
+	return value
+}
+
+// Environ returns the environment as "key=value" strings, like os.Environ: the
+// environment given to ExecuteWithEnv, sorted by key, or the process environment.
+// It is the environment to give to the programs run by the commands.
+func (c *Command) Environ() []string {
+	env := c.Root().env
+	if env == nil {
+		return os.Environ()
+	}
+	environ := make([]string, 0, len(env))
+	for key, value := range env {
+		environ = append(environ, key+"="+value)
+	}
+	sort.Strings(environ)
+	return environ
+}
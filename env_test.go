@@ -0,0 +1,63 @@
+package cobra
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"reflect"
+	"testing"
+)
+
+type envTestKey struct{}
+
+func TestExecuteWithEnv(t *testing.T) {
+	for i := 0; i < 4; i++ {
+		i := i
+		t.Run(fmt.Sprint(i), func(t *testing.T) {
+			t.Parallel()
+			var region, ctxValue string
+			var home bool
+			var environ []string
+			rootCmd := &Command{Use: "root", Run: emptyRun}
+			childCmd := &Command{Use: "child", Run: func(cmd *Command, args []string) {
+				region = cmd.Getenv("REGION")
+				_, home = cmd.LookupEnv("HOME")
+				environ = cmd.Environ()
+				ctxValue, _ = cmd.Context().Value(envTestKey{}).(string)
+			}}
+			rootCmd.AddCommand(childCmd)
+
+			want := fmt.Sprintf("region-%d", i)
+			ctx := context.WithValue(context.Background(), envTestKey{}, want)
+			err := childCmd.ExecuteWithEnv(ctx, []string{"child"}, map[string]string{"REGION": want})
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if region != want {
+				t.Errorf("Expected REGION %q, got %q", want, region)
+			}
+			if home {
+				t.Error("Expected HOME not to be read from the process environment")
+			}
+			if expected := []string{"REGION=" + want}; !reflect.DeepEqual(environ, expected) {
+				t.Errorf("Expected the environment %q, got %q", expected, environ)
+			}
+			if ctxValue != want {
+				t.Errorf("Expected the context value %q, got %q", want, ctxValue)
+			}
+			if rootCmd.Context() != context.Background() {
+				t.Error("Expected the context to be reset after the execution")
+			}
+		})
+	}
+}
+
+func TestLookupEnvDefaultsToProcessEnvironment(t *testing.T) {
+	os.Setenv("COBRA_TEST_ENV", "value")
+	defer os.Unsetenv("COBRA_TEST_ENV")
+
+	c := &Command{Use: "c"}
+	if value, ok := c.LookupEnv("COBRA_TEST_ENV"); !ok || value != "value" {
+		t.Errorf("Expected %q from the process environment, got %q, %v", "value", value, ok)
+	}
+}
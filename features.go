@@ -0,0 +1,94 @@
+package cobra
+
+import (
+	"fmt"
+
+	"github.com/spf13/pflag"
+)
+
+// FlagFeature is the annotation of the flags gated by a feature flag, see MarkFlagFeature.
+const FlagFeature = "cobra_annotation_flag_feature"
+
+// FeatureProvider tells whether the features, e.g. of a feature flag service, are
+// enabled. See SetFeatureProvider.
+type FeatureProvider interface {
+	FeatureEnabled(key string) bool
+}
+
+// SetFeatureProvider sets the provider of the feature flags gating this command, its
+// subcommands and their flags, see the Feature field and MarkFlagFeature.
+func (c *Command) SetFeatureProvider(p FeatureProvider) {
+	c.featureProvider = p
+}
+
+// FeatureEnabled returns true if the feature key is enabled by the provider set with
+// SetFeatureProvider on this command or a parent. An empty key, or the lack of a
+// provider, enables it.
+func (c *Command) FeatureEnabled(key string) bool {
+	if key == "" {
+		return true
+	}
+	if provider := c.findFeatureProvider(); provider != nil {
+		return provider.FeatureEnabled(key)
+	}
+	return true
+}
+
+func (c *Command) findFeatureProvider() FeatureProvider {
+	for p := c; p != nil; p = p.parent {
+		if p.featureProvider != nil {
+			return p.featureProvider
+		}
+	}
+	return nil
+}
+
+// MarkFlagFeature adds the FlagFeature annotation to the named flag if it exists: when
+// the feature key is disabled, the flag is hidden and your command reports an error if
+// invoked with it.
+func (c *Command) MarkFlagFeature(name, key string) error {
+	return MarkFlagFeature(c.Flags(), name, key)
+}
+
+// MarkPersistentFlagFeature adds the FlagFeature annotation to the named persistent
+// flag if it exists, see MarkFlagFeature.
+func (c *Command) MarkPersistentFlagFeature(name, key string) error {
+	return MarkFlagFeature(c.PersistentFlags(), name, key)
+}
+
+// MarkFlagFeature adds the FlagFeature annotation to the named flag if it exists, see
+// the MarkFlagFeature method of Command.
+func MarkFlagFeature(flags *pflag.FlagSet, name, key string) error {
+	return flags.SetAnnotation(name, FlagFeature, []string{key})
+}
+
+// checkFeature returns an error if the feature of cmd or of one of its parents is
+// disabled.
+func checkFeature(cmd *Command) error {
+	for p := cmd; p != nil; p = p.parent {
+		if !p.FeatureEnabled(p.Feature) {
+			return fmt.Errorf("command %q requires the feature %q, which is disabled", p.CommandPath(), p.Feature)
+		}
+	}
+	return nil
+}
+
+// flagFeatureDisabled returns true if the feature of the flag f of c is disabled.
+func (c *Command) flagFeatureDisabled(f *pflag.Flag) bool {
+	key := f.Annotations[FlagFeature]
+	return len(key) > 0 && !c.FeatureEnabled(key[0])
+}
+
+// validateFlagFeatures returns an error if a flag whose feature is disabled is set.
+func (c *Command) validateFlagFeatures() error {
+	if c.findFeatureProvider() == nil {
+		return nil
+	}
+	var err error
+	c.Flags().Visit(func(f *pflag.Flag) {
+		if err == nil && c.flagFeatureDisabled(f) {
+			err = fmt.Errorf("flag %q requires the feature %q, which is disabled", f.Name, f.Annotations[FlagFeature][0])
+		}
+	})
+	return err
+}
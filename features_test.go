@@ -0,0 +1,106 @@
+package cobra
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+type testFeatures map[string]bool
+
+func (f testFeatures) FeatureEnabled(key string) bool {
+	return f[key]
+}
+
+func newFeatureTree(features testFeatures) *Command {
+	rootCmd := &Command{Use: "root", Run: emptyRun}
+	rootCmd.SetFeatureProvider(features)
+	betaCmd := &Command{Use: "beta", Short: "try the beta", Feature: "beta", Run: emptyRun}
+	betaCmd.AddCommand(&Command{Use: "sub", Run: emptyRun})
+	deployCmd := &Command{Use: "deploy", Run: emptyRun}
+	deployCmd.Flags().Bool("canary", false, "deploy a canary first")
+	deployCmd.MarkFlagFeature("canary", "canary")
+	rootCmd.AddCommand(betaCmd, deployCmd)
+	return rootCmd
+}
+
+func TestFeatureDisabled(t *testing.T) {
+	rootCmd := newFeatureTree(testFeatures{})
+
+	output, err := executeCommand(rootCmd, "--help")
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	checkStringOmits(t, output, "beta")
+
+	_, err = executeCommand(rootCmd, "beta", "sub")
+	if err == nil || err.Error() != `command "root beta" requires the feature "beta", which is disabled` {
+		t.Errorf("Expected a disabled feature error, got %v", err)
+	}
+
+	output, err = executeCommand(rootCmd, "deploy", "--help")
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	checkStringOmits(t, output, "canary")
+
+	_, err = executeCommand(newFeatureTree(testFeatures{}), "deploy", "--canary")
+	if err == nil || err.Error() != `flag "canary" requires the feature "canary", which is disabled` {
+		t.Errorf("Expected a disabled feature error, got %v", err)
+	}
+
+	for name, gen := range map[string]func(*bytes.Buffer) error{
+		"bash":       func(buf *bytes.Buffer) error { return rootCmd.GenBashCompletion(buf) },
+		"zsh":        func(buf *bytes.Buffer) error { return rootCmd.GenZshCompletion(buf) },
+		"powershell": func(buf *bytes.Buffer) error { return rootCmd.GenPowerShellCompletion(buf) },
+	} {
+		buf := new(bytes.Buffer)
+		if err := gen(buf); err != nil {
+			t.Fatal(err)
+		}
+		if strings.Contains(buf.String(), "beta") || strings.Contains(buf.String(), "canary") {
+			t.Errorf("Expected the %s completion to omit the disabled features", name)
+		}
+	}
+}
+
+func TestFeatureEnabledLater(t *testing.T) {
+	features := testFeatures{}
+	rootCmd := newFeatureTree(features)
+
+	output, err := executeCommand(rootCmd, "deploy", "--help")
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	checkStringOmits(t, output, "canary")
+
+	features["canary"] = true
+	output, err = executeCommand(rootCmd, "deploy", "--help")
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	checkStringContains(t, output, "canary")
+
+	buf := new(bytes.Buffer)
+	if err := rootCmd.GenZshCompletion(buf); err != nil {
+		t.Fatal(err)
+	}
+	checkStringContains(t, buf.String(), "canary")
+}
+
+func TestFeatureEnabled(t *testing.T) {
+	rootCmd := newFeatureTree(testFeatures{"beta": true, "canary": true})
+
+	output, err := executeCommand(rootCmd, "--help")
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	checkStringContains(t, output, "beta")
+
+	if _, err := executeCommand(rootCmd, "beta", "sub"); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if _, err := executeCommand(newFeatureTree(testFeatures{"canary": true}), "deploy", "--canary"); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,121 @@
+package cobra
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/spf13/pflag"
+)
+
+// FileValue wraps a flag value so that it can be read from a file, given as "@path",
+// or from the standard input, given as "-". A value starting with "@@" is taken
+// literally, without its first "@". This keeps secrets off the command line.
+type FileValue struct {
+	pflag.Value
+
+	// Stdin is read when the value is "-". It defaults to the input of the command
+	// when set by MarkFlagValueFromFile, and to os.Stdin otherwise.
+	Stdin io.Reader
+
+	cmd *Command
+}
+
+// Set reads the value from a file or the standard input if requested, and sets it.
+func (v *FileValue) Set(s string) error {
+	switch {
+	case s == "-":
+		return v.setFrom(v.stdin())
+	case strings.HasPrefix(s, "@@"):
+		return v.Value.Set(s[1:])
+	case strings.HasPrefix(s, "@"):
+		return v.setFromFile(s[1:])
+	}
+	return v.Value.Set(s)
+}
+
+func (v *FileValue) stdin() io.Reader {
+	if v.Stdin != nil {
+		return v.Stdin
+	}
+	if v.cmd != nil {
+		return v.cmd.InOrStdin()
+	}
+	return os.Stdin
+}
+
+func (v *FileValue) setFromFile(path string) error {
+	if path == "-" {
+		return v.setFrom(v.stdin())
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return v.setFrom(f)
+}
+
+// setFrom sets the value to the content of r, without its trailing newline.
+func (v *FileValue) setFrom(r io.Reader) error {
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	return v.Value.Set(strings.TrimRight(string(b), "\r\n"))
+}
+
+// filePathValue is the value of the "<name>-file" flag added by MarkFlagValueFromFile.
+// Setting it sets the target flag, whose value is a *FileValue, as if it were given on
+// the command line.
+type filePathValue struct {
+	target *pflag.Flag
+	path   string
+}
+
+func (v *filePathValue) Set(path string) error {
+	if err := v.target.Value.(*FileValue).setFromFile(path); err != nil {
+		return err
+	}
+	v.target.Changed = true
+	v.path = path
+	return nil
+}
+
+func (v *filePathValue) String() string { return v.path }
+
+func (v *filePathValue) Type() string { return "string" }
+
+// MarkFlagValueFromFile wraps the value of the named flag in a FileValue, so that it
+// can be read from a file with "@path" or from the standard input with "-". It also
+// adds a "<name>-file" flag taking the path of the file, which shells complete with
+// file names.
+func (c *Command) MarkFlagValueFromFile(name string) error {
+	return markFlagValueFromFile(c, c.Flags(), name)
+}
+
+// MarkPersistentFlagValueFromFile does what MarkFlagValueFromFile does for the named
+// persistent flag. The "<name>-file" flag is persistent too.
+func (c *Command) MarkPersistentFlagValueFromFile(name string) error {
+	return markFlagValueFromFile(c, c.PersistentFlags(), name)
+}
+
+func markFlagValueFromFile(c *Command, flags *pflag.FlagSet, name string) error {
+	f := flags.Lookup(name)
+	if f == nil {
+		return fmt.Errorf("no such flag -%v", name)
+	}
+	v, ok := f.Value.(*FileValue)
+	if !ok {
+		v = &FileValue{Value: f.Value, cmd: c}
+		f.Value = v
+	}
+	fileName := name + "-file"
+	if flags.Lookup(fileName) != nil {
+		return fmt.Errorf("flag %q already exists", fileName)
+	}
+	flags.Var(&filePathValue{target: f}, fileName, fmt.Sprintf("read the value of --%s from a file (- for stdin)", name))
+	return MarkFlagFilename(flags, fileName)
+}
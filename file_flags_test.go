@@ -0,0 +1,106 @@
+package cobra
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMarkFlagValueFromFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cobra-file-flags")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "password")
+	if err := ioutil.WriteFile(path, []byte("from-file\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	var password string
+	newCmd := func() *Command {
+		c := &Command{Use: "c", Run: emptyRun}
+		c.Flags().StringVar(&password, "password", "", "password")
+		if err := c.MarkFlagValueFromFile("password"); err != nil {
+			t.Fatal(err)
+		}
+		c.SetIn(strings.NewReader("from-stdin\n"))
+		return c
+	}
+
+	tests := []struct {
+		args     []string
+		expected string
+	}{
+		{[]string{"--password", "literal"}, "literal"},
+		{[]string{"--password", "@" + path}, "from-file"},
+		{[]string{"--password=-"}, "from-stdin"},
+		{[]string{"--password", "@@literal"}, "@literal"},
+		{[]string{"--password-file", path}, "from-file"},
+		{[]string{"--password-file", "-"}, "from-stdin"},
+	}
+	for _, tc := range tests {
+		password = ""
+		if _, err := executeCommand(newCmd(), tc.args...); err != nil {
+			t.Errorf("%v: unexpected error: %v", tc.args, err)
+			continue
+		}
+		if password != tc.expected {
+			t.Errorf("%v: expected %q, got %q", tc.args, tc.expected, password)
+		}
+	}
+
+	if _, err := executeCommand(newCmd(), "--password", "@"+filepath.Join(dir, "missing")); err == nil {
+		t.Error("Expected error for a missing file")
+	}
+}
+
+func TestMarkFlagValueFromFileRequired(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cobra-file-flags")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "token")
+	if err := ioutil.WriteFile(path, []byte("secret\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	var source string
+	c := &Command{Use: "c", Run: func(cmd *Command, args []string) {
+		source, _ = cmd.FlagSource("token")
+	}}
+	c.Flags().String("token", "", "token")
+	c.MarkFlagRequired("token")
+	if err := c.MarkFlagValueFromFile("token"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := executeCommand(c, "--token-file", path); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !c.Flags().Changed("token") {
+		t.Error("Expected --token-file to set --token")
+	}
+	if source != FlagSourceCommandLine {
+		t.Errorf("Expected the source of --token to be %q, got %q", FlagSourceCommandLine, source)
+	}
+}
+
+func TestMarkFlagValueFromFileCompletion(t *testing.T) {
+	c := &Command{Use: "c", Run: emptyRun}
+	c.Flags().String("password", "", "password")
+	if err := c.MarkFlagValueFromFile("password"); err != nil {
+		t.Fatal(err)
+	}
+
+	buf := new(bytes.Buffer)
+	if err := c.GenBashCompletion(buf); err != nil {
+		t.Fatal(err)
+	}
+	checkStringContains(t, buf.String(), `flags_with_completion+=("--password-file")`)
+	checkStringContains(t, buf.String(), `flags_completion+=("_filedir")`)
+}
@@ -0,0 +1,101 @@
+package cobra
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/pflag"
+)
+
+// Annotations for conditional flags.
+const (
+	FlagRequiredIf = "cobra_annotation_flag_required_if"
+	FlagAllowedIf  = "cobra_annotation_flag_allowed_if"
+)
+
+// MarkFlagRequiredIf adds the FlagRequiredIf annotation to the named flag if it exists,
+// and causes your command to report an error if invoked without the flag while the
+// condition holds. The condition is either the name of another flag, which holds when
+// that flag is set, or "name=value", which holds when that flag has the given value.
+// E.g. MarkFlagRequiredIf("key-file", "--auth=mtls").
+func (c *Command) MarkFlagRequiredIf(name string, condition string) error {
+	return MarkFlagRequiredIf(c.Flags(), name, condition)
+}
+
+// MarkFlagAllowedIf adds the FlagAllowedIf annotation to the named flag if it exists,
+// and causes your command to report an error if invoked with the flag while the
+// condition does not hold. See MarkFlagRequiredIf for the syntax of the condition.
+func (c *Command) MarkFlagAllowedIf(name string, condition string) error {
+	return MarkFlagAllowedIf(c.Flags(), name, condition)
+}
+
+// MarkFlagRequiredIf adds the FlagRequiredIf annotation to the named flag if it exists,
+// and causes your command to report an error if invoked without the flag while the
+// condition holds.
+func MarkFlagRequiredIf(flags *pflag.FlagSet, name string, condition string) error {
+	return addFlagCondition(flags, name, FlagRequiredIf, condition)
+}
+
+// MarkFlagAllowedIf adds the FlagAllowedIf annotation to the named flag if it exists,
+// and causes your command to report an error if invoked with the flag while the
+// condition does not hold.
+func MarkFlagAllowedIf(flags *pflag.FlagSet, name string, condition string) error {
+	return addFlagCondition(flags, name, FlagAllowedIf, condition)
+}
+
+func addFlagCondition(flags *pflag.FlagSet, name, key, condition string) error {
+	f := flags.Lookup(name)
+	if f == nil {
+		return fmt.Errorf("no such flag -%v", name)
+	}
+	condition = strings.TrimLeft(condition, "-")
+	if condition == "" || strings.HasPrefix(condition, "=") {
+		return fmt.Errorf("invalid condition %q for flag %q", condition, name)
+	}
+	return flags.SetAnnotation(name, key, append(f.Annotations[key], condition))
+}
+
+// flagConditionHolds reports whether the condition, as given to MarkFlagRequiredIf,
+// holds for the flags.
+func flagConditionHolds(flags *pflag.FlagSet, condition string) bool {
+	name, value := condition, ""
+	i := strings.Index(condition, "=")
+	if i >= 0 {
+		name, value = condition[:i], condition[i+1:]
+	}
+	f := flags.Lookup(name)
+	if f == nil {
+		return false
+	}
+	if i < 0 {
+		return f.Changed
+	}
+	return f.Value.String() == value
+}
+
+func (c *Command) validateConditionalFlags() error {
+	flags := c.Flags()
+	var err error
+	flags.VisitAll(func(f *pflag.Flag) {
+		if err != nil {
+			return
+		}
+		for _, condition := range f.Annotations[FlagRequiredIf] {
+			if !f.Changed && flagConditionHolds(flags, condition) {
+				err = fmt.Errorf("flag %q is required when --%s", f.Name, condition)
+				return
+			}
+		}
+		allowed := f.Annotations[FlagAllowedIf]
+		if !f.Changed || len(allowed) == 0 {
+			return
+		}
+		for _, condition := range allowed {
+			if flagConditionHolds(flags, condition) {
+				return
+			}
+		}
+		err = fmt.Errorf("flag %q is only allowed when --%s", f.Name, strings.Join(allowed, " or --"))
+	})
+	return err
+}
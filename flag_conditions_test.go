@@ -0,0 +1,60 @@
+package cobra
+
+import (
+	"testing"
+)
+
+func getConditionalFlagsTestCommand(t *testing.T) *Command {
+	c := &Command{Use: "root", Run: emptyRun}
+	c.Flags().String("auth", "none", "")
+	c.Flags().String("key-file", "", "")
+	c.Flags().Bool("debug", false, "")
+	c.Flags().String("log-file", "", "")
+	c.Flags().Bool("insecure", false, "")
+	if err := c.MarkFlagRequiredIf("key-file", "--auth=mtls"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := c.MarkFlagRequiredIf("log-file", "debug"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := c.MarkFlagAllowedIf("insecure", "auth=none"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	return c
+}
+
+func TestConditionalFlags(t *testing.T) {
+	testcases := []struct {
+		args []string
+		err  string
+	}{
+		{[]string{}, ""},
+		{[]string{"--auth=token"}, ""},
+		{[]string{"--auth=mtls"}, `flag "key-file" is required when --auth=mtls`},
+		{[]string{"--auth=mtls", "--key-file=key.pem"}, ""},
+		{[]string{"--debug"}, `flag "log-file" is required when --debug`},
+		{[]string{"--debug", "--log-file=out.log"}, ""},
+		{[]string{"--insecure"}, ""},
+		{[]string{"--insecure", "--auth=token"}, `flag "insecure" is only allowed when --auth=none`},
+	}
+	for _, tc := range testcases {
+		_, err := executeCommand(getConditionalFlagsTestCommand(t), tc.args...)
+		if tc.err == "" && err != nil {
+			t.Errorf("%v: unexpected error: %v", tc.args, err)
+		}
+		if tc.err != "" && (err == nil || err.Error() != tc.err) {
+			t.Errorf("%v: expected error %q, got %v", tc.args, tc.err, err)
+		}
+	}
+}
+
+func TestConditionalFlagsInvalid(t *testing.T) {
+	c := &Command{Use: "root", Run: emptyRun}
+	c.Flags().String("key-file", "", "")
+	if err := c.MarkFlagRequiredIf("missing", "auth"); err == nil {
+		t.Error("Expected error for unknown flag")
+	}
+	if err := c.MarkFlagRequiredIf("key-file", "--=mtls"); err == nil {
+		t.Error("Expected error for invalid condition")
+	}
+}
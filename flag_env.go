@@ -0,0 +1,142 @@
+package cobra
+
+import (
+	"fmt"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/spf13/pflag"
+)
+
+// FlagEnv is the annotation of the flags bound to an environment variable with
+// MarkFlagEnv, whose value is the name of the variable.
+const FlagEnv = "cobra_annotation_flag_env"
+
+// FlagSourceEnv is the source of the flags set from their environment variable, see
+// MarkFlagEnv.
+const FlagSourceEnv = "env"
+
+// MarkFlagEnv binds the named flag to the environment variable key, whose value sets
+// the flag when it is not set on the command line nor by a preset, and no flag mutually
+// exclusive with it is set. The value counts as set for MarkFlagRequired.
+func (c *Command) MarkFlagEnv(name, key string) error {
+	return MarkFlagEnv(c.Flags(), name, key)
+}
+
+// MarkPersistentFlagEnv binds the named persistent flag to the environment variable
+// key, whose value sets the flag when it is not set on the command line nor by a
+// preset.
+func (c *Command) MarkPersistentFlagEnv(name, key string) error {
+	return MarkFlagEnv(c.PersistentFlags(), name, key)
+}
+
+// MarkFlagEnv binds the named flag to the environment variable key, whose value sets
+// the flag when it is not set on the command line nor by a preset.
+func MarkFlagEnv(flags *pflag.FlagSet, name, key string) error {
+	return flags.SetAnnotation(name, FlagEnv, []string{key})
+}
+
+// applyFlagEnv sets the flags of c which are not set yet from their environment
+// variables, as looked up by LookupEnv. The flags mutually exclusive with a flag already
+// set are left alone.
+func (c *Command) applyFlagEnv() error {
+	var err error
+	c.Flags().VisitAll(func(f *pflag.Flag) {
+		key := flagEnvKey(f)
+		if err != nil || key == "" || f.Changed || c.excludedBySetFlag(f.Name) {
+			return
+		}
+		value, ok := c.LookupEnv(key)
+		if !ok {
+			return
+		}
+		if setErr := c.SetFlagFromSource(f.Name, value, FlagSourceEnv); setErr != nil {
+			err = fmt.Errorf("$%s: %v", key, setErr)
+		}
+	})
+	return err
+}
+
+func flagEnvKey(f *pflag.Flag) string {
+	if values := f.Annotations[FlagEnv]; len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// EnvVar is an environment variable bound to a flag with MarkFlagEnv.
+type EnvVar struct {
+	// Name is the name of the variable.
+	Name string
+	// Flag is the flag bound to the variable.
+	Flag *pflag.Flag
+	// Command is the command defining the flag.
+	Command *Command
+}
+
+// EnvVars returns the environment variables bound to the visible flags of c, including
+// the persistent flags inherited from its parents, sorted by name.
+func (c *Command) EnvVars() []EnvVar {
+	vars := appendEnvVars(nil, c, c.LocalFlags())
+	for p := c.parent; p != nil; p = p.parent {
+		vars = appendEnvVars(vars, p, p.PersistentFlags())
+	}
+	sortEnvVars(vars)
+	return vars
+}
+
+// appendEnvVars appends to vars the environment variables bound to the visible flags
+// of flags, defined by c.
+func appendEnvVars(vars []EnvVar, c *Command, flags *pflag.FlagSet) []EnvVar {
+	flags.VisitAll(func(f *pflag.Flag) {
+		if key := flagEnvKey(f); key != "" && !f.Hidden {
+			vars = append(vars, EnvVar{Name: key, Flag: f, Command: c})
+		}
+	})
+	return vars
+}
+
+func sortEnvVars(vars []EnvVar) {
+	sort.SliceStable(vars, func(i, j int) bool {
+		return vars[i].Name < vars[j].Name
+	})
+}
+
+// AddEnvCommand adds the "env" command to c, listing the environment variables bound
+// to the flags of the commands of the tree of c with MarkFlagEnv, with the flags they
+// set and their current values. The values of sensitive flags are redacted.
+func (c *Command) AddEnvCommand() {
+	c.AddCommand(&Command{
+		Use:   "env",
+		Short: "List the environment variables setting flags",
+		Args:  NoArgs,
+		RunE: func(cmd *Command, args []string) error {
+			var vars []EnvVar
+			var visit func(*Command)
+			visit = func(p *Command) {
+				vars = appendEnvVars(vars, p, p.LocalFlags())
+				for _, sub := range p.Commands() {
+					if sub.IsAvailableCommand() {
+						visit(sub)
+					}
+				}
+			}
+			visit(c)
+			sortEnvVars(vars)
+
+			w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 4, 2, ' ', 0)
+			fmt.Fprintf(w, "VARIABLE\tFLAG\tVALUE\n")
+			for _, v := range vars {
+				value, ok := cmd.LookupEnv(v.Name)
+				switch {
+				case !ok:
+					value = "(not set)"
+				case isFlagSensitive(v.Flag):
+					value = RedactedValue
+				}
+				fmt.Fprintf(w, "%s\t%s --%s\t%s\n", v.Name, v.Command.CommandPath(), v.Flag.Name, value)
+			}
+			return w.Flush()
+		},
+	})
+}
@@ -0,0 +1,104 @@
+package cobra
+
+import (
+	"bytes"
+	"context"
+	"testing"
+)
+
+func getFlagEnvTestCommand() (*Command, *Command) {
+	rootCmd := &Command{Use: "root"}
+	rootCmd.PersistentFlags().String("token", "", "API token")
+	rootCmd.MarkPersistentFlagSensitive("token")
+	rootCmd.MarkPersistentFlagEnv("token", "APP_TOKEN")
+	deployCmd := &Command{Use: "deploy", Run: emptyRun}
+	deployCmd.Flags().String("region", "us-east-1", "region")
+	deployCmd.Flags().Int("replicas", 1, "number of replicas")
+	deployCmd.MarkFlagEnv("region", "APP_REGION")
+	deployCmd.MarkFlagEnv("replicas", "APP_REPLICAS")
+	deployCmd.MarkFlagRequired("region")
+	rootCmd.AddCommand(deployCmd)
+	return rootCmd, deployCmd
+}
+
+func TestFlagEnv(t *testing.T) {
+	rootCmd, deployCmd := getFlagEnvTestCommand()
+	env := map[string]string{"APP_REGION": "ca-central-1", "APP_REPLICAS": "3"}
+	if err := rootCmd.ExecuteWithEnv(context.Background(), []string{"deploy", "--replicas", "5"}, env); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if region, _ := deployCmd.Flags().GetString("region"); region != "ca-central-1" {
+		t.Errorf("Expected region from the environment, got %q", region)
+	}
+	if source, _ := deployCmd.FlagSource("region"); source != FlagSourceEnv {
+		t.Errorf("Expected source %q, got %q", FlagSourceEnv, source)
+	}
+	if replicas, _ := deployCmd.Flags().GetInt("replicas"); replicas != 5 {
+		t.Errorf("Expected the command line to take precedence, got %d", replicas)
+	}
+
+	rootCmd, _ = getFlagEnvTestCommand()
+	rootCmd.SetOut(new(bytes.Buffer))
+	err := rootCmd.ExecuteWithEnv(context.Background(), []string{"deploy"}, map[string]string{"APP_REGION": "x", "APP_REPLICAS": "many"})
+	expected := `$APP_REPLICAS: invalid argument "many" for "--replicas" flag: strconv.ParseInt: parsing "many": invalid syntax`
+	if err == nil || err.Error() != expected {
+		t.Errorf("Expected error %q, got %v", expected, err)
+	}
+}
+
+func TestEnvVars(t *testing.T) {
+	_, deployCmd := getFlagEnvTestCommand()
+	var names []string
+	for _, v := range deployCmd.EnvVars() {
+		names = append(names, v.Name+" "+v.Command.Name()+" --"+v.Flag.Name)
+	}
+	expected := []string{"APP_REGION deploy --region", "APP_REPLICAS deploy --replicas", "APP_TOKEN root --token"}
+	if len(names) != len(expected) {
+		t.Fatalf("Expected %v, got %v", expected, names)
+	}
+	for i := range expected {
+		if names[i] != expected[i] {
+			t.Errorf("Expected %v, got %v", expected, names)
+		}
+	}
+}
+
+func TestEnvCommand(t *testing.T) {
+	rootCmd, _ := getFlagEnvTestCommand()
+	rootCmd.AddEnvCommand()
+	buf := new(bytes.Buffer)
+	rootCmd.SetOut(buf)
+	env := map[string]string{"APP_REGION": "ca-central-1", "APP_TOKEN": "secret"}
+	if err := rootCmd.ExecuteWithEnv(context.Background(), []string{"env"}, env); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	output := buf.String()
+	expected := `VARIABLE      FLAG                    VALUE
+APP_REGION    root deploy --region    ca-central-1
+APP_REPLICAS  root deploy --replicas  (not set)
+APP_TOKEN     root --token            <redacted>
+`
+	if output != expected {
+		t.Errorf("Expected:\n%s\nGot:\n%s", expected, output)
+	}
+}
+
+func TestFlagEnvMutuallyExclusive(t *testing.T) {
+	rootCmd := &Command{Use: "root", Run: emptyRun}
+	rootCmd.Flags().Bool("json", false, "")
+	rootCmd.Flags().Bool("yaml", false, "")
+	rootCmd.MarkFlagEnv("json", "APP_JSON")
+	if err := rootCmd.MarkFlagsMutuallyExclusive("json", "yaml"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	env := map[string]string{"APP_JSON": "true"}
+	if err := rootCmd.ExecuteWithEnv(context.Background(), []string{"--yaml"}, env); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if json, _ := rootCmd.Flags().GetBool("json"); json {
+		t.Error("Expected the environment to leave --json unset when --yaml is set")
+	}
+	if source, _ := rootCmd.FlagSource("json"); source != FlagSourceDefault {
+		t.Errorf("Expected source %q, got %q", FlagSourceDefault, source)
+	}
+}
@@ -0,0 +1,133 @@
+package cobra
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	flag "github.com/spf13/pflag"
+)
+
+// FlagErrorKind is the kind of a FlagParseError.
+type FlagErrorKind int
+
+const (
+	// FlagErrorBadSyntax is a malformed flag, e.g. "---name".
+	FlagErrorBadSyntax FlagErrorKind = iota
+	// FlagErrorUnknown is a flag which is not defined.
+	FlagErrorUnknown
+	// FlagErrorMissingValue is a flag given without its value.
+	FlagErrorMissingValue
+	// FlagErrorInvalidValue is a flag given a value it does not accept.
+	FlagErrorInvalidValue
+)
+
+// FlagParseError is the error given to the function set by SetFlagErrorFunc when the
+// flags can't be parsed, so that it can be rendered differently, e.g. localized or as JSON.
+type FlagParseError struct {
+	// Kind is the kind of the error.
+	Kind FlagErrorKind
+	// Name is the long name of the flag, if known.
+	Name string
+	// Shorthand is the shorthand of the flag, if known.
+	Shorthand string
+	// Value is the value given to the flag, for FlagErrorInvalidValue errors.
+	// The values of sensitive flags are replaced by RedactedValue.
+	Value string
+	// Err is the error of the flag parser, with the values of sensitive flags redacted.
+	Err error
+	// Suggestions holds the names of the defined flags close to an unknown flag.
+	Suggestions []string
+
+	msg string
+}
+
+// Error returns the message of the flag parser, extended with hints and, if
+// EnableFlagSuggestions is set, suggestions.
+func (e *FlagParseError) Error() string {
+	return e.msg
+}
+
+// Unwrap returns the error of the flag parser.
+func (e *FlagParseError) Unwrap() error {
+	return e.Err
+}
+
+var (
+	flagUnknownRe       = regexp.MustCompile(`^unknown flag: --(.+)$`)
+	flagUnknownShortRe  = regexp.MustCompile(`^unknown shorthand flag: '(.)' in -`)
+	flagMissingRe       = regexp.MustCompile(`^flag needs an argument: --(.+)$`)
+	flagMissingShortRe  = regexp.MustCompile(`^flag needs an argument: '(.)' in -`)
+	flagInvalidValueRe  = regexp.MustCompile(`^invalid argument ("(?:[^"\\]|\\.)*") for "(?:-(.), )?--([^"]+)" flag: `)
+	flagBadSyntaxPrefix = "bad flag syntax: "
+)
+
+// newFlagParseError returns the FlagParseError of the error err returned by parsing
+// the flags args. Errors not coming from the flag parser are returned unchanged.
+func (c *Command) newFlagParseError(err error, args []string) error {
+	redacted := c.redactFlagError(err, args)
+	e := &FlagParseError{Err: redacted}
+	msg := err.Error()
+	if m := flagUnknownRe.FindStringSubmatch(msg); m != nil {
+		e.Kind, e.Name = FlagErrorUnknown, m[1]
+	} else if m := flagUnknownShortRe.FindStringSubmatch(msg); m != nil {
+		e.Kind, e.Shorthand = FlagErrorUnknown, m[1]
+	} else if m := flagMissingRe.FindStringSubmatch(msg); m != nil {
+		e.Kind, e.Name = FlagErrorMissingValue, m[1]
+	} else if m := flagMissingShortRe.FindStringSubmatch(msg); m != nil {
+		e.Kind, e.Shorthand = FlagErrorMissingValue, m[1]
+		if f := c.Flags().ShorthandLookup(e.Shorthand); f != nil {
+			e.Name = f.Name
+		}
+	} else if m := flagInvalidValueRe.FindStringSubmatch(msg); m != nil {
+		e.Kind, e.Shorthand, e.Name = FlagErrorInvalidValue, m[2], m[3]
+		fmt.Sscanf(m[1], "%q", &e.Value)
+		if isFlagSensitive(c.Flags().Lookup(e.Name)) {
+			e.Value = RedactedValue
+		}
+	} else if strings.HasPrefix(msg, flagBadSyntaxPrefix) {
+		e.Kind = FlagErrorBadSyntax
+	} else {
+		return err
+	}
+
+	if e.Kind == FlagErrorUnknown && e.Name != "" {
+		e.Suggestions = c.flagSuggestionsFor(e.Name)
+	}
+	if EnableFlagPlacementHints {
+		redacted = c.addFlagPlacementHint(redacted)
+	}
+	e.msg = redacted.Error()
+	if EnableFlagSuggestions && len(e.Suggestions) > 0 {
+		e.msg += "\n\nDid you mean this?\n"
+		for _, s := range e.Suggestions {
+			e.msg += fmt.Sprintf("\t--%v\n", s)
+		}
+	}
+	return e
+}
+
+// flagSuggestionsFor returns the names of the flags of c close to typedName, by
+// Levenshtein distance or prefix, like SuggestionsFor does for commands.
+func (c *Command) flagSuggestionsFor(typedName string) []string {
+	if c.DisableSuggestions {
+		return nil
+	}
+	distance := c.SuggestionsMinimumDistance
+	if distance <= 0 {
+		distance = 2
+	}
+	var suggestions []string
+	c.Flags().VisitAll(func(f *flag.Flag) {
+		if f.Hidden || f.Deprecated != "" {
+			return
+		}
+		if ld(typedName, f.Name, true) <= distance ||
+			strings.HasPrefix(strings.ToLower(f.Name), strings.ToLower(typedName)) {
+			suggestions = append(suggestions, f.Name)
+		}
+	})
+	sort.Strings(suggestions)
+	return suggestions
+}
@@ -0,0 +1,89 @@
+package cobra
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFlagParseError(t *testing.T) {
+	tests := []struct {
+		args     []string
+		expected FlagParseError
+	}{
+		{[]string{"--verbos"}, FlagParseError{Kind: FlagErrorUnknown, Name: "verbos", Suggestions: []string{"verbose"}}},
+		{[]string{"-x"}, FlagParseError{Kind: FlagErrorUnknown, Shorthand: "x"}},
+		{[]string{"--count"}, FlagParseError{Kind: FlagErrorMissingValue, Name: "count"}},
+		{[]string{"-c"}, FlagParseError{Kind: FlagErrorMissingValue, Name: "count", Shorthand: "c"}},
+		{[]string{"-c", "many"}, FlagParseError{Kind: FlagErrorInvalidValue, Name: "count", Shorthand: "c", Value: "many"}},
+		{[]string{"--token=secret", "--count=x"}, FlagParseError{Kind: FlagErrorInvalidValue, Name: "count", Shorthand: "c", Value: "x"}},
+		{[]string{"---count"}, FlagParseError{Kind: FlagErrorBadSyntax}},
+	}
+	for _, tc := range tests {
+		var got *FlagParseError
+		c := &Command{Use: "c", Run: emptyRun}
+		c.Flags().Bool("verbose", false, "")
+		c.Flags().IntP("count", "c", 0, "")
+		c.Flags().String("token", "", "")
+		c.MarkFlagSensitive("token")
+		c.SetFlagErrorFunc(func(_ *Command, err error) error {
+			got, _ = err.(*FlagParseError)
+			return err
+		})
+
+		output, err := executeCommand(c, tc.args...)
+		if err == nil {
+			t.Errorf("%v: expected error", tc.args)
+			continue
+		}
+		if got == nil {
+			t.Errorf("%v: expected a *FlagParseError, got %T", tc.args, err)
+			continue
+		}
+		if got.Err == nil {
+			t.Errorf("%v: expected the error of the flag parser", tc.args)
+		}
+		got.Err, got.msg = nil, ""
+		if !reflect.DeepEqual(*got, tc.expected) {
+			t.Errorf("%v: expected %+v, got %+v", tc.args, tc.expected, *got)
+		}
+		checkStringOmits(t, output, "secret")
+	}
+}
+
+func TestFlagParseErrorRedacted(t *testing.T) {
+	var got *FlagParseError
+	c := &Command{Use: "c", Run: emptyRun}
+	c.Flags().Int("token", 0, "")
+	c.MarkFlagSensitive("token")
+	c.SetFlagErrorFunc(func(_ *Command, err error) error {
+		got, _ = err.(*FlagParseError)
+		return err
+	})
+
+	output, _ := executeCommand(c, "--token", "secret")
+	if got == nil {
+		t.Fatal("Expected a *FlagParseError")
+	}
+	if got.Value != RedactedValue {
+		t.Errorf("Expected value %q, got %q", RedactedValue, got.Value)
+	}
+	checkStringOmits(t, got.Err.Error(), "secret")
+	checkStringOmits(t, output, "secret")
+}
+
+func TestFlagParseErrorSuggestions(t *testing.T) {
+	c := &Command{Use: "c", Run: emptyRun}
+	c.Flags().Bool("verbose", false, "")
+
+	output, _ := executeCommand(c, "--verbos")
+	checkStringOmits(t, output, "Did you mean this?")
+
+	EnableFlagSuggestions = true
+	defer func() { EnableFlagSuggestions = false }()
+	output, _ = executeCommand(c, "--verbos")
+	checkStringContains(t, output, "Did you mean this?\n\t--verbose\n")
+
+	c.DisableSuggestions = true
+	output, _ = executeCommand(c, "--verbos")
+	checkStringOmits(t, output, "Did you mean this?")
+}
@@ -0,0 +1,169 @@
+package cobra
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/pflag"
+)
+
+// Kinds of the groups of flags, as described in the usage.
+const (
+	flagGroupMutuallyExclusive = "mutually exclusive"
+	flagGroupRequiredTogether  = "required together"
+)
+
+// flagGroup is a group of flags given to MarkFlagsMutuallyExclusive or
+// MarkFlagsRequiredTogether.
+type flagGroup struct {
+	kind  string
+	names []string
+}
+
+// MarkFlagsMutuallyExclusive makes the named flags a group of which only one can be
+// set, e.g. MarkFlagsMutuallyExclusive("json", "yaml", "table"), causing your command
+// to report an error if invoked with several of them. The flags may be persistent
+// flags of c or of its parents. The group applies to c and to the subcommands of c
+// inheriting all its flags. It is listed in the usage, and bash and zsh completions
+// don't offer the other flags of the group once one is set.
+func (c *Command) MarkFlagsMutuallyExclusive(names ...string) error {
+	return c.addFlagGroup(flagGroupMutuallyExclusive, names)
+}
+
+// MarkFlagsRequiredTogether makes the named flags a group of which either all or none
+// must be set, e.g. MarkFlagsRequiredTogether("username", "password"), causing your
+// command to report an error if invoked with only some of them. The flags may be
+// persistent flags of c or of its parents, and also belong to groups of mutually
+// exclusive flags. The group applies to c and to the subcommands of c inheriting all
+// its flags. It is listed in the usage.
+func (c *Command) MarkFlagsRequiredTogether(names ...string) error {
+	return c.addFlagGroup(flagGroupRequiredTogether, names)
+}
+
+func (c *Command) addFlagGroup(kind string, names []string) error {
+	if len(names) < 2 {
+		return fmt.Errorf("a group of flags needs at least two flags, got %v", names)
+	}
+	c.mergePersistentFlags()
+	for _, name := range names {
+		if c.Flags().Lookup(name) == nil {
+			return fmt.Errorf("no such flag -%v", name)
+		}
+	}
+	c.flagGroups = append(c.flagGroups, flagGroup{kind, append([]string(nil), names...)})
+	return nil
+}
+
+// flagGroupsOf returns the names of the flags of the groups of the kind given to c and
+// to its parents, whose flags are all flags of c, the groups of the parents first.
+func (c *Command) flagGroupsOf(kind string) [][]string {
+	var cmds []*Command
+	for p := c; p != nil; p = p.parent {
+		cmds = append([]*Command{p}, cmds...)
+	}
+	flags := c.Flags()
+	seen := make(map[string]bool)
+	var groups [][]string
+	for _, p := range cmds {
+	groups:
+		for _, group := range p.flagGroups {
+			key := strings.Join(group.names, " ")
+			if group.kind != kind || seen[key] {
+				continue
+			}
+			for _, name := range group.names {
+				if flags.Lookup(name) == nil {
+					continue groups
+				}
+			}
+			seen[key] = true
+			groups = append(groups, group.names)
+		}
+	}
+	return groups
+}
+
+// mutuallyExclusiveFlags returns the names of the flags of c which can't be set with
+// the named flag.
+func (c *Command) mutuallyExclusiveFlags(name string) []string {
+	var names []string
+	for _, group := range c.flagGroupsOf(flagGroupMutuallyExclusive) {
+		if !stringInSlice(name, group) {
+			continue
+		}
+		for _, other := range group {
+			if other != name && !stringInSlice(other, names) {
+				names = append(names, other)
+			}
+		}
+	}
+	return names
+}
+
+// excludedBySetFlag returns true if a flag mutually exclusive with the named flag is
+// set, in which case the named flag must not be set by a preset or the environment.
+func (c *Command) excludedBySetFlag(name string) bool {
+	for _, other := range c.mutuallyExclusiveFlags(name) {
+		if f := c.Flags().Lookup(other); f != nil && f.Changed {
+			return true
+		}
+	}
+	return false
+}
+
+// HasFlagGroups returns true if some flags of c are grouped with
+// MarkFlagsMutuallyExclusive or MarkFlagsRequiredTogether.
+func (c *Command) HasFlagGroups() bool {
+	return len(c.flagGroupsOf(flagGroupMutuallyExclusive)) > 0 || len(c.flagGroupsOf(flagGroupRequiredTogether)) > 0
+}
+
+// FlagGroupUsages returns the lines listing the groups of flags of c for the usage,
+// e.g. "  --json, --yaml, --table   mutually exclusive".
+func (c *Command) FlagGroupUsages() string {
+	var lines [][2]string
+	for _, kind := range []string{flagGroupMutuallyExclusive, flagGroupRequiredTogether} {
+		for _, group := range c.flagGroupsOf(kind) {
+			lines = append(lines, [2]string{"--" + strings.Join(group, ", --"), kind})
+		}
+	}
+	width := 0
+	for _, line := range lines {
+		if n := displayWidth(line[0]); n > width {
+			width = n
+		}
+	}
+	var b strings.Builder
+	for _, line := range lines {
+		b.WriteString("  " + rpad(line[0], width) + "   " + line[1] + "\n")
+	}
+	return b.String()
+}
+
+func (c *Command) validateFlagGroups() error {
+	flags := c.Flags()
+	for _, group := range c.flagGroupsOf(flagGroupRequiredTogether) {
+		set, unset := splitChangedFlags(flags, group)
+		if len(set) > 0 && len(unset) > 0 {
+			return fmt.Errorf("flags --%s must be set together, missing %s", strings.Join(group, ", --"), strings.Join(unset, " and "))
+		}
+	}
+	for _, group := range c.flagGroupsOf(flagGroupMutuallyExclusive) {
+		if set, _ := splitChangedFlags(flags, group); len(set) > 1 {
+			return fmt.Errorf("only one of the flags --%s can be set, got %s", strings.Join(group, ", --"), strings.Join(set, " and "))
+		}
+	}
+	return nil
+}
+
+// splitChangedFlags returns the named flags of flags which are set and those which are
+// not, as "--name".
+func splitChangedFlags(flags *pflag.FlagSet, names []string) (set, unset []string) {
+	for _, name := range names {
+		if f := flags.Lookup(name); f != nil && f.Changed {
+			set = append(set, "--"+name)
+		} else {
+			unset = append(unset, "--"+name)
+		}
+	}
+	return set, unset
+}
@@ -0,0 +1,184 @@
+package cobra
+
+import (
+	"bytes"
+	"testing"
+)
+
+func getFlagGroupsTestCommand(t *testing.T) *Command {
+	root := &Command{Use: "root"}
+	root.PersistentFlags().Bool("table", false, "")
+	c := &Command{Use: "get", Run: emptyRun}
+	c.Flags().BoolP("json", "j", false, "")
+	c.Flags().Bool("yaml", false, "")
+	c.Flags().String("name", "", "")
+	root.AddCommand(c)
+	if err := c.MarkFlagsMutuallyExclusive("json", "yaml", "table"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	return root
+}
+
+func TestMutuallyExclusiveFlags(t *testing.T) {
+	testcases := []struct {
+		args []string
+		err  string
+	}{
+		{[]string{"get"}, ""},
+		{[]string{"get", "--json", "--name=x"}, ""},
+		{[]string{"get", "--table"}, ""},
+		{[]string{"get", "-j", "--yaml"}, "only one of the flags --json, --yaml, --table can be set, got --json and --yaml"},
+		{[]string{"get", "--table", "--json"}, "only one of the flags --json, --yaml, --table can be set, got --json and --table"},
+	}
+	for _, tc := range testcases {
+		_, err := executeCommand(getFlagGroupsTestCommand(t), tc.args...)
+		if tc.err == "" && err != nil {
+			t.Errorf("%v: unexpected error: %v", tc.args, err)
+		}
+		if tc.err != "" && (err == nil || err.Error() != tc.err) {
+			t.Errorf("%v: expected error %q, got %v", tc.args, tc.err, err)
+		}
+	}
+}
+
+func TestMutuallyExclusiveFlagsInvalid(t *testing.T) {
+	c := &Command{Use: "root", Run: emptyRun}
+	c.Flags().Bool("json", false, "")
+	if err := c.MarkFlagsMutuallyExclusive("json"); err == nil {
+		t.Error("Expected error for a group of one flag")
+	}
+	if err := c.MarkFlagsMutuallyExclusive("json", "missing"); err == nil {
+		t.Error("Expected error for unknown flag")
+	}
+	if len(c.flagGroups) != 0 {
+		t.Error("Expected no group after an error")
+	}
+}
+
+// getFlagGroupsScopeTestCommand returns a tree where list marks a group with the
+// persistent flag --table of the root, and the root a group of its persistent flags.
+func getFlagGroupsScopeTestCommand(t *testing.T) *Command {
+	rootCmd := getFlagGroupsTestCommand(t)
+	rootCmd.Run = emptyRun
+	rootCmd.PersistentFlags().Bool("yaml", false, "")
+	listCmd := &Command{Use: "list", Run: emptyRun}
+	listCmd.Flags().Bool("wide", false, "")
+	rootCmd.AddCommand(listCmd)
+	if err := listCmd.MarkFlagsMutuallyExclusive("table", "wide"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := rootCmd.MarkFlagsRequiredTogether("table", "yaml"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	return rootCmd
+}
+
+func TestFlagGroupsScope(t *testing.T) {
+	testcases := []struct {
+		args []string
+		err  string
+	}{
+		// The group marked on list applies neither to the parent nor to get.
+		{[]string{"--table", "--yaml"}, ""},
+		{[]string{"get", "--table", "--yaml"}, "only one of the flags --json, --yaml, --table can be set, got --yaml and --table"},
+		{[]string{"list", "--table", "--yaml", "--wide"}, "only one of the flags --table, --wide can be set, got --table and --wide"},
+		// The group marked on the parent applies to the subcommands inheriting its flags.
+		{[]string{"list", "--wide", "--yaml"}, "flags --table, --yaml must be set together, missing --table"},
+	}
+	for _, tc := range testcases {
+		_, err := executeCommand(getFlagGroupsScopeTestCommand(t), tc.args...)
+		if tc.err == "" && err != nil {
+			t.Errorf("%v: unexpected error: %v", tc.args, err)
+		}
+		if tc.err != "" && (err == nil || err.Error() != tc.err) {
+			t.Errorf("%v: expected error %q, got %v", tc.args, tc.err, err)
+		}
+	}
+
+	rootCmd := getFlagGroupsScopeTestCommand(t)
+	getCmd, _, _ := rootCmd.Find([]string{"get"})
+	if err := getCmd.MarkFlagsMutuallyExclusive("table", "wide"); err == nil {
+		t.Error("Expected error for a flag of a sibling")
+	}
+}
+
+func TestFlagGroupsUsage(t *testing.T) {
+	output, err := executeCommand(getFlagGroupsTestCommand(t), "get", "--help")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	checkStringContains(t, output, "Flag Groups:\n  --json, --yaml, --table   mutually exclusive\n")
+}
+
+func TestBashCompletionMutuallyExclusiveFlags(t *testing.T) {
+	root := getFlagGroupsTestCommand(t)
+
+	buf := new(bytes.Buffer)
+	root.GenBashCompletion(buf)
+	output := buf.String()
+
+	check(t, output, `mutually_exclusive_flags+=("--json --json= -j --yaml --yaml= --table --table=")`)
+	check(t, output, `excluded_flags+=(${group})`)
+}
+
+func getRequiredTogetherTestCommand(t *testing.T) *Command {
+	c := &Command{Use: "login", Run: emptyRun}
+	c.Flags().String("username", "", "")
+	c.Flags().String("password", "", "")
+	c.Flags().String("token", "", "")
+	if err := c.MarkFlagsRequiredTogether("username", "password"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := c.MarkFlagsMutuallyExclusive("username", "token"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	return c
+}
+
+func TestRequiredTogetherFlags(t *testing.T) {
+	testcases := []struct {
+		args []string
+		err  string
+	}{
+		{[]string{}, ""},
+		{[]string{"--username=u", "--password=p"}, ""},
+		{[]string{"--token=t"}, ""},
+		{[]string{"--username=u"}, "flags --username, --password must be set together, missing --password"},
+		{[]string{"--password=p", "--token=t"}, "flags --username, --password must be set together, missing --username"},
+		{[]string{"--username=u", "--password=p", "--token=t"}, "only one of the flags --username, --token can be set, got --username and --token"},
+	}
+	for _, tc := range testcases {
+		_, err := executeCommand(getRequiredTogetherTestCommand(t), tc.args...)
+		if tc.err == "" && err != nil {
+			t.Errorf("%v: unexpected error: %v", tc.args, err)
+		}
+		if tc.err != "" && (err == nil || err.Error() != tc.err) {
+			t.Errorf("%v: expected error %q, got %v", tc.args, tc.err, err)
+		}
+	}
+}
+
+func TestRequiredTogetherFlagsUsage(t *testing.T) {
+	output, err := executeCommand(getRequiredTogetherTestCommand(t), "--help")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	checkStringContains(t, output, `Flag Groups:
+  --username, --token      mutually exclusive
+  --username, --password   required together
+`)
+}
+
+func TestZshCompletionMutuallyExclusiveFlags(t *testing.T) {
+	root := getFlagGroupsTestCommand(t)
+
+	buf := new(bytes.Buffer)
+	if err := root.GenZshCompletion(buf); err != nil {
+		t.Fatal(err)
+	}
+	output := buf.String()
+
+	check(t, output, `'(-j --json --yaml --table)'{-j,--json}'[]'`)
+	check(t, output, `'(--json -j --table)--yaml[]'`)
+	check(t, output, `'(--json -j --yaml)--table[]'`)
+}
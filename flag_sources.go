@@ -0,0 +1,44 @@
+package cobra
+
+import "fmt"
+
+// Sources of the values of flags, as returned by FlagSource.
+const (
+	// FlagSourceDefault is the source of the flags left to their default value.
+	FlagSourceDefault = "default"
+	// FlagSourceCommandLine is the source of the flags set on the command line.
+	FlagSourceCommandLine = "command line"
+	// FlagSourcePreset is the source of the flags set by a preset, see AddPresetFlags.
+	FlagSourcePreset = "preset"
+)
+
+// SetFlagFromSource sets the value of the named flag of the command, like
+// Flags().Set, and records its source, e.g. "env" or a configuration file, so that
+// FlagSource and the config view command report it.
+func (c *Command) SetFlagFromSource(name, value, source string) error {
+	if err := c.Flags().Set(name, value); err != nil {
+		return err
+	}
+	if c.flagSources == nil {
+		c.flagSources = make(map[string]string)
+	}
+	c.flagSources[name] = source
+	return nil
+}
+
+// FlagSource returns the source of the value of the named flag of the command: the
+// source given to SetFlagFromSource, FlagSourceCommandLine if the flag was set by the
+// command line, or FlagSourceDefault.
+func (c *Command) FlagSource(name string) (string, error) {
+	f := c.Flags().Lookup(name)
+	if f == nil {
+		return "", fmt.Errorf("no such flag -%v", name)
+	}
+	if source, ok := c.flagSources[name]; ok {
+		return source, nil
+	}
+	if f.Changed {
+		return FlagSourceCommandLine, nil
+	}
+	return FlagSourceDefault, nil
+}
@@ -0,0 +1,240 @@
+package cobra
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	flag "github.com/spf13/pflag"
+)
+
+// flagSyntaxEnabled returns true if enabled returns true for c or one of its parents.
+func (c *Command) flagSyntaxEnabled(enabled func(*Command) bool) bool {
+	for p := c; p != nil; p = p.parent {
+		if enabled(p) {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeFlagArgs rewrites the flags of args given in the alternative syntaxes
+// accepted by c, e.g. WindowsFlagSyntax, to the syntax of pflag. The values following
+// the flags which take one, and the arguments following "--", are left unchanged.
+func (c *Command) normalizeFlagArgs(args []string) []string {
+	windows := c.flagSyntaxEnabled(func(p *Command) bool { return p.WindowsFlagSyntax })
+	singleDash := c.singleDashLongFlags()
+	abbreviated := c.abbreviatedFlags()
+	if !windows && !singleDash && !abbreviated {
+		return args
+	}
+	c.mergePersistentFlags()
+	flags := c.Flags()
+
+	normalized := make([]string, 0, len(args))
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			return append(normalized, args[i:]...)
+		}
+		if windows {
+			arg = windowsFlagArg(arg, flags)
+		}
+		if singleDash {
+			arg = singleDashLongFlagArg(arg, flags)
+		}
+		if abbreviated {
+			arg = abbreviatedFlagArg(arg, flags)
+		}
+		normalized = append(normalized, arg)
+		if flagTakesNextArg(arg, flags) && i+1 < len(args) {
+			i++
+			normalized = append(normalized, args[i])
+		}
+	}
+	return normalized
+}
+
+// flagTakesNextArg returns true if arg, in the syntax of pflag, is a flag of flags
+// whose value is the next argument, e.g. "--name" or "-vn" for a string flag named
+// "name" with the shorthand "n".
+func flagTakesNextArg(arg string, flags *flag.FlagSet) bool {
+	if len(arg) < 2 || arg[0] != '-' || strings.Contains(arg, "=") {
+		return false
+	}
+	if arg[1] == '-' {
+		f := flags.Lookup(arg[2:])
+		return f != nil && f.NoOptDefVal == ""
+	}
+	// Only the last shorthand of a group can take the next argument.
+	for i := 1; i < len(arg); i++ {
+		f := flags.ShorthandLookup(arg[i : i+1])
+		if f == nil {
+			return false
+		}
+		if f.NoOptDefVal == "" {
+			return i == len(arg)-1
+		}
+	}
+	return false
+}
+
+// windowsFlagArg returns the pflag syntax of arg if it is a flag of flags written in
+// the Windows syntax, e.g. "/name:value", or returns arg unchanged.
+func windowsFlagArg(arg string, flags *flag.FlagSet) string {
+	if len(arg) < 2 || arg[0] != '/' {
+		return arg
+	}
+	name, value := arg[1:], ""
+	hasValue := false
+	if i := strings.IndexByte(name, ':'); i >= 0 {
+		name, value, hasValue = name[:i], name[i+1:], true
+	}
+	if name == "" {
+		return arg
+	}
+	var dashes string
+	switch {
+	case len(name) == 1 && flags.ShorthandLookup(name) != nil:
+		dashes = "-"
+	case flags.Lookup(name) != nil:
+		dashes = "--"
+	default:
+		return arg
+	}
+	if hasValue {
+		return dashes + name + "=" + value
+	}
+	return dashes + name
+}
+
+// singleDashLongFlags returns true if c accepts single-dash long flags.
+func (c *Command) singleDashLongFlags() bool {
+	return c.flagSyntaxEnabled(func(p *Command) bool { return p.SingleDashLongFlags })
+}
+
+// singleDashLongFlagArg returns the double-dash form of arg if it is a long flag of
+// flags given with a single dash, e.g. "-output=json", or returns arg unchanged.
+func singleDashLongFlagArg(arg string, flags *flag.FlagSet) string {
+	if len(arg) < 3 || arg[0] != '-' || arg[1] == '-' {
+		return arg
+	}
+	name := strings.SplitN(arg[1:], "=", 2)[0]
+	if !isSingleDashLongFlag(name, flags) {
+		return arg
+	}
+	return "-" + arg
+}
+
+// isSingleDashLongFlag returns true if "-name" unambiguously names a long flag of
+// flags, rather than a group of shorthands.
+func isSingleDashLongFlag(name string, flags *flag.FlagSet) bool {
+	return len(name) > 1 && flags.Lookup(name) != nil && flags.ShorthandLookup(name[:1]) == nil
+}
+
+// abbreviatedFlags returns true if c accepts abbreviated long flags.
+func (c *Command) abbreviatedFlags() bool {
+	return c.flagSyntaxEnabled(func(p *Command) bool { return p.AbbreviatedFlags })
+}
+
+// abbreviatedFlagArg returns arg with the full name of the flag if it is an
+// unambiguous abbreviation of a long flag of flags, e.g. "--verb" for "--verbose",
+// or returns arg unchanged.
+func abbreviatedFlagArg(arg string, flags *flag.FlagSet) string {
+	name, value := splitLongFlagArg(arg)
+	if name == "" || flags.Lookup(name) != nil {
+		return arg
+	}
+	if candidates := flagAbbreviationCandidates(name, flags); len(candidates) == 1 {
+		return "--" + candidates[0] + value
+	}
+	return arg
+}
+
+// splitLongFlagArg returns the name of the long flag arg, e.g. "name" for
+// "--name=value", and the rest of arg, e.g. "=value". The name is empty if arg is
+// not a long flag.
+func splitLongFlagArg(arg string) (name, value string) {
+	if len(arg) < 3 || !strings.HasPrefix(arg, "--") || arg[2] == '-' {
+		return "", ""
+	}
+	name = arg[2:]
+	if i := strings.IndexByte(name, '='); i >= 0 {
+		name, value = name[:i], name[i:]
+	}
+	return name, value
+}
+
+// flagAbbreviationCandidates returns the sorted names of the visible flags of flags
+// starting with prefix.
+func flagAbbreviationCandidates(prefix string, flags *flag.FlagSet) []string {
+	var candidates []string
+	flags.VisitAll(func(f *flag.Flag) {
+		if !f.Hidden && strings.HasPrefix(f.Name, prefix) {
+			candidates = append(candidates, f.Name)
+		}
+	})
+	sort.Strings(candidates)
+	return candidates
+}
+
+// checkAbbreviatedFlags returns an error listing the candidates of the first long
+// flag of args, up to "--", which abbreviates several flags.
+func (c *Command) checkAbbreviatedFlags(args []string) error {
+	flags := c.Flags()
+	for _, arg := range args {
+		if arg == "--" {
+			return nil
+		}
+		name, _ := splitLongFlagArg(arg)
+		if name == "" || flags.Lookup(name) != nil {
+			continue
+		}
+		if candidates := flagAbbreviationCandidates(name, flags); len(candidates) > 1 {
+			return fmt.Errorf("ambiguous flag: --%s could be --%s", name, strings.Join(candidates, ", --"))
+		}
+	}
+	return nil
+}
+
+// flagUsageLineRe matches the flag part of a line of pflag's FlagUsages: the
+// indentation, the optional shorthand, the name and the optional value name.
+var flagUsageLineRe = regexp.MustCompile(`^  (?:-(\S), |    )--([^\s\[=]+)(?: ([^\s\[]+))?`)
+
+// WindowsFlagUsages returns the usages of flags written in the Windows syntax
+// accepted with WindowsFlagSyntax, e.g. "/n, /name:string" rather than
+// "-n, --name string". Help templates can use it in place of FlagUsages:
+//
+//	{{.WindowsFlagUsages .LocalFlags | trimTrailingWhitespaces}}
+func (c *Command) WindowsFlagUsages(flags *flag.FlagSet) string {
+	return rewriteFlagUsages(c.DisplayFlags(flags).FlagUsages(), func(shorthand, name, valueName string) string {
+		// The Windows syntax has the same width, so that the usages stay aligned.
+		s := "       /" + name
+		if shorthand != "" {
+			s = "  /" + shorthand + ",  /" + name
+		}
+		if valueName != "" {
+			s += ":" + valueName
+		}
+		return s
+	})
+}
+
+// rewriteFlagUsages returns the usages, as returned by FlagUsages, whose flag parts
+// are replaced by rewrite given the shorthand, the name and the value name of the
+// flag. The replacement must have the width of the flag part to keep the alignment,
+// and is ignored if empty.
+func rewriteFlagUsages(usages string, rewrite func(shorthand, name, valueName string) string) string {
+	lines := strings.SplitAfter(usages, "\n")
+	for i, line := range lines {
+		m := flagUsageLineRe.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		if r := rewrite(m[1], m[2], m[3]); r != "" {
+			lines[i] = r + line[len(m[0]):]
+		}
+	}
+	return strings.Join(lines, "")
+}
@@ -0,0 +1,185 @@
+package cobra
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestWindowsFlagSyntax(t *testing.T) {
+	var gotArgs []string
+	rootCmd := &Command{Use: "root", WindowsFlagSyntax: true, Run: emptyRun}
+	rootCmd.PersistentFlags().BoolP("verbose", "v", false, "verbose output")
+	childCmd := &Command{Use: "child", Run: func(_ *Command, args []string) { gotArgs = args }}
+	childCmd.Flags().StringP("output", "o", "", "output format")
+	rootCmd.AddCommand(childCmd)
+
+	_, err := executeCommand(rootCmd, "/v", "child", "/output:json", "/tmp/file", "/unknown:x", "--", "/o:yaml")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if output, _ := childCmd.Flags().GetString("output"); output != "json" {
+		t.Errorf("Expected output %q, got %q", "json", output)
+	}
+	if verbose, _ := childCmd.Flags().GetBool("verbose"); !verbose {
+		t.Error("Expected verbose to be set by /v")
+	}
+	expected := []string{"/tmp/file", "/unknown:x", "/o:yaml"}
+	if !reflect.DeepEqual(gotArgs, expected) {
+		t.Errorf("Expected args %v, got %v", expected, gotArgs)
+	}
+}
+
+func TestWindowsFlagSyntaxLeavesValues(t *testing.T) {
+	var gotArgs []string
+	rootCmd := &Command{Use: "root", WindowsFlagSyntax: true, Run: emptyRun}
+	rootCmd.PersistentFlags().BoolP("verbose", "v", false, "verbose output")
+	childCmd := &Command{Use: "child", Run: func(_ *Command, args []string) { gotArgs = args }}
+	childCmd.Flags().StringP("out", "o", "", "output directory")
+	childCmd.Flags().String("in", "", "input directory")
+	rootCmd.AddCommand(childCmd)
+
+	for _, traverse := range []bool{false, true} {
+		rootCmd.TraverseChildren = traverse
+		_, err := executeCommand(rootCmd, "child", "/out", "/o", "--in", "/v", "-o", "/v", "operand")
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if out, _ := childCmd.Flags().GetString("out"); out != "/v" {
+			t.Errorf("Expected out %q, got %q", "/v", out)
+		}
+		if in, _ := childCmd.Flags().GetString("in"); in != "/v" {
+			t.Errorf("Expected in %q, got %q", "/v", in)
+		}
+		if verbose, _ := childCmd.Flags().GetBool("verbose"); verbose {
+			t.Error("Expected verbose not to be set by a flag value")
+		}
+		if !reflect.DeepEqual(gotArgs, []string{"operand"}) {
+			t.Errorf("Expected args %v, got %v", []string{"operand"}, gotArgs)
+		}
+		childCmd.Flags().Set("out", "")
+	}
+}
+
+func TestWindowsFlagSyntaxDisabled(t *testing.T) {
+	var gotArgs []string
+	rootCmd := &Command{Use: "root", Run: func(_ *Command, args []string) { gotArgs = args }}
+	rootCmd.Flags().String("output", "", "output format")
+
+	if _, err := executeCommand(rootCmd, "/output:json"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(gotArgs, []string{"/output:json"}) {
+		t.Errorf("Expected the argument to be left unchanged, got %v", gotArgs)
+	}
+}
+
+func TestWindowsFlagUsages(t *testing.T) {
+	c := &Command{Use: "root", WindowsFlagSyntax: true}
+	c.Flags().StringP("output", "o", "", "output format")
+	c.Flags().Bool("all", false, "all the things")
+	c.SetUsageTemplate(`{{.WindowsFlagUsages .LocalFlags}}`)
+
+	expected := strings.Join([]string{
+		"       /all             all the things",
+		"  /o,  /output:string   output format",
+		"",
+	}, "\n")
+	if got := c.UsageString(); got != expected {
+		t.Errorf("Expected:\n%q\nGot:\n%q", expected, got)
+	}
+	if got, want := c.WindowsFlagUsages(c.LocalFlags()), c.LocalFlags().FlagUsages(); len(got) != len(want) {
+		t.Errorf("Expected the Windows syntax to keep the alignment, got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestSingleDashLongFlags(t *testing.T) {
+	var gotArgs []string
+	rootCmd := &Command{Use: "root", SingleDashLongFlags: true, Run: emptyRun}
+	rootCmd.PersistentFlags().Bool("verbose", false, "verbose output")
+	childCmd := &Command{Use: "child", Run: func(_ *Command, args []string) { gotArgs = args }}
+	childCmd.Flags().String("output", "", "output format")
+	childCmd.Flags().String("name", "", "a name")
+	childCmd.Flags().BoolP("all", "a", false, "all")
+	childCmd.Flags().Bool("append", false, "append")
+	rootCmd.AddCommand(childCmd)
+
+	_, err := executeCommand(rootCmd, "-verbose", "child", "-output", "json", "-name=x", "-append", "arg", "--", "-output")
+	if err == nil || !strings.Contains(err.Error(), "unknown shorthand flag: 'p' in -ppend") {
+		t.Errorf("Expected -append to be parsed as shorthands, got %v", err)
+	}
+
+	_, err = executeCommand(rootCmd, "-verbose", "child", "-output", "json", "-name=x", "arg", "--", "-output")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if output, _ := childCmd.Flags().GetString("output"); output != "json" {
+		t.Errorf("Expected output %q, got %q", "json", output)
+	}
+	if name, _ := childCmd.Flags().GetString("name"); name != "x" {
+		t.Errorf("Expected name %q, got %q", "x", name)
+	}
+	if verbose, _ := childCmd.Flags().GetBool("verbose"); !verbose {
+		t.Error("Expected verbose to be set by -verbose")
+	}
+	if !reflect.DeepEqual(gotArgs, []string{"arg", "-output"}) {
+		t.Errorf("Expected args %v, got %v", []string{"arg", "-output"}, gotArgs)
+	}
+}
+
+func TestSingleDashLongFlagsHelpAndCompletion(t *testing.T) {
+	rootCmd := &Command{Use: "root", SingleDashLongFlags: true, Run: emptyRun}
+	rootCmd.Flags().String("output", "", "output format")
+	rootCmd.Flags().BoolP("all", "a", false, "all")
+	rootCmd.Flags().Bool("append", false, "append")
+
+	output, err := executeCommand(rootCmd, "--help")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	checkStringContains(t, output, "  -a, --all             all\n")
+	checkStringContains(t, output, "      --append          append\n")
+	checkStringContains(t, output, "       -output string   output format\n")
+
+	buf := new(bytes.Buffer)
+	if err := rootCmd.GenBashCompletion(buf); err != nil {
+		t.Fatal(err)
+	}
+	script := buf.String()
+	check(t, script, `flags+=("-output=")`)
+	check(t, script, `two_word_flags+=("-output")`)
+	checkOmit(t, script, `flags+=("-append")`)
+}
+
+func TestAbbreviatedFlags(t *testing.T) {
+	rootCmd := &Command{Use: "root", AbbreviatedFlags: true, Run: emptyRun}
+	rootCmd.PersistentFlags().String("output", "", "output format")
+	childCmd := &Command{Use: "child", Run: emptyRun}
+	childCmd.Flags().Bool("verbose", false, "verbose output")
+	childCmd.Flags().Bool("version", false, "show the version")
+	childCmd.Flags().Bool("secret", false, "hidden flag")
+	childCmd.Flags().MarkHidden("secret")
+	rootCmd.AddCommand(childCmd)
+
+	_, err := executeCommand(rootCmd, "--out", "json", "child", "--verb")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if output, _ := childCmd.Flags().GetString("output"); output != "json" {
+		t.Errorf("Expected output %q, got %q", "json", output)
+	}
+	if verbose, _ := childCmd.Flags().GetBool("verbose"); !verbose {
+		t.Error("Expected verbose to be set by --verb")
+	}
+
+	_, err = executeCommand(rootCmd, "child", "--ver")
+	if err == nil || err.Error() != "ambiguous flag: --ver could be --verbose, --version" {
+		t.Errorf("Expected an ambiguous flag error, got %v", err)
+	}
+
+	_, err = executeCommand(rootCmd, "child", "--sec")
+	if err == nil || !strings.Contains(err.Error(), "unknown flag: --sec") {
+		t.Errorf("Expected hidden flags not to be abbreviated, got %v", err)
+	}
+}
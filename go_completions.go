@@ -0,0 +1,86 @@
+package cobra
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"io"
+	"os"
+	"strings"
+)
+
+// GenGoCompletionFile generates a Go source file holding the completion scripts.
+func (c *Command) GenGoCompletionFile(filename, pkg string) error {
+	outFile, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer outFile.Close()
+
+	return c.GenGoCompletion(outFile, pkg)
+}
+
+// GenGoCompletion generates a Go source file of package pkg holding the bash, zsh and
+// PowerShell completion scripts of the root command in the BashCompletion, ZshCompletion
+// and PowerShellCompletion constants, and writes it to the passed writer. Run from
+// go:generate, it lets binaries serve their completion scripts without generating them
+// at runtime, and the scripts be reviewed in diffs.
+func (c *Command) GenGoCompletion(w io.Writer, pkg string) error {
+	scripts := []struct {
+		name, shell string
+		gen         func(io.Writer) error
+	}{
+		{"BashCompletion", "bash", c.GenBashCompletion},
+		{"ZshCompletion", "zsh", c.GenZshCompletion},
+		{"PowerShellCompletion", "PowerShell", c.GenPowerShellCompletion},
+	}
+
+	buf := new(bytes.Buffer)
+	buf.WriteString("// Code generated by cobra. DO NOT EDIT.\n\n")
+	buf.WriteString(fmt.Sprintf("package %s\n", pkg))
+	for _, s := range scripts {
+		script := new(bytes.Buffer)
+		if err := s.gen(script); err != nil {
+			return err
+		}
+		buf.WriteString(fmt.Sprintf("\n// %s is the %s completion script of %s.\n", s.name, s.shell, c.Root().Name()))
+		buf.WriteString(fmt.Sprintf("const %s = %s\n", s.name, goRawStringLiteral(script.String())))
+	}
+
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(src)
+	return err
+}
+
+// goRawStringLiteral returns a Go expression of s, made of raw string literals to keep
+// it readable, concatenated with the backquotes and carriage returns they cannot hold.
+func goRawStringLiteral(s string) string {
+	var parts []string
+	var raw strings.Builder
+	flush := func() {
+		if raw.Len() > 0 {
+			parts = append(parts, "`"+raw.String()+"`")
+			raw.Reset()
+		}
+	}
+	for _, r := range s {
+		switch r {
+		case '`':
+			flush()
+			parts = append(parts, "\"`\"")
+		case '\r':
+			flush()
+			parts = append(parts, `"\r"`)
+		default:
+			raw.WriteRune(r)
+		}
+	}
+	flush()
+	if len(parts) == 0 {
+		return `""`
+	}
+	return strings.Join(parts, " + ")
+}
@@ -0,0 +1,66 @@
+package cobra
+
+import (
+	"bytes"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strconv"
+	"testing"
+)
+
+// evalStringConcat evaluates a constant expression made of concatenated string literals.
+func evalStringConcat(t *testing.T, e ast.Expr) string {
+	switch e := e.(type) {
+	case *ast.BasicLit:
+		s, err := strconv.Unquote(e.Value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return s
+	case *ast.BinaryExpr:
+		return evalStringConcat(t, e.X) + evalStringConcat(t, e.Y)
+	}
+	t.Fatalf("Unexpected expression %T", e)
+	return ""
+}
+
+func TestGenGoCompletion(t *testing.T) {
+	rootCmd := &Command{Use: "root", Run: emptyRun}
+	rootCmd.AddCommand(&Command{Use: "child", Short: "with `backquotes`", Run: emptyRun})
+
+	buf := new(bytes.Buffer)
+	if err := rootCmd.GenGoCompletion(buf, "completions"); err != nil {
+		t.Fatal(err)
+	}
+	f, err := parser.ParseFile(token.NewFileSet(), "completions.go", buf.Bytes(), 0)
+	if err != nil {
+		t.Fatalf("Generated code does not parse: %v\n%s", err, buf.String())
+	}
+	if f.Name.Name != "completions" {
+		t.Errorf("Expected package completions, got %s", f.Name.Name)
+	}
+
+	constants := make(map[string]string)
+	for _, decl := range f.Decls {
+		for _, spec := range decl.(*ast.GenDecl).Specs {
+			vs := spec.(*ast.ValueSpec)
+			constants[vs.Names[0].Name] = evalStringConcat(t, vs.Values[0])
+		}
+	}
+
+	expected := map[string]func(*bytes.Buffer) error{
+		"BashCompletion":       func(b *bytes.Buffer) error { return rootCmd.GenBashCompletion(b) },
+		"ZshCompletion":        func(b *bytes.Buffer) error { return rootCmd.GenZshCompletion(b) },
+		"PowerShellCompletion": func(b *bytes.Buffer) error { return rootCmd.GenPowerShellCompletion(b) },
+	}
+	for name, gen := range expected {
+		script := new(bytes.Buffer)
+		if err := gen(script); err != nil {
+			t.Fatal(err)
+		}
+		if constants[name] != script.String() {
+			t.Errorf("Expected %s to hold the generated script", name)
+		}
+	}
+}
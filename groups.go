@@ -0,0 +1,55 @@
+package cobra
+
+import "fmt"
+
+// Group is a titled section of the subcommands in the help of a command, see AddGroup.
+type Group struct {
+	// ID is the identifier of the group, given to the GroupID of its commands.
+	ID string
+	// Title is the title of the section, e.g. "Basic Commands:".
+	Title string
+}
+
+// AddGroup adds groups to c. The help of c then lists its subcommands under the title
+// of their group, in the order the groups were added, and the subcommands without
+// group under "Additional Commands:".
+func (c *Command) AddGroup(groups ...*Group) {
+	c.commandGroups = append(c.commandGroups, groups...)
+}
+
+// Groups returns the groups added to c with AddGroup.
+func (c *Command) Groups() []*Group {
+	return c.commandGroups
+}
+
+// ContainsGroup returns true if the group groupID has been added to c.
+func (c *Command) ContainsGroup(groupID string) bool {
+	for _, group := range c.commandGroups {
+		if group.ID == groupID {
+			return true
+		}
+	}
+	return false
+}
+
+// AllChildCommandsHaveGroup returns true if all the subcommands of c listed in its help
+// belong to a group.
+func (c *Command) AllChildCommandsHaveGroup() bool {
+	for _, sub := range c.commands {
+		if (sub.IsAvailableCommand() || sub.IsHelpCommand()) && sub.GroupID == "" {
+			return false
+		}
+	}
+	return true
+}
+
+// checkCommandGroups panics if a command of the tree of c has a GroupID which has not
+// been added to its parent.
+func (c *Command) checkCommandGroups() {
+	for _, sub := range c.commands {
+		if sub.GroupID != "" && !c.ContainsGroup(sub.GroupID) {
+			panic(fmt.Sprintf("group id %q is not defined for subcommand %q", sub.GroupID, sub.CommandPath()))
+		}
+		sub.checkCommandGroups()
+	}
+}
@@ -0,0 +1,78 @@
+package cobra
+
+import (
+	"fmt"
+
+	flag "github.com/spf13/pflag"
+)
+
+// LocalFlagUsages returns the usages of the local flags of c for its help, limited and
+// wrapped according to HelpFlagsLimit and HelpFlagsWidth.
+func (c *Command) LocalFlagUsages() string {
+	return c.flagUsages(c.DisplayFlags(c.LocalFlags()))
+}
+
+// InheritedFlagUsages returns the usages of the inherited flags of c for its help,
+// limited and wrapped according to HelpFlagsLimit and HelpFlagsWidth.
+func (c *Command) InheritedFlagUsages() string {
+	return c.flagUsages(c.DisplayFlags(c.InheritedFlags()))
+}
+
+func (c *Command) flagUsages(flags *flag.FlagSet) string {
+	usages := c.limitedFlagUsages(flags)
+	if !c.singleDashLongFlags() {
+		return usages
+	}
+	all := c.Flags()
+	return rewriteFlagUsages(usages, func(shorthand, name, valueName string) string {
+		if !isSingleDashLongFlag(name, all) {
+			return ""
+		}
+		// Same width as "-n, --name" and "    --name".
+		s := "       -" + name
+		if shorthand != "" {
+			s = "  -" + shorthand + ",  -" + name
+		}
+		if valueName != "" {
+			s += " " + valueName
+		}
+		return s
+	})
+}
+
+func (c *Command) limitedFlagUsages(flags *flag.FlagSet) string {
+	limit, cols := 0, 0
+	for p := c; p != nil && (limit == 0 || cols == 0); p = p.parent {
+		if limit == 0 {
+			limit = p.HelpFlagsLimit
+		}
+		if cols == 0 {
+			cols = p.HelpFlagsWidth
+		}
+	}
+	if limit <= 0 {
+		return flags.FlagUsagesWrapped(cols)
+	}
+
+	// Only the listed flags are rendered, so that the size of the help doesn't depend
+	// on the number of flags.
+	listed := flag.NewFlagSet(c.Name(), flag.ContinueOnError)
+	listed.SortFlags = false
+	count, more := 0, 0
+	flags.VisitAll(func(f *flag.Flag) {
+		if f.Hidden {
+			return
+		}
+		if count == limit {
+			more++
+			return
+		}
+		listed.AddFlag(f)
+		count++
+	})
+	usages := listed.FlagUsagesWrapped(cols)
+	if more > 0 {
+		usages += fmt.Sprintf("      ... and %d more flags\n", more)
+	}
+	return usages
+}
@@ -0,0 +1,61 @@
+package cobra
+
+import (
+	"fmt"
+	"io/ioutil"
+	"testing"
+)
+
+func TestHelpFlagsLimit(t *testing.T) {
+	rootCmd := &Command{Use: "root", Run: emptyRun, HelpFlagsLimit: 3}
+	for i := 0; i < 10; i++ {
+		rootCmd.PersistentFlags().Bool(fmt.Sprintf("flag%d", i), false, "a flag")
+	}
+	rootCmd.Flags().Bool("hidden", false, "a hidden flag")
+	rootCmd.Flags().MarkHidden("hidden")
+	childCmd := &Command{Use: "child", Run: emptyRun}
+	rootCmd.AddCommand(childCmd)
+
+	output, err := executeCommand(rootCmd, "child", "--help")
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	checkStringContains(t, output, "Global Flags:\n      --flag0   a flag\n      --flag1   a flag\n      --flag2   a flag\n      ... and 7 more flags\n")
+	checkStringOmits(t, output, "flag3")
+
+	output, err = executeCommand(rootCmd, "--help")
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	checkStringContains(t, output, "      ... and 8 more flags\n")
+	checkStringOmits(t, output, "hidden")
+}
+
+func TestHelpFlagsWidth(t *testing.T) {
+	rootCmd := &Command{Use: "root", Run: emptyRun, HelpFlagsWidth: 60}
+	rootCmd.Flags().String("name", "", "the name of the resource to create in the current namespace")
+
+	output, err := executeCommand(rootCmd, "--help")
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	checkStringContains(t, output, "      --name string   the name of the resource to\n                      create in the current namespace\n")
+}
+
+func BenchmarkHelpManyFlags(b *testing.B) {
+	for _, limit := range []int{0, 20} {
+		b.Run(fmt.Sprintf("limit=%d", limit), func(b *testing.B) {
+			c := &Command{Use: "root", Run: emptyRun, HelpFlagsLimit: limit}
+			for i := 0; i < 5000; i++ {
+				c.Flags().String(fmt.Sprintf("flag%d", i), "", "a flag")
+			}
+			c.SetOutput(ioutil.Discard)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if err := c.Help(); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package cobra
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	flag "github.com/spf13/pflag"
+)
+
+// Weights of the matches of a keyword in the fields of a command.
+const (
+	searchWeightName    = 8
+	searchWeightShort   = 4
+	searchWeightLong    = 2
+	searchWeightExample = 1
+	searchWeightFlags   = 1
+)
+
+// SearchCommands returns the available commands of the tree of c matching all the
+// keywords of query in their name, aliases, descriptions, examples or flag usages,
+// ranked by relevance. Matches are case insensitive.
+func (c *Command) SearchCommands(query string) []*Command {
+	keywords := strings.Fields(strings.ToLower(query))
+	if len(keywords) == 0 {
+		return nil
+	}
+	type match struct {
+		cmd   *Command
+		score int
+	}
+	var matches []match
+	var visit func(*Command)
+	visit = func(cmd *Command) {
+		if score := cmd.searchScore(keywords); score > 0 {
+			matches = append(matches, match{cmd, score})
+		}
+		for _, sub := range cmd.Commands() {
+			if sub.IsAvailableCommand() {
+				visit(sub)
+			}
+		}
+	}
+	visit(c)
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].score != matches[j].score {
+			return matches[i].score > matches[j].score
+		}
+		return matches[i].cmd.CommandPath() < matches[j].cmd.CommandPath()
+	})
+	cmds := make([]*Command, len(matches))
+	for i, m := range matches {
+		cmds[i] = m.cmd
+	}
+	return cmds
+}
+
+// searchScore returns the relevance of c for the keywords, or 0 if one of them
+// doesn't match.
+func (c *Command) searchScore(keywords []string) int {
+	var usages []string
+	c.LocalFlags().VisitAll(func(f *flag.Flag) {
+		if !f.Hidden {
+			usages = append(usages, f.Name, f.Usage)
+		}
+	})
+	fields := []struct {
+		text   string
+		weight int
+	}{
+		{strings.Join(append([]string{c.Name()}, c.Aliases...), " "), searchWeightName},
+		{c.Short, searchWeightShort},
+		{c.Long, searchWeightLong},
+		{c.Example, searchWeightExample},
+		{strings.Join(usages, " "), searchWeightFlags},
+	}
+
+	total := 0
+	for _, keyword := range keywords {
+		score := 0
+		for _, field := range fields {
+			score += strings.Count(strings.ToLower(field.text), keyword) * field.weight
+		}
+		if score == 0 {
+			return 0
+		}
+		total += score
+	}
+	return total
+}
+
+// printSearchResults writes to w the commands of the tree of c matching query.
+func (c *Command) printSearchResults(w io.Writer, query string) {
+	cmds := c.SearchCommands(query)
+	if len(cmds) == 0 {
+		fmt.Fprintf(w, "No command matches %q.\n", query)
+		return
+	}
+	width := 0
+	for _, cmd := range cmds {
+		if l := displayWidth(cmd.CommandPath()); l > width {
+			width = l
+		}
+	}
+	for _, cmd := range cmds {
+		fmt.Fprintln(w, strings.TrimRight("  "+rpad(cmd.CommandPath(), width)+" "+cmd.Short, " "))
+	}
+}
@@ -0,0 +1,68 @@
+package cobra
+
+import (
+	"reflect"
+	"testing"
+)
+
+func searchTestTree() *Command {
+	rootCmd := &Command{Use: "root", Run: emptyRun}
+	rootCmd.HelpCommandOptions.SearchFlag = true
+	configCmd := &Command{Use: "config", Short: "Manage the configuration", Run: emptyRun}
+	viewCmd := &Command{Use: "view", Short: "Display the configuration file", Run: emptyRun}
+	setCmd := &Command{Use: "set", Short: "Set a value", Long: "Set a value of the configuration.", Run: emptyRun}
+	logsCmd := &Command{Use: "logs", Short: "Print the logs", Run: emptyRun}
+	logsCmd.Flags().Bool("follow", false, "display new logs as they arrive")
+	hiddenCmd := &Command{Use: "dump", Short: "Display internals", Hidden: true, Run: emptyRun}
+	configCmd.AddCommand(viewCmd, setCmd)
+	rootCmd.AddCommand(configCmd, logsCmd, hiddenCmd)
+	return rootCmd
+}
+
+func commandPaths(cmds []*Command) []string {
+	var paths []string
+	for _, cmd := range cmds {
+		paths = append(paths, cmd.CommandPath())
+	}
+	return paths
+}
+
+func TestSearchCommands(t *testing.T) {
+	rootCmd := searchTestTree()
+
+	tests := []struct {
+		query    string
+		expected []string
+	}{
+		{"configuration", []string{"root config", "root config view", "root config set"}},
+		{"DISPLAY", []string{"root config view", "root logs"}},
+		{"display configuration", []string{"root config view"}},
+		{"nothing", nil},
+		{"", nil},
+	}
+	for _, tc := range tests {
+		got := commandPaths(rootCmd.SearchCommands(tc.query))
+		if !reflect.DeepEqual(got, tc.expected) {
+			t.Errorf("SearchCommands(%q) = %v, expected %v", tc.query, got, tc.expected)
+		}
+	}
+}
+
+func TestHelpSearchFlag(t *testing.T) {
+	rootCmd := searchTestTree()
+
+	output, err := executeCommand(rootCmd, "help", "--search", "logs")
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	expected := "  root logs Print the logs\n"
+	if output != expected {
+		t.Errorf("Expected %q, got %q", expected, output)
+	}
+
+	output, err = executeCommand(rootCmd, "help", "--search", "kubernetes")
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	checkStringContains(t, output, `No command matches "kubernetes".`)
+}
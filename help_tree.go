@@ -0,0 +1,124 @@
+package cobra
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	flag "github.com/spf13/pflag"
+)
+
+// HelpTree writes to w the tree of the available commands of c, with their short
+// description and their local flags, so that users can explore a large application
+// from a single output. It is printed by "help --all", see HelpCommandOptions.
+func (c *Command) HelpTree(w io.Writer) {
+	c.helpTree(w, "")
+}
+
+func (c *Command) helpTree(w io.Writer, indent string) {
+	line := indent + c.CommandPath()
+	if c.Short != "" {
+		line += " - " + c.Short
+	}
+	fmt.Fprintln(w, line)
+
+	flags := flag.NewFlagSet(c.Name(), flag.ContinueOnError)
+	c.DisplayFlags(c.LocalFlags()).VisitAll(func(f *flag.Flag) {
+		if f.Name != "help" {
+			flags.AddFlag(f)
+		}
+	})
+	if usages := flags.FlagUsages(); usages != "" {
+		for _, l := range strings.Split(strings.TrimRight(usages, "\n"), "\n") {
+			fmt.Fprintln(w, indent+"  "+l)
+		}
+	}
+
+	for _, sub := range c.Commands() {
+		if !sub.IsAvailableCommand() {
+			continue
+		}
+		sub.helpTree(w, indent+"  ")
+	}
+}
+
+// interactiveHelp browses the commands of the tree of cmd in a full-screen browser
+// reading keys from in and drawing on out. It is defined when cobra is built with the
+// cobra_tui build tag, which adds the --interactive flag to the help command.
+var interactiveHelp func(cmd *Command, in io.Reader, out io.Writer) error
+
+// CommandDescription is the machine-readable description of a command and of its
+// available subcommands, as returned by Describe.
+type CommandDescription struct {
+	Name     string                `json:"name"`
+	Path     string                `json:"path"`
+	UseLine  string                `json:"use_line"`
+	Aliases  []string              `json:"aliases,omitempty"`
+	Short    string                `json:"short,omitempty"`
+	Long     string                `json:"long,omitempty"`
+	Example  string                `json:"example,omitempty"`
+	Flags    []FlagDescription     `json:"flags,omitempty"`
+	Commands []*CommandDescription `json:"commands,omitempty"`
+}
+
+// FlagDescription is the machine-readable description of a flag of a command.
+type FlagDescription struct {
+	Name      string `json:"name"`
+	Shorthand string `json:"shorthand,omitempty"`
+	Type      string `json:"type"`
+	Default   string `json:"default,omitempty"`
+	Usage     string `json:"usage,omitempty"`
+	// Inherited is true for the persistent flags of the parents.
+	Inherited bool `json:"inherited,omitempty"`
+	Required  bool `json:"required,omitempty"`
+}
+
+// Describe returns the description of c and of its available subcommands, with their
+// visible flags, e.g. for documentation sites or help browsers.
+func (c *Command) Describe() *CommandDescription {
+	d := &CommandDescription{
+		Name:    c.Name(),
+		Path:    c.CommandPath(),
+		UseLine: c.UseLine(),
+		Aliases: c.Aliases,
+		Short:   c.Short,
+		Long:    c.Long,
+		Example: c.Example,
+	}
+	describeFlags := func(flags *flag.FlagSet, inherited bool) {
+		flags.VisitAll(func(f *flag.Flag) {
+			if f.Hidden || f.Name == "help" {
+				return
+			}
+			fd := FlagDescription{
+				Name:      f.Name,
+				Shorthand: f.Shorthand,
+				Type:      f.Value.Type(),
+				Usage:     f.Usage,
+				Inherited: inherited,
+				Required:  len(f.Annotations[BashCompOneRequiredFlag]) > 0,
+			}
+			if !defaultIsZeroValue(f) {
+				fd.Default = f.DefValue
+			}
+			d.Flags = append(d.Flags, fd)
+		})
+	}
+	describeFlags(c.DisplayFlags(c.LocalFlags()), false)
+	describeFlags(c.DisplayFlags(c.InheritedFlags()), true)
+	for _, sub := range c.Commands() {
+		if sub.IsAvailableCommand() {
+			d.Commands = append(d.Commands, sub.Describe())
+		}
+	}
+	return d
+}
+
+// WriteDescriptionJSON writes the description of c returned by Describe to w, as JSON.
+func (c *Command) WriteDescriptionJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+	return enc.Encode(c.Describe())
+}
@@ -0,0 +1,74 @@
+package cobra
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestHelpTree(t *testing.T) {
+	rootCmd := &Command{Use: "root", Short: "The root", Run: emptyRun}
+	rootCmd.HelpCommandOptions.AllFlag = true
+	rootCmd.PersistentFlags().Bool("verbose", false, "verbose output")
+	getCmd := &Command{Use: "get", Short: "Get things", Run: emptyRun}
+	getCmd.Flags().String("output", "", "output format")
+	listCmd := &Command{Use: "list", Short: "List things", Run: emptyRun}
+	hiddenCmd := &Command{Use: "secret", Short: "Hidden", Hidden: true, Run: emptyRun}
+	getCmd.AddCommand(listCmd)
+	rootCmd.AddCommand(getCmd, hiddenCmd)
+
+	output, err := executeCommand(rootCmd, "help", "--all")
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	checkStringContains(t, output, "root - The root\n        --verbose   verbose output\n")
+	checkStringContains(t, output, "  root get - Get things\n          --output string   output format\n")
+	checkStringContains(t, output, "    root get list - List things\n")
+	checkStringOmits(t, output, "secret")
+	checkStringOmits(t, output, "--help")
+
+	output, err = executeCommand(rootCmd, "help", "--all", "get")
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	checkStringContains(t, output, "root get - Get things\n")
+	checkStringOmits(t, output, "The root")
+}
+
+func TestHelpCommandWithoutAllFlag(t *testing.T) {
+	rootCmd := &Command{Use: "root", Run: emptyRun}
+	rootCmd.AddCommand(&Command{Use: "child", Run: emptyRun})
+
+	if _, err := executeCommand(rootCmd, "help", "--all"); err == nil {
+		t.Error("Expected an error for the --all flag of the help command")
+	}
+}
+
+func TestDescribe(t *testing.T) {
+	rootCmd := &Command{Use: "root", Short: "The root", Run: emptyRun}
+	rootCmd.PersistentFlags().Bool("verbose", false, "verbose output")
+	getCmd := &Command{Use: "get <name>", Aliases: []string{"g"}, Short: "Get things", Run: emptyRun}
+	getCmd.Flags().StringP("output", "o", "text", "output format")
+	getCmd.Flags().String("secret", "", "")
+	getCmd.Flags().MarkHidden("secret")
+	rootCmd.AddCommand(getCmd, &Command{Use: "hidden", Hidden: true, Run: emptyRun})
+
+	d := rootCmd.Describe()
+	if len(d.Commands) != 1 || d.Commands[0].Path != "root get" || d.Commands[0].Aliases[0] != "g" {
+		t.Fatalf("Unexpected subcommands %+v", d.Commands)
+	}
+	expected := []FlagDescription{
+		{Name: "output", Shorthand: "o", Type: "string", Default: "text", Usage: "output format"},
+		{Name: "verbose", Type: "bool", Usage: "verbose output", Inherited: true},
+	}
+	if got := d.Commands[0].Flags; !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected flags %+v, got %+v", expected, got)
+	}
+
+	buf := new(bytes.Buffer)
+	if err := rootCmd.WriteDescriptionJSON(buf); err != nil {
+		t.Fatal(err)
+	}
+	checkStringContains(t, buf.String(), `"use_line": "root get <name> [flags]"`)
+}
@@ -0,0 +1,280 @@
+// +build cobra_tui,!windows
+
+package cobra
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+func init() {
+	interactiveHelp = browseHelp
+}
+
+// helpBrowser is the state of the full-screen help browser.
+type helpBrowser struct {
+	// entries are the described commands, depth first.
+	entries []*CommandDescription
+	// query filters the entries by fuzzy matching, matches holds the matching ones.
+	query    string
+	matches  []*CommandDescription
+	selected int
+	// details shows the details of the selected command rather than the list.
+	details    bool
+	rows, cols int
+}
+
+func newHelpBrowser(root *CommandDescription, rows, cols int) *helpBrowser {
+	b := &helpBrowser{rows: rows, cols: cols}
+	var visit func(*CommandDescription)
+	visit = func(d *CommandDescription) {
+		b.entries = append(b.entries, d)
+		for _, sub := range d.Commands {
+			visit(sub)
+		}
+	}
+	visit(root)
+	b.filter()
+	return b
+}
+
+// filter updates the matches of the query, best first.
+func (b *helpBrowser) filter() {
+	type match struct {
+		d     *CommandDescription
+		score int
+	}
+	var matches []match
+	for _, d := range b.entries {
+		if score := fuzzyScore(b.query, d.Path+" "+d.Short); score > 0 || b.query == "" {
+			matches = append(matches, match{d, score})
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].score > matches[j].score
+	})
+	b.matches = b.matches[:0]
+	for _, m := range matches {
+		b.matches = append(b.matches, m.d)
+	}
+	b.selected = 0
+}
+
+// handleKey updates the browser for the key read from the terminal, and returns true
+// if the browser must be closed.
+func (b *helpBrowser) handleKey(key string) bool {
+	switch key {
+	case "\x03": // Ctrl-C
+		return true
+	case "\x1b":
+		if !b.details {
+			return true
+		}
+		b.details = false
+	case "\r", "\n":
+		b.details = !b.details && len(b.matches) > 0
+	case "\x1b[A", "\x10": // Up, Ctrl-P
+		if b.selected > 0 {
+			b.selected--
+		}
+	case "\x1b[B", "\x0e": // Down, Ctrl-N
+		if b.selected < len(b.matches)-1 {
+			b.selected++
+		}
+	case "\x7f", "\b":
+		if b.details || b.query == "" {
+			break
+		}
+		_, size := utf8.DecodeLastRuneInString(b.query)
+		b.query = b.query[:len(b.query)-size]
+		b.filter()
+	default:
+		r, size := utf8.DecodeRuneInString(key)
+		if b.details || size != len(key) || !unicode.IsPrint(r) {
+			break
+		}
+		b.query += key
+		b.filter()
+	}
+	return false
+}
+
+// render draws the browser on w.
+func (b *helpBrowser) render(w io.Writer) {
+	var lines []string
+	if b.details {
+		lines = append(lines, "\x1b[1m"+b.matches[b.selected].Path+"\x1b[0m  (Esc: back)", "")
+		lines = append(lines, describeLines(b.matches[b.selected])...)
+	} else {
+		lines = append(lines, "Search: "+b.query, fmt.Sprintf("%d/%d commands  (Up/Down: move, Enter: details, Esc: quit)", len(b.matches), len(b.entries)), "")
+		height := b.rows - len(lines)
+		offset := 0
+		if b.selected >= height {
+			offset = b.selected - height + 1
+		}
+		width := 0
+		for _, d := range b.matches {
+			if n := displayWidth(d.Path); n > width {
+				width = n
+			}
+		}
+		for i := offset; i < len(b.matches) && i < offset+height; i++ {
+			line := truncateWidth(rpad(b.matches[i].Path, width)+"  "+b.matches[i].Short, b.cols)
+			if i == b.selected {
+				line = "\x1b[7m" + rpad(line, b.cols) + "\x1b[0m"
+			}
+			lines = append(lines, line)
+		}
+	}
+	if len(lines) > b.rows {
+		lines = lines[:b.rows]
+	}
+	for i, line := range lines {
+		if !strings.HasPrefix(line, "\x1b[7m") {
+			lines[i] = truncateWidth(line, b.cols)
+		}
+	}
+	fmt.Fprint(w, "\x1b[H\x1b[2J"+strings.Join(lines, "\r\n"))
+}
+
+// describeLines returns the lines of the details of the command described by d.
+func describeLines(d *CommandDescription) []string {
+	lines := []string{"Usage: " + d.UseLine}
+	if len(d.Aliases) > 0 {
+		lines = append(lines, "Aliases: "+strings.Join(d.Aliases, ", "))
+	}
+	if text := strings.TrimSpace(d.Long); text != "" || d.Short != "" {
+		if text == "" {
+			text = d.Short
+		}
+		lines = append(lines, "")
+		lines = append(lines, strings.Split(text, "\n")...)
+	}
+	if d.Example != "" {
+		lines = append(lines, "", "Examples:")
+		lines = append(lines, strings.Split(d.Example, "\n")...)
+	}
+	if len(d.Flags) > 0 {
+		lines = append(lines, "", "Flags:")
+		for _, f := range d.Flags {
+			line := "      --" + f.Name
+			if f.Shorthand != "" {
+				line = "  -" + f.Shorthand + ", --" + f.Name
+			}
+			if f.Type != "bool" {
+				line += " " + f.Type
+			}
+			line += "   " + f.Usage
+			if f.Default != "" {
+				line += " (default " + f.Default + ")"
+			}
+			if f.Required {
+				line += " [required]"
+			}
+			if f.Inherited {
+				line += " [inherited]"
+			}
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// truncateWidth truncates s to width display columns.
+func truncateWidth(s string, width int) string {
+	if displayWidth(s) <= width {
+		return s
+	}
+	var b strings.Builder
+	w := 0
+	for _, r := range s {
+		rw := displayWidth(string(r))
+		if w+rw > width {
+			break
+		}
+		b.WriteRune(r)
+		w += rw
+	}
+	return b.String()
+}
+
+// fuzzyScore returns how well s matches pattern, whose runes must appear in s in order,
+// case insensitively, or 0. Consecutive runes and runes starting words score more.
+func fuzzyScore(pattern, s string) int {
+	pattern, s = strings.ToLower(pattern), strings.ToLower(s)
+	score, consecutive := 0, 0
+	prev := ' '
+	rest := []rune(pattern)
+	for _, r := range s {
+		if len(rest) == 0 {
+			break
+		}
+		if r != rest[0] {
+			consecutive = 0
+			prev = r
+			continue
+		}
+		rest = rest[1:]
+		consecutive++
+		score += 2 * consecutive
+		if prev == ' ' || prev == '-' || prev == '_' {
+			score += 2
+		}
+		prev = r
+	}
+	if len(rest) > 0 {
+		return 0
+	}
+	return score
+}
+
+// browseHelp browses the commands of the tree of cmd in a full-screen browser, with
+// the terminal in in raw mode.
+func browseHelp(cmd *Command, in io.Reader, out io.Writer) error {
+	f, ok := in.(*os.File)
+	if !ok || !isTerminal(f) {
+		return errors.New("the interactive help needs a terminal")
+	}
+	stty := func(args ...string) (string, error) {
+		c := exec.Command("stty", args...)
+		c.Stdin = f
+		b, err := c.Output()
+		return strings.TrimSpace(string(b)), err
+	}
+	state, err := stty("-g")
+	if err != nil {
+		return fmt.Errorf("the interactive help needs stty: %v", err)
+	}
+	if _, err := stty("raw", "-echo"); err != nil {
+		return err
+	}
+	defer stty(state)
+
+	rows, cols := 24, 80
+	if size, err := stty("size"); err == nil {
+		fmt.Sscan(size, &rows, &cols)
+	}
+	// The alternate screen keeps the content of the terminal.
+	fmt.Fprint(out, "\x1b[?1049h\x1b[?25l")
+	defer fmt.Fprint(out, "\x1b[?25h\x1b[?1049l")
+
+	b := newHelpBrowser(cmd.Describe(), rows, cols)
+	buf := make([]byte, 16)
+	for {
+		b.render(out)
+		n, err := f.Read(buf)
+		if err != nil {
+			return err
+		}
+		if b.handleKey(string(buf[:n])) {
+			return nil
+		}
+	}
+}
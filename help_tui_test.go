@@ -0,0 +1,75 @@
+// +build cobra_tui,!windows
+
+package cobra
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestFuzzyScore(t *testing.T) {
+	if fuzzyScore("dpl", "root deploy") == 0 {
+		t.Error("Expected dpl to match deploy")
+	}
+	if fuzzyScore("xyz", "root deploy") != 0 {
+		t.Error("Expected xyz not to match deploy")
+	}
+	if fuzzyScore("dep", "root deploy") <= fuzzyScore("dep", "root describe pods") {
+		t.Error("Expected a consecutive match to score more")
+	}
+}
+
+func TestHelpBrowser(t *testing.T) {
+	rootCmd := &Command{Use: "root", Short: "The root", Run: emptyRun}
+	deployCmd := &Command{Use: "deploy", Short: "Deploy a service", Example: "root deploy web", Run: emptyRun}
+	deployCmd.Flags().StringP("env", "e", "dev", "environment")
+	rootCmd.AddCommand(deployCmd, &Command{Use: "status", Short: "Show the status", Run: emptyRun})
+
+	b := newHelpBrowser(rootCmd.Describe(), 20, 60)
+	if len(b.matches) != 3 {
+		t.Fatalf("Expected 3 commands, got %d", len(b.matches))
+	}
+	for _, key := range []string{"d", "p", "l"} {
+		b.handleKey(key)
+	}
+	if len(b.matches) != 1 || b.matches[0].Path != "root deploy" {
+		t.Fatalf("Expected the deploy command to match, got %v", b.matches)
+	}
+	buf := new(bytes.Buffer)
+	b.render(buf)
+	checkStringContains(t, buf.String(), "Search: dpl")
+	checkStringContains(t, buf.String(), "root deploy  Deploy a service")
+
+	b.handleKey("\r")
+	buf.Reset()
+	b.render(buf)
+	checkStringContains(t, buf.String(), "Usage: root deploy [flags]")
+	checkStringContains(t, buf.String(), "  -e, --env string   environment (default dev)")
+	checkStringContains(t, buf.String(), "root deploy web")
+
+	if b.handleKey("\x1b") || b.details {
+		t.Error("Expected Esc to go back to the list")
+	}
+	b.handleKey("\x7f")
+	b.handleKey("\x7f")
+	b.handleKey("\x7f")
+	b.handleKey("\x1b[B")
+	if b.selected != 1 || len(b.matches) != 3 {
+		t.Errorf("Expected the second of 3 commands to be selected, got %d of %d", b.selected, len(b.matches))
+	}
+	if !b.handleKey("\x1b") {
+		t.Error("Expected Esc to close the browser")
+	}
+}
+
+func TestHelpInteractiveFlag(t *testing.T) {
+	rootCmd := &Command{Use: "root", Run: emptyRun}
+	rootCmd.AddCommand(&Command{Use: "child", Run: emptyRun})
+	rootCmd.SetIn(new(bytes.Buffer))
+
+	output, err := executeCommand(rootCmd, "help", "--interactive")
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	checkStringContains(t, output, "the interactive help needs a terminal")
+}
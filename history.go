@@ -0,0 +1,166 @@
+package cobra
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/pflag"
+)
+
+// historyAnnotation is the annotation of the command added by AddHistoryCommand, whose
+// first argument is completed with the previous invocations by bash completion.
+const historyAnnotation = "cobra_annotation_history"
+
+// defaultHistorySize is the number of invocations remembered when AddHistoryCommand is
+// given no size.
+const defaultHistorySize = 1000
+
+// AddHistoryCommand makes the successful executions of the subtree of c be remembered
+// in a per-user history file, under the user cache directory, keeping the last size
+// distinct invocations, and adds the "history" command to c. The values of sensitive
+// flags are redacted. "history" lists the invocations, "history <number>" executes one
+// again, and "history <invocation>" executes the given invocation, which bash completion
+// offers from the history.
+func (c *Command) AddHistoryCommand(size int) {
+	if size <= 0 {
+		size = defaultHistorySize
+	}
+	c.historySize = size
+	c.AddCommand(&Command{
+		Use:                "history [number | invocation]",
+		Short:              "List or execute again the previous invocations",
+		DisableFlagParsing: true,
+		Annotations:        map[string]string{historyAnnotation: "true"},
+		RunE: func(cmd *Command, args []string) error {
+			if len(args) == 1 && (args[0] == "-h" || args[0] == "--help") {
+				return pflag.ErrHelp
+			}
+			if len(args) == 0 {
+				for i, entry := range cmd.History() {
+					fmt.Fprintf(cmd.OutOrStdout(), "%5d  %s %s\n", i+1, cmd.Root().Name(), entry)
+				}
+				return nil
+			}
+			if len(args) == 1 {
+				if n, err := strconv.Atoi(args[0]); err == nil {
+					history := cmd.History()
+					if n < 1 || n > len(history) {
+						return fmt.Errorf("no invocation %d in the history", n)
+					}
+					if args, err = splitWords(history[n-1]); err != nil {
+						return err
+					}
+					for _, arg := range args {
+						if strings.Contains(arg, RedactedValue) {
+							return fmt.Errorf("invocation %d has redacted values, type it again", n)
+						}
+					}
+					fmt.Fprintf(cmd.ErrOrStderr(), "%s %s\n", cmd.Root().Name(), history[n-1])
+				}
+			}
+			return cmd.executeAgain(args)
+		},
+	})
+}
+
+// executeAgain executes the root command of c with args, from the history command c.
+func (c *Command) executeAgain(args []string) error {
+	root := c.Root()
+	saved := root.args
+	defer func() { root.args = saved }()
+	root.SetArgs(args)
+	if _, err := root.ExecuteC(); err != nil {
+		// The error has been reported by the execution.
+		c.SilenceErrors, c.SilenceUsage = true, true
+		return err
+	}
+	return nil
+}
+
+// History returns the invocations remembered since AddHistoryCommand has been called,
+// oldest first, as the shell quoted arguments given to the root command.
+func (c *Command) History() []string {
+	path, err := c.historyPath()
+	if err != nil {
+		return nil
+	}
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	var entries []string
+	for _, entry := range strings.Split(string(b), "\n") {
+		if entry != "" {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+func (c *Command) historyPath() (string, error) {
+	dir, err := userCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, c.Root().Name(), "history"), nil
+}
+
+// findHistorySize returns the size given to AddHistoryCommand by c or a parent, or 0.
+func (c *Command) findHistorySize() int {
+	for p := c; p != nil; p = p.parent {
+		if p.historySize > 0 {
+			return p.historySize
+		}
+	}
+	return 0
+}
+
+// recordHistory remembers the invocation of c with args, the arguments given to the
+// root command. Recording is best effort: errors are ignored.
+func (c *Command) recordHistory(args []string) {
+	size := c.findHistorySize()
+	if size == 0 || len(args) == 0 || c.Annotations[historyAnnotation] != "" {
+		return
+	}
+	words := c.redactSensitiveArgs(args)
+	for i, word := range words {
+		words[i] = quoteHistoryWord(word)
+	}
+	entry := strings.Join(words, " ")
+
+	var entries []string
+	for _, e := range c.History() {
+		if e != entry {
+			entries = append(entries, e)
+		}
+	}
+	entries = append(entries, entry)
+	if len(entries) > size {
+		entries = entries[len(entries)-size:]
+	}
+	path, err := c.historyPath()
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return
+	}
+	ioutil.WriteFile(path, []byte(strings.Join(entries, "\n")+"\n"), 0600)
+}
+
+// quoteHistoryWord quotes s for bash and zsh, unless it needs no quoting.
+func quoteHistoryWord(s string) string {
+	if s == "" {
+		return "''"
+	}
+	for _, r := range s {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("-_=+.,:/@%", r)) {
+			return shellSingleQuote(s)
+		}
+	}
+	return s
+}
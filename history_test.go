@@ -0,0 +1,104 @@
+package cobra
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestHistory(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cobra-history")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defer func(f func() (string, error)) { userCacheDir = f }(userCacheDir)
+	userCacheDir = func() (string, error) { return dir, nil }
+
+	var deployed []string
+	rootCmd := &Command{Use: "root", Run: emptyRun}
+	deployCmd := &Command{
+		Use: "deploy",
+		Run: func(cmd *Command, args []string) {
+			env, _ := cmd.Flags().GetString("env")
+			deployed = append(deployed, env)
+		},
+	}
+	deployCmd.Flags().String("env", "", "environment")
+	deployCmd.Flags().String("token", "", "token")
+	if err := deployCmd.MarkFlagSensitive("token"); err != nil {
+		t.Fatal(err)
+	}
+	rootCmd.AddCommand(deployCmd)
+	rootCmd.AddHistoryCommand(2)
+
+	invocations := [][]string{
+		{"deploy", "--env", "dev"},
+		{"deploy", "--env", "my prod"},
+		{"deploy", "--env", "dev"},
+		{"deploy", "--env", "test", "--token", "test"},
+	}
+	for _, args := range invocations {
+		if _, err := executeCommand(rootCmd, args...); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	if _, err := executeCommand(rootCmd, "deploy", "--unknown"); err == nil {
+		t.Fatal("Expected an error")
+	}
+
+	expected := []string{"deploy --env dev", "deploy --env test --token '<redacted>'"}
+	if got := rootCmd.History(); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected history %q, got %q", expected, got)
+	}
+
+	output, err := executeCommand(rootCmd, "history")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	checkStringContains(t, output, "    1  root deploy --env dev\n    2  root deploy --env test --token '<redacted>'\n")
+
+	deployed = nil
+	if _, err := executeCommand(rootCmd, "history", "1"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := executeCommand(rootCmd, "history", "deploy", "--env", "my prod"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(deployed, []string{"dev", "my prod"}) {
+		t.Errorf("Expected the invocations to be executed again, got %v", deployed)
+	}
+	expected = []string{"deploy --env dev", "deploy --env 'my prod'"}
+	if got := rootCmd.History(); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected history %q, got %q", expected, got)
+	}
+
+	if _, err := executeCommand(rootCmd, "history", "3"); err == nil {
+		t.Error("Expected an error for a missing invocation")
+	}
+
+	buf := new(bytes.Buffer)
+	if err := rootCmd.GenBashCompletion(buf); err != nil {
+		t.Fatal(err)
+	}
+	check(t, buf.String(), "    last_command=\"root_history\"\n")
+	check(t, buf.String(), "    complete_history=1\n")
+}
+
+func TestQuoteHistoryWord(t *testing.T) {
+	args := []string{"deploy", "--msg", "it's done", "", "a=b"}
+	words := make([]string, len(args))
+	for i, arg := range args {
+		words[i] = quoteHistoryWord(arg)
+	}
+	got, err := splitWords(strings.Join(words, " "))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, args) {
+		t.Errorf("Expected %q, got %q", args, got)
+	}
+}
@@ -0,0 +1,30 @@
+package cobra
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// GenHomebrewCompletionFiles generates the completion files of the root command in dir,
+// usually shipped with the release archives: <name>.bash for bash and _<name> for zsh.
+// HomebrewCompletionStanza returns the lines of a Homebrew formula installing them.
+func (c *Command) GenHomebrewCompletionFiles(dir string) error {
+	name := c.Root().Name()
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	if err := c.GenBashCompletionFile(filepath.Join(dir, name+".bash")); err != nil {
+		return err
+	}
+	return c.GenZshCompletionAutoloadFile(filepath.Join(dir, "_"+name))
+}
+
+// HomebrewCompletionStanza returns the lines of the install method of a Homebrew
+// formula installing the completion files generated by GenHomebrewCompletionFiles,
+// dir being their directory relative to the root of the archive.
+func (c *Command) HomebrewCompletionStanza(dir string) string {
+	name := c.Root().Name()
+	return fmt.Sprintf("    bash_completion.install %q => %q\n", filepath.ToSlash(filepath.Join(dir, name+".bash")), name) +
+		fmt.Sprintf("    zsh_completion.install %q\n", filepath.ToSlash(filepath.Join(dir, "_"+name)))
+}
@@ -0,0 +1,37 @@
+package cobra
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestGenHomebrewCompletionFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cobra-homebrew")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	rootCmd := &Command{Use: "mycli", Run: emptyRun}
+	childCmd := &Command{Use: "child", Run: emptyRun}
+	rootCmd.AddCommand(childCmd)
+
+	completions := filepath.Join(dir, "completions")
+	if err := childCmd.GenHomebrewCompletionFiles(completions); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"mycli.bash", "_mycli"} {
+		if _, err := os.Stat(filepath.Join(completions, name)); err != nil {
+			t.Errorf("Expected completion file %s: %v", name, err)
+		}
+	}
+
+	expected := `    bash_completion.install "completions/mycli.bash" => "mycli"
+    zsh_completion.install "completions/_mycli"
+`
+	if got := childCmd.HomebrewCompletionStanza("completions"); got != expected {
+		t.Errorf("Expected stanza:\n%s\ngot:\n%s", expected, got)
+	}
+}
@@ -0,0 +1,128 @@
+package manifest
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+	"text/template"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// argsWord is the word of a command line replaced by all the arguments.
+const argsWord = "{{.Args}}"
+
+// Exec is the description of the external command line run by a command of a manifest.
+// Its words and the values of its environment variables are templates, executed with
+// the arguments of the command as .Args and the values of its flags as .Flags, e.g.
+// "--namespace={{.Flags.namespace}}" or "{{index .Args 0}}". The word "{{.Args}}" is
+// replaced by all the arguments, as separate words. The env template function returns
+// an environment variable, e.g. {{env "HOME"}}.
+type Exec struct {
+	// Command is the command line, starting with the name or path of the program.
+	Command []string `yaml:"command"`
+	// Env are the environment variables added to the environment of the program.
+	Env map[string]string `yaml:"env,omitempty"`
+	// CleanEnv runs the program with Env only, rather than with the environment of the
+	// CLI too.
+	CleanEnv bool `yaml:"cleanEnv,omitempty"`
+	// Dir is the working directory of the program, the current one by default.
+	Dir string `yaml:"dir,omitempty"`
+}
+
+// execData is the data of the templates of an Exec.
+type execData struct {
+	Args  []string
+	Flags map[string]string
+}
+
+// runE returns the RunE of a command running e. The templates are parsed beforehand
+// too, so that their errors are reported by Load.
+func (e *Exec) runE() (func(cmd *cobra.Command, args []string) error, error) {
+	if len(e.Command) == 0 {
+		return nil, fmt.Errorf("missing command of exec")
+	}
+	texts := append([]string(nil), e.Command...)
+	for _, name := range e.sortedEnv() {
+		texts = append(texts, e.Env[name])
+	}
+	for _, text := range texts {
+		if _, err := parseTemplate(text, os.Getenv); err != nil {
+			return nil, err
+		}
+	}
+
+	return func(cmd *cobra.Command, args []string) error {
+		data := execData{Args: args, Flags: make(map[string]string)}
+		cmd.Flags().VisitAll(func(f *pflag.Flag) {
+			data.Flags[f.Name] = f.Value.String()
+		})
+		var argv []string
+		for _, word := range e.Command {
+			if word == argsWord {
+				argv = append(argv, args...)
+				continue
+			}
+			word, err := executeTemplate(word, cmd, data)
+			if err != nil {
+				return err
+			}
+			argv = append(argv, word)
+		}
+		if len(argv) == 0 {
+			return fmt.Errorf("empty command line")
+		}
+
+		c := exec.CommandContext(cmd.Context(), argv[0], argv[1:]...)
+		c.Env = []string{}
+		if !e.CleanEnv {
+			c.Env = cmd.Environ()
+		}
+		for _, name := range e.sortedEnv() {
+			value, err := executeTemplate(e.Env[name], cmd, data)
+			if err != nil {
+				return err
+			}
+			c.Env = append(c.Env, name+"="+value)
+		}
+		c.Dir = e.Dir
+		c.Stdin = cmd.InOrStdin()
+		c.Stdout = cmd.OutOrStdout()
+		c.Stderr = cmd.ErrOrStderr()
+		// The exit code of the program is passed through by cobra.Command.ExecuteResult.
+		return c.Run()
+	}, nil
+}
+
+// sortedEnv returns the names of the environment variables of e, in order.
+func (e *Exec) sortedEnv() []string {
+	names := make([]string, 0, len(e.Env))
+	for name := range e.Env {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func parseTemplate(text string, getenv func(string) string) (*template.Template, error) {
+	return template.New("exec").Option("missingkey=error").Funcs(template.FuncMap{
+		"env": getenv,
+	}).Parse(text)
+}
+
+// executeTemplate executes the template text for cmd, whose env function looks up the
+// environment of cmd.
+func executeTemplate(text string, cmd *cobra.Command, data execData) (string, error) {
+	tmpl, err := parseTemplate(text, cmd.Getenv)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,103 @@
+package manifest
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+const execManifest = `
+use: greet <name>...
+exec:
+  command: [sh, -c, 'echo "$GREETING, $*{{.Flags.punctuation}}"; exit {{.Flags.code}}', greet, '{{.Args}}']
+  env:
+    GREETING: '{{.Flags.greeting}}'
+  cleanEnv: true
+flags:
+  - name: greeting
+    default: Hello
+  - name: punctuation
+    default: '!'
+  - name: code
+    type: int
+`
+
+func TestExec(t *testing.T) {
+	greetCmd, err := Load(strings.NewReader(execManifest), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := new(bytes.Buffer)
+	greetCmd.SetOutput(out)
+	greetCmd.SetArgs([]string{"Jane", "Doe", "--greeting", "Hi"})
+	if err := greetCmd.Execute(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if got := out.String(); got != "Hi, Jane Doe!\n" {
+		t.Errorf("Unexpected output %q", got)
+	}
+
+	greetCmd, err = Load(strings.NewReader(execManifest), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	greetCmd.SetOutput(new(bytes.Buffer))
+	greetCmd.SetArgs([]string{"Jane", "--code", "3"})
+	if r := greetCmd.ExecuteResult(); r.ExitCode != 3 {
+		t.Errorf("Expected the exit code of the program, got %d (error: %v)", r.ExitCode, r.Err)
+	}
+}
+
+func TestExecEnv(t *testing.T) {
+	os.Setenv("MANIFEST_TEST_VAR", "inherited")
+	defer os.Unsetenv("MANIFEST_TEST_VAR")
+
+	cmd, err := Load(strings.NewReader(`
+use: show
+exec:
+  command: [sh, -c, 'echo "$MANIFEST_TEST_VAR {{env "MANIFEST_TEST_VAR"}}"']
+`), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := new(bytes.Buffer)
+	cmd.SetOutput(out)
+	cmd.SetArgs([]string{})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if got := out.String(); got != "inherited inherited\n" {
+		t.Errorf("Unexpected output %q", got)
+	}
+
+	// The program gets the environment given to ExecuteWithEnv, like the templates.
+	out.Reset()
+	env := map[string]string{"MANIFEST_TEST_VAR": "given"}
+	if err := cmd.ExecuteWithEnv(context.Background(), []string{}, env); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if got := out.String(); got != "given given\n" {
+		t.Errorf("Unexpected output %q", got)
+	}
+}
+
+func TestExecErrors(t *testing.T) {
+	tests := []struct {
+		manifest string
+		err      string
+	}{
+		{"use: a\nexec:\n  command: []\n", `exec of command "a": missing command of exec`},
+		{"use: a\nexec:\n  command: ['{{.Args']\n", `exec of command "a": template: exec:1: unclosed action`},
+		{"use: a\nrun: x\nexec:\n  command: [true]\n", `command "a" has both run and exec`},
+	}
+	for _, tc := range tests {
+		_, err := Load(strings.NewReader(tc.manifest), Handlers{"x": func(*cobra.Command, []string) error { return nil }})
+		if err == nil || !strings.Contains(err.Error(), tc.err) {
+			t.Errorf("Expected an error containing %q, got %v", tc.err, err)
+		}
+	}
+}
@@ -0,0 +1,284 @@
+// Package manifest builds cobra command trees from YAML manifests, so that commands
+// can be added to a CLI without recompiling it.
+package manifest
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"gopkg.in/yaml.v2"
+)
+
+// Command is the description of a command in a manifest.
+type Command struct {
+	Use     string   `yaml:"use"`
+	Aliases []string `yaml:"aliases,omitempty"`
+	Short   string   `yaml:"short,omitempty"`
+	Long    string   `yaml:"long,omitempty"`
+	Example string   `yaml:"example,omitempty"`
+	Hidden  bool     `yaml:"hidden,omitempty"`
+	// Flags are the flags of the command.
+	Flags []Flag `yaml:"flags,omitempty"`
+	// Run is the name of the handler running the command, among the handlers given to
+	// Load. Commands without Run nor Exec only group their subcommands.
+	Run string `yaml:"run,omitempty"`
+	// Exec is the external command line run by the command, rather than a handler.
+	Exec *Exec `yaml:"exec,omitempty"`
+	// Commands are the subcommands of the command.
+	Commands []Command `yaml:"commands,omitempty"`
+}
+
+// Flag is the description of a flag in a manifest.
+type Flag struct {
+	Name      string `yaml:"name"`
+	Shorthand string `yaml:"shorthand,omitempty"`
+	Usage     string `yaml:"usage,omitempty"`
+	// Type is the type of the flag, one of the names returned by the Type method of
+	// the values of pflag: string, bool, int, int64, uint, float64, duration,
+	// stringSlice, stringArray, intSlice or stringToString. It is string by default.
+	Type string `yaml:"type,omitempty"`
+	// Default is the default value of the flag, a scalar or, for the slices, a list.
+	Default interface{} `yaml:"default,omitempty"`
+	// Persistent makes the flag available to the subcommands.
+	Persistent bool `yaml:"persistent,omitempty"`
+	Required   bool `yaml:"required,omitempty"`
+	Hidden     bool `yaml:"hidden,omitempty"`
+}
+
+// Handlers are the functions running the commands of a manifest, by name.
+type Handlers map[string]func(cmd *cobra.Command, args []string) error
+
+// LoadFile reads the manifest in filename and returns the command tree it describes.
+// See Load.
+func LoadFile(filename string, handlers Handlers) (*cobra.Command, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return Load(f, handlers)
+}
+
+// Load reads a manifest from r and returns the command tree it describes, whose
+// commands are run by the named handlers or by external programs. The tree is usually added to the root
+// command of the CLI with AddCommand.
+func Load(r io.Reader, handlers Handlers) (*cobra.Command, error) {
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	var m Command
+	if err := yaml.UnmarshalStrict(b, &m); err != nil {
+		return nil, fmt.Errorf("invalid manifest: %v", err)
+	}
+	return m.Build(handlers)
+}
+
+// Build returns the command tree described by m, whose commands are run by the named
+// handlers. The errors name the command of the manifest they come from by its path,
+// e.g. "ops restart".
+func (m *Command) Build(handlers Handlers) (*cobra.Command, error) {
+	return m.build(handlers, "", nil)
+}
+
+// build returns the command tree described by m, a subcommand of the command at
+// parentPath, whose persistent flags, and those of its parents, have the shorthands
+// of inherited.
+func (m *Command) build(handlers Handlers, parentPath string, inherited map[string]string) (*cobra.Command, error) {
+	if m.Use == "" {
+		if parentPath != "" {
+			return nil, fmt.Errorf("missing use of a subcommand of %q", parentPath)
+		}
+		return nil, fmt.Errorf("missing use of a command")
+	}
+	cmd := &cobra.Command{
+		Use:     m.Use,
+		Aliases: m.Aliases,
+		Short:   m.Short,
+		Long:    m.Long,
+		Example: m.Example,
+		Hidden:  m.Hidden,
+	}
+	path := cmd.Name()
+	if parentPath != "" {
+		path = parentPath + " " + path
+	}
+	if m.Run != "" {
+		handler, ok := handlers[m.Run]
+		if !ok {
+			return nil, fmt.Errorf("unknown handler %q of command %q", m.Run, path)
+		}
+		cmd.RunE = handler
+	}
+	if m.Exec != nil {
+		if m.Run != "" {
+			return nil, fmt.Errorf("command %q has both run and exec", path)
+		}
+		runE, err := m.Exec.runE()
+		if err != nil {
+			return nil, fmt.Errorf("exec of command %q: %v", path, err)
+		}
+		cmd.RunE = runE
+		// The errors of the program are run time errors, its usage is not the one of
+		// the command.
+		cmd.SilenceUsage = true
+	}
+	for _, f := range m.Flags {
+		if err := f.add(cmd, inherited); err != nil {
+			return nil, fmt.Errorf("flag %q of command %q: %v", f.Name, path, err)
+		}
+	}
+	shorthands := make(map[string]string, len(inherited))
+	for shorthand, name := range inherited {
+		shorthands[shorthand] = name
+	}
+	cmd.PersistentFlags().VisitAll(func(f *pflag.Flag) {
+		if f.Shorthand != "" {
+			shorthands[f.Shorthand] = f.Name
+		}
+	})
+	for i := range m.Commands {
+		sub, err := m.Commands[i].build(handlers, path, shorthands)
+		if err != nil {
+			return nil, err
+		}
+		cmd.AddCommand(sub)
+	}
+	return cmd, nil
+}
+
+// add defines the flag f in the flags of cmd, whose parents have persistent flags with
+// the shorthands of inherited. pflag panics on the flags it can't define, which are
+// reported as errors instead.
+func (f *Flag) add(cmd *cobra.Command, inherited map[string]string) error {
+	if f.Name == "" {
+		return fmt.Errorf("missing name")
+	}
+	if cmd.Flags().Lookup(f.Name) != nil || cmd.PersistentFlags().Lookup(f.Name) != nil {
+		return fmt.Errorf("defined twice")
+	}
+	if f.Shorthand != "" {
+		if len(f.Shorthand) > 1 {
+			return fmt.Errorf("shorthand %q is more than one ASCII character", f.Shorthand)
+		}
+		for _, flags := range []*pflag.FlagSet{cmd.Flags(), cmd.PersistentFlags()} {
+			if other := flags.ShorthandLookup(f.Shorthand); other != nil {
+				return fmt.Errorf("shorthand %q is already used by flag %q", f.Shorthand, other.Name)
+			}
+		}
+		if other, ok := inherited[f.Shorthand]; ok && other != f.Name {
+			return fmt.Errorf("shorthand %q is already used by flag %q of a parent", f.Shorthand, other)
+		}
+	}
+	flags := cmd.Flags()
+	if f.Persistent {
+		flags = cmd.PersistentFlags()
+	}
+	if err := f.define(flags); err != nil {
+		return err
+	}
+	flags.Lookup(f.Name).Hidden = f.Hidden
+	if f.Required {
+		return cobra.MarkFlagRequired(flags, f.Name)
+	}
+	return nil
+}
+
+// define defines the flag f in flags, with its default value.
+func (f *Flag) define(flags *pflag.FlagSet) error {
+	def := f.Default
+	if def == nil {
+		def = ""
+	}
+	var list []string
+	switch v := def.(type) {
+	case []interface{}:
+		for _, item := range v {
+			list = append(list, fmt.Sprint(item))
+		}
+	case map[interface{}]interface{}:
+	default:
+		if s := fmt.Sprint(v); s != "" {
+			list = []string{s}
+		}
+	}
+	scalar := strings.Join(list, ",")
+
+	var err error
+	switch f.Type {
+	case "", "string":
+		flags.StringP(f.Name, f.Shorthand, scalar, f.Usage)
+	case "bool":
+		var b bool
+		if scalar != "" {
+			b, err = strconv.ParseBool(scalar)
+		}
+		flags.BoolP(f.Name, f.Shorthand, b, f.Usage)
+	case "int":
+		var n int
+		if scalar != "" {
+			n, err = strconv.Atoi(scalar)
+		}
+		flags.IntP(f.Name, f.Shorthand, n, f.Usage)
+	case "int64":
+		var n int64
+		if scalar != "" {
+			n, err = strconv.ParseInt(scalar, 0, 64)
+		}
+		flags.Int64P(f.Name, f.Shorthand, n, f.Usage)
+	case "uint":
+		var n uint64
+		if scalar != "" {
+			n, err = strconv.ParseUint(scalar, 0, 0)
+		}
+		flags.UintP(f.Name, f.Shorthand, uint(n), f.Usage)
+	case "float64":
+		var x float64
+		if scalar != "" {
+			x, err = strconv.ParseFloat(scalar, 64)
+		}
+		flags.Float64P(f.Name, f.Shorthand, x, f.Usage)
+	case "duration":
+		var d time.Duration
+		if scalar != "" {
+			d, err = time.ParseDuration(scalar)
+		}
+		flags.DurationP(f.Name, f.Shorthand, d, f.Usage)
+	case "stringSlice":
+		flags.StringSliceP(f.Name, f.Shorthand, list, f.Usage)
+	case "stringArray":
+		flags.StringArrayP(f.Name, f.Shorthand, list, f.Usage)
+	case "intSlice":
+		ints := make([]int, len(list))
+		for i, item := range list {
+			if ints[i], err = strconv.Atoi(item); err != nil {
+				break
+			}
+		}
+		flags.IntSliceP(f.Name, f.Shorthand, ints, f.Usage)
+	case "stringToString":
+		m := make(map[string]string)
+		if v, ok := def.(map[interface{}]interface{}); ok {
+			for k, item := range v {
+				m[fmt.Sprint(k)] = fmt.Sprint(item)
+			}
+		} else if scalar != "" {
+			err = fmt.Errorf("not a map")
+		}
+		flags.StringToStringP(f.Name, f.Shorthand, m, f.Usage)
+	default:
+		return fmt.Errorf("unknown type %q", f.Type)
+	}
+	if err != nil {
+		return fmt.Errorf("invalid default %v: %v", f.Default, err)
+	}
+	return nil
+}
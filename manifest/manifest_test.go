@@ -0,0 +1,109 @@
+package manifest
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+const opsManifest = `
+use: ops
+short: Operations of the platform
+flags:
+  - name: region
+    persistent: true
+    default: ca-east
+commands:
+  - use: restart <service>
+    aliases: [rs]
+    short: Restart a service
+    run: restart
+    flags:
+      - name: timeout
+        shorthand: t
+        type: duration
+        default: 30s
+      - name: replicas
+        type: intSlice
+        default: [1, 2]
+      - name: force
+        type: bool
+      - name: reason
+        required: true
+`
+
+func TestLoad(t *testing.T) {
+	var got []string
+	handlers := Handlers{
+		"restart": func(cmd *cobra.Command, args []string) error {
+			region, _ := cmd.Flags().GetString("region")
+			timeout, _ := cmd.Flags().GetDuration("timeout")
+			replicas, _ := cmd.Flags().GetIntSlice("replicas")
+			force, _ := cmd.Flags().GetBool("force")
+			got = append(got, args[0], region, timeout.String(), fmt.Sprint(replicas))
+			if force {
+				got = append(got, "force")
+			}
+			return nil
+		},
+	}
+	opsCmd, err := Load(strings.NewReader(opsManifest), handlers)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rootCmd := &cobra.Command{Use: "root"}
+	rootCmd.AddCommand(opsCmd)
+	rootCmd.SetOutput(new(bytes.Buffer))
+
+	rootCmd.SetArgs([]string{"ops", "rs", "web", "-t", "1m", "--force", "--reason", "deploy"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := []string{"web", "ca-east", time.Minute.String(), "[1 2]", "force"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %q, got %q", expected, got)
+	}
+
+	// The flags of the first execution are still set.
+	opsCmd, err = Load(strings.NewReader(opsManifest), handlers)
+	if err != nil {
+		t.Fatal(err)
+	}
+	opsCmd.SetOutput(new(bytes.Buffer))
+	opsCmd.SetArgs([]string{"restart", "web"})
+	if err := opsCmd.Execute(); err == nil || !strings.Contains(err.Error(), `required flag(s) "reason" not set`) {
+		t.Errorf("Expected the reason flag to be required, got %v", err)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := []struct {
+		manifest string
+		err      string
+	}{
+		{"use: ops\nrun: missing\n", `unknown handler "missing" of command "ops"`},
+		{"use: ops\nflags:\n  - name: n\n    type: integer\n", `flag "n" of command "ops": unknown type "integer"`},
+		{"use: ops\nflags:\n  - name: n\n    type: int\n    default: ten\n", `flag "n" of command "ops": invalid default ten`},
+		{"use: ops\nshrot: typo\n", "invalid manifest"},
+		{"short: no use\n", "missing use of a command"},
+		{"use: ops\ncommands:\n  - short: no use\n", `missing use of a subcommand of "ops"`},
+		{"use: ops\nflags:\n  - name: n\n  - name: n\n    persistent: true\n", `flag "n" of command "ops": defined twice`},
+		{"use: ops\nflags:\n  - name: n\n    shorthand: nn\n", `flag "n" of command "ops": shorthand "nn" is more than one ASCII character`},
+		{"use: ops\nflags:\n  - name: a\n    shorthand: x\n  - name: b\n    shorthand: x\n", `flag "b" of command "ops": shorthand "x" is already used by flag "a"`},
+		{
+			"use: ops\nflags:\n  - name: region\n    shorthand: r\n    persistent: true\ncommands:\n  - use: restart\n    flags:\n      - name: replicas\n        shorthand: r\n",
+			`flag "replicas" of command "ops restart": shorthand "r" is already used by flag "region" of a parent`,
+		},
+	}
+	for _, tc := range tests {
+		_, err := Load(strings.NewReader(tc.manifest), nil)
+		if err == nil || !strings.Contains(err.Error(), tc.err) {
+			t.Errorf("Expected an error containing %q for %q, got %v", tc.err, tc.manifest, err)
+		}
+	}
+}
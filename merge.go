@@ -0,0 +1,203 @@
+package cobra
+
+import (
+	"fmt"
+	"reflect"
+
+	flag "github.com/spf13/pflag"
+)
+
+// CollisionPolicy tells Merge how to resolve a grafted command colliding with an
+// existing command.
+type CollisionPolicy int
+
+const (
+	// MergeError makes Merge return an error on collisions, leaving both trees unchanged.
+	MergeError CollisionPolicy = iota
+	// MergeSkip keeps the existing command and drops the grafted one.
+	MergeSkip
+	// MergeReplace removes the existing command in favor of the grafted one.
+	MergeReplace
+)
+
+// MergeOptions configures Merge.
+type MergeOptions struct {
+	// Prefix is prepended to the names and aliases of the grafted commands.
+	Prefix string
+	// OnCollision resolves the grafted commands whose name or alias is already
+	// used by a command of the destination.
+	OnCollision CollisionPolicy
+	// FlagNamespace is prepended to the long names of the persistent flags of the
+	// source root, whose shorthands are dropped. E.g. with "team-", --verbose becomes
+	// --team-verbose. Flag values are shared, so variables bound to the flags still
+	// receive their values.
+	FlagNamespace string
+}
+
+// Merge grafts the subcommands of src into dst, so that several CLIs can be composed
+// into one binary. The persistent flags and the persistent hooks of src are carried over
+// to each grafted command, as src is no longer their parent. src must not have been
+// executed, and is left without subcommands.
+func Merge(dst, src *Command, opts MergeOptions) error {
+	var grafted, replaced []*Command
+	for _, sub := range src.commands {
+		if sub == src.helpCommand {
+			continue
+		}
+		existing := mergeCollision(dst, sub, opts.Prefix)
+		if existing != nil {
+			switch opts.OnCollision {
+			case MergeSkip:
+				continue
+			case MergeReplace:
+				replaced = append(replaced, existing)
+			default:
+				return fmt.Errorf("command %q of %q collides with command %q of %q",
+					opts.Prefix+sub.Name(), src.CommandPath(), existing.Name(), dst.CommandPath())
+			}
+		}
+		grafted = append(grafted, sub)
+	}
+
+	var flags []*flag.Flag
+	var err error
+	src.PersistentFlags().VisitAll(func(f *flag.Flag) {
+		nf := namespacedFlag(f, opts.FlagNamespace)
+		if err == nil {
+			err = mergeFlagCollision(dst, nf)
+		}
+		flags = append(flags, nf)
+	})
+	if err != nil {
+		return err
+	}
+
+	dst.RemoveCommand(replaced...)
+	for _, sub := range grafted {
+		src.RemoveCommand(sub)
+		sub.Use = opts.Prefix + sub.Use
+		for i, alias := range sub.Aliases {
+			sub.Aliases[i] = opts.Prefix + alias
+		}
+		for _, f := range flags {
+			if sub.PersistentFlags().Lookup(f.Name) == nil && sub.Flags().Lookup(f.Name) == nil {
+				sub.PersistentFlags().AddFlag(f)
+			}
+		}
+		if sub.PersistentPreRun == nil && sub.PersistentPreRunE == nil {
+			sub.PersistentPreRun, sub.PersistentPreRunE = src.PersistentPreRun, src.PersistentPreRunE
+		}
+		if sub.PersistentPostRun == nil && sub.PersistentPostRunE == nil {
+			sub.PersistentPostRun, sub.PersistentPostRunE = src.PersistentPostRun, src.PersistentPostRunE
+		}
+		resetParentsPflags(sub)
+		dst.AddCommand(sub)
+	}
+	return nil
+}
+
+// mergeCollision returns the command of dst whose name or an alias is used by sub once
+// prefixed, or nil.
+func mergeCollision(dst, sub *Command, prefix string) *Command {
+	names := []string{prefix + sub.Name()}
+	for _, alias := range sub.Aliases {
+		names = append(names, prefix+alias)
+	}
+	for _, existing := range dst.commands {
+		for _, name := range names {
+			if existing.Name() == name || existing.HasAlias(name) {
+				return existing
+			}
+		}
+	}
+	return nil
+}
+
+// mergeFlagCollision returns an error if f collides with a persistent flag that dst
+// passes on to its subcommands.
+func mergeFlagCollision(dst *Command, f *flag.Flag) error {
+	for p := dst; p != nil; p = p.parent {
+		flags := p.PersistentFlags()
+		if existing := flags.Lookup(f.Name); existing != nil {
+			return fmt.Errorf("flag %q collides with a persistent flag of %q", f.Name, p.CommandPath())
+		}
+		if f.Shorthand != "" && flags.ShorthandLookup(f.Shorthand) != nil {
+			return fmt.Errorf("shorthand %q of flag %q collides with a persistent flag of %q", f.Shorthand, f.Name, p.CommandPath())
+		}
+	}
+	return nil
+}
+
+// namespacedFlag returns a copy of f with its long name prefixed by namespace and no
+// shorthand, or f itself if namespace is empty.
+func namespacedFlag(f *flag.Flag, namespace string) *flag.Flag {
+	if namespace == "" {
+		return f
+	}
+	nf := *f
+	nf.Name = namespace + f.Name
+	nf.Shorthand = ""
+	return &nf
+}
+
+// namespacePersistentFlags replaces the persistent flags of c and its subcommands by
+// copies namespaced by namespacedFlag.
+func (c *Command) namespacePersistentFlags(namespace string) {
+	c.unmergePersistentFlags()
+	if c.pflags != nil {
+		pflags := flag.NewFlagSet(c.Name(), flag.ContinueOnError)
+		pflags.SetOutput(c.flagOutput())
+		c.pflags.VisitAll(func(f *flag.Flag) {
+			pflags.AddFlag(namespacedFlag(f, namespace))
+		})
+		c.pflags = pflags
+	}
+	for _, sub := range c.commands {
+		sub.namespacePersistentFlags(namespace)
+	}
+	resetParentsPflags(c)
+}
+
+// unmergePersistentFlags rebuilds the flags of c with its local flags only, the
+// persistent flags of c and of its parents being merged again on the next use.
+func (c *Command) unmergePersistentFlags() {
+	if c.flags == nil {
+		return
+	}
+	flags := flag.NewFlagSet(c.Name(), flag.ContinueOnError)
+	flags.SetOutput(c.flagOutput())
+	flags.Usage = c.flags.Usage
+	flags.SortFlags = c.flags.SortFlags
+	flags.ParseErrorsWhitelist = c.flags.ParseErrorsWhitelist
+	flags.SetNormalizeFunc(c.flags.GetNormalizeFunc())
+	flags.SetInterspersed(flagsInterspersed(c.flags))
+	c.flags.VisitAll(func(f *flag.Flag) {
+		if c.pflags != nil && c.pflags.Lookup(f.Name) == f {
+			return
+		}
+		if c.parentsPflags != nil && c.parentsPflags.Lookup(f.Name) == f {
+			return
+		}
+		flags.AddFlag(f)
+	})
+	c.flags = flags
+}
+
+// flagsInterspersed returns the interspersed setting of flags, see SetInterspersed,
+// which pflag keeps unexported.
+func flagsInterspersed(flags *flag.FlagSet) bool {
+	interspersed := reflect.ValueOf(flags).Elem().FieldByName("interspersed")
+	return interspersed.Kind() != reflect.Bool || interspersed.Bool()
+}
+
+// resetParentsPflags forgets the flags inherited by c and its subcommands, which are
+// merged again from their new parents.
+func resetParentsPflags(c *Command) {
+	c.unmergePersistentFlags()
+	c.parentsPflags = nil
+	c.lflags = nil
+	c.iflags = nil
+	for _, sub := range c.commands {
+		resetParentsPflags(sub)
+	}
+}
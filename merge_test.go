@@ -0,0 +1,182 @@
+package cobra
+
+import (
+	"strings"
+	"testing"
+)
+
+func newTeamCLI(preRuns *[]string) *Command {
+	teamCmd := &Command{
+		Use:              "team",
+		PersistentPreRun: func(c *Command, _ []string) { *preRuns = append(*preRuns, c.Name()) },
+	}
+	teamCmd.PersistentFlags().BoolP("verbose", "v", false, "verbose output")
+	teamCmd.AddCommand(
+		&Command{Use: "deploy", Aliases: []string{"d"}, Run: emptyRun},
+		&Command{Use: "status", Run: emptyRun},
+	)
+	return teamCmd
+}
+
+func TestMerge(t *testing.T) {
+	var preRuns []string
+	rootCmd := &Command{Use: "root", Run: emptyRun}
+	rootCmd.PersistentFlags().BoolP("verbose", "v", false, "verbose output")
+	rootCmd.AddCommand(&Command{Use: "status", Run: emptyRun})
+
+	teamCmd := newTeamCLI(&preRuns)
+	if err := Merge(rootCmd, teamCmd, MergeOptions{}); err == nil {
+		t.Fatal("Expected collision error")
+	}
+	if len(teamCmd.Commands()) != 2 || len(rootCmd.Commands()) != 1 {
+		t.Fatal("Expected trees to be unchanged after a collision error")
+	}
+
+	err := Merge(rootCmd, teamCmd, MergeOptions{Prefix: "team-", FlagNamespace: "team-"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if teamCmd.HasSubCommands() {
+		t.Error("Expected source to be left without subcommands")
+	}
+
+	output, err := executeCommand(rootCmd, "team-d", "--team-verbose", "-v")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v\n%s", err, output)
+	}
+	if strings.Join(preRuns, " ") != "team-deploy" {
+		t.Errorf("Expected the persistent pre run of the source to run, got %v", preRuns)
+	}
+
+	output, err = executeCommand(rootCmd, "team-status", "--help")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	checkStringContains(t, output, "--team-verbose")
+	checkStringOmits(t, output, "-v, --team-verbose")
+}
+
+func TestMergeAfterFlagsUsed(t *testing.T) {
+	var preRuns []string
+	rootCmd := &Command{Use: "root", Run: emptyRun}
+	teamCmd := newTeamCLI(&preRuns)
+	deployCmd, _, _ := teamCmd.Find([]string{"deploy"})
+	// Merge the persistent flags of the source before they are namespaced.
+	deployCmd.InheritedFlags()
+
+	if err := Merge(rootCmd, teamCmd, MergeOptions{FlagNamespace: "team-"}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	deployCmd.InheritedFlags()
+	if deployCmd.Flags().Lookup("verbose") != nil || deployCmd.Flags().ShorthandLookup("v") != nil {
+		t.Error("Expected the flags of deploy to have no flag verbose left")
+	}
+	if _, err := executeCommand(rootCmd, "deploy", "--verbose"); err == nil {
+		t.Error("Expected an error for the flag before its namespace")
+	}
+	if _, err := executeCommand(rootCmd, "deploy", "--team-verbose"); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+func TestMergeCollisionPolicies(t *testing.T) {
+	var preRuns []string
+	rootCmd := &Command{Use: "root", Run: emptyRun}
+	ownStatus := &Command{Use: "status", Run: emptyRun}
+	rootCmd.AddCommand(ownStatus)
+	if err := Merge(rootCmd, newTeamCLI(&preRuns), MergeOptions{OnCollision: MergeSkip}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if c, _, _ := rootCmd.Find([]string{"status"}); c != ownStatus {
+		t.Error("Expected the existing command to be kept")
+	}
+
+	rootCmd = &Command{Use: "root", Run: emptyRun}
+	rootCmd.AddCommand(ownStatus)
+	if err := Merge(rootCmd, newTeamCLI(&preRuns), MergeOptions{OnCollision: MergeReplace}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if c, _, _ := rootCmd.Find([]string{"status"}); c == ownStatus {
+		t.Error("Expected the existing command to be replaced")
+	}
+	if len(rootCmd.Commands()) != 2 {
+		t.Errorf("Expected 2 commands, got %d", len(rootCmd.Commands()))
+	}
+}
+
+func TestFlagNamespace(t *testing.T) {
+	var verbose, debug bool
+	vendorCmd := &Command{Use: "vendor", FlagNamespace: "vendor-"}
+	vendorCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "verbose output")
+	subCmd := &Command{Use: "sub", Run: emptyRun}
+	subCmd.PersistentFlags().BoolVar(&debug, "debug", false, "debug output")
+	vendorCmd.AddCommand(subCmd)
+
+	rootCmd := &Command{Use: "root", Run: emptyRun}
+	rootCmd.PersistentFlags().BoolP("verbose", "v", false, "verbose output")
+	rootCmd.AddCommand(vendorCmd)
+
+	output, err := executeCommand(rootCmd, "vendor", "sub", "-v", "--vendor-verbose", "--vendor-debug")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v\n%s", err, output)
+	}
+	if !verbose || !debug {
+		t.Error("Expected namespaced flags to be set")
+	}
+	if v, _ := rootCmd.PersistentFlags().GetBool("verbose"); !v {
+		t.Error("Expected the root flag to be set")
+	}
+
+	output, err = executeCommand(rootCmd, "vendor", "sub", "--help")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	checkStringContains(t, output, "--vendor-verbose")
+	checkStringContains(t, output, "--vendor-debug")
+
+	// Adding the command again must not namespace its flags twice.
+	rootCmd.RemoveCommand(vendorCmd)
+	rootCmd.AddCommand(vendorCmd)
+	if vendorCmd.PersistentFlags().Lookup("vendor-verbose") == nil {
+		t.Error("Expected flags to be namespaced once")
+	}
+}
+
+func TestFlagNamespaceAfterFlagsUsed(t *testing.T) {
+	vendorCmd := &Command{Use: "vendor", FlagNamespace: "vendor-", Run: emptyRun}
+	vendorCmd.Flags().Bool("local", false, "local flag")
+	vendorCmd.PersistentFlags().BoolP("verbose", "v", false, "verbose output")
+	subCmd := &Command{Use: "sub", Run: emptyRun}
+	subCmd.Flags().SetInterspersed(false)
+	vendorCmd.AddCommand(subCmd)
+	// Merge the persistent flags before they are namespaced.
+	vendorCmd.Flags()
+	vendorCmd.LocalFlags()
+	subCmd.Flags()
+	subCmd.InheritedFlags()
+
+	rootCmd := &Command{Use: "root", Run: emptyRun}
+	rootCmd.AddCommand(vendorCmd)
+
+	for _, c := range []*Command{vendorCmd, subCmd} {
+		c.InheritedFlags()
+		if c.Flags().Lookup("verbose") != nil || c.Flags().ShorthandLookup("v") != nil {
+			t.Errorf("Expected the flags of %q to have no flag verbose left", c.Name())
+		}
+		if c.Flags().Lookup("vendor-verbose") == nil {
+			t.Errorf("Expected the flags of %q to have the namespaced flag", c.Name())
+		}
+	}
+	if vendorCmd.Flags().Lookup("local") == nil {
+		t.Error("Expected the local flag to be kept")
+	}
+	if _, err := executeCommand(rootCmd, "vendor", "sub", "--verbose"); err == nil {
+		t.Error("Expected an error for the flag before its namespace")
+	}
+	if _, err := executeCommand(rootCmd, "vendor", "sub", "arg", "--vendor-verbose"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if subCmd.Flags().Changed("vendor-verbose") {
+		t.Error("Expected the flags of sub to stay not interspersed")
+	}
+}
@@ -0,0 +1,74 @@
+package cobra
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"runtime/debug"
+)
+
+// SetPanicHandler sets the function converting the panics of this command and of its
+// subcommands, in their Run functions and hooks, into the error returned by Execute,
+// rather than crashing with a stack trace. It is given the recovered value, the stack
+// of the panic and the executed command. See CrashReportHandler.
+func (c *Command) SetPanicHandler(f func(recovered interface{}, stack []byte, cmd *Command) error) {
+	c.panicHandler = f
+}
+
+// findPanicHandler returns the function set with SetPanicHandler on c or a parent, or nil.
+func (c *Command) findPanicHandler() func(interface{}, []byte, *Command) error {
+	for p := c; p != nil; p = p.parent {
+		if p.panicHandler != nil {
+			return p.panicHandler
+		}
+	}
+	return nil
+}
+
+// executeRecovering executes c, converting its panics with its panic handler, if any.
+func (c *Command) executeRecovering(a []string) (err error) {
+	handler := c.findPanicHandler()
+	if handler == nil {
+		return c.execute(a)
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = handler(r, debug.Stack(), c)
+		}
+	}()
+	return c.execute(a)
+}
+
+// CrashReportHandler returns a panic handler, for SetPanicHandler, writing a crash
+// report with the stack of the panic to a new file of dir, and returning an error
+// telling users where to find it.
+func CrashReportHandler(dir string) func(recovered interface{}, stack []byte, cmd *Command) error {
+	return func(recovered interface{}, stack []byte, cmd *Command) error {
+		report := fmt.Sprintf("command: %s\nversion: %s\npanic: %v\n\n%s",
+			cmd.CommandPath(), cmd.Root().Version, recovered, stack)
+		path, err := writeCrashReport(dir, cmd.Root().Name(), report)
+		if err != nil {
+			return fmt.Errorf("internal error in %q: %v", cmd.CommandPath(), recovered)
+		}
+		return fmt.Errorf("internal error in %q: %v\nA crash report was written to %s",
+			cmd.CommandPath(), recovered, path)
+	}
+}
+
+// writeCrashReport writes report to a new file of dir named after name, e.g.
+// root-crash-123456, and returns its path.
+func writeCrashReport(dir, name, report string) (string, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", err
+	}
+	// The "*" of the patterns of TempFile needs Go 1.11, the random part is a suffix.
+	f, err := ioutil.TempFile(dir, name+"-crash-")
+	if err != nil {
+		return "", err
+	}
+	_, err = f.WriteString(report)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return f.Name(), err
+}
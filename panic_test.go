@@ -0,0 +1,68 @@
+package cobra
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSetPanicHandler(t *testing.T) {
+	var gotCmd *Command
+	var gotStack []byte
+	rootCmd := &Command{Use: "root", Run: emptyRun}
+	childCmd := &Command{
+		Use:    "child",
+		PreRun: func(*Command, []string) { panic("boom") },
+		Run:    emptyRun,
+	}
+	rootCmd.AddCommand(childCmd)
+	rootCmd.SetPanicHandler(func(recovered interface{}, stack []byte, cmd *Command) error {
+		gotCmd, gotStack = cmd, stack
+		return fmt.Errorf("something went wrong: %v", recovered)
+	})
+
+	rootCmd.SetArgs([]string{"child"})
+	rootCmd.SetOutput(ioutil.Discard)
+	r := rootCmd.ExecuteResult()
+	if r.Err == nil || r.Err.Error() != "something went wrong: boom" {
+		t.Errorf("Expected the error of the panic handler, got %v", r.Err)
+	}
+	if r.ExitCode != ExitError {
+		t.Errorf("Expected exit code %d, got %d", ExitError, r.ExitCode)
+	}
+	if gotCmd != childCmd {
+		t.Errorf("Expected the panic handler to be given the child command, got %v", gotCmd)
+	}
+	if !strings.Contains(string(gotStack), "panic_test.go") {
+		t.Errorf("Expected the stack of the panic, got:\n%s", gotStack)
+	}
+}
+
+func TestCrashReportHandler(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cobra-crash")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	rootCmd := &Command{Use: "root", Version: "1.0", Run: func(*Command, []string) { panic("boom") }}
+	rootCmd.SetPanicHandler(CrashReportHandler(filepath.Join(dir, "crashes")))
+
+	_, err = executeCommand(rootCmd)
+	if err == nil || !strings.HasPrefix(err.Error(), "internal error in \"root\": boom\nA crash report was written to ") {
+		t.Fatalf("Expected an internal error, got %v", err)
+	}
+	path := strings.TrimPrefix(err.Error(), "internal error in \"root\": boom\nA crash report was written to ")
+	report, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkStringContains(t, string(report), "command: root\nversion: 1.0\npanic: boom\n")
+	checkStringContains(t, string(report), "panic_test.go")
+	if base := filepath.Base(path); !strings.HasPrefix(base, "root-crash-") || strings.Contains(base, "*") {
+		t.Errorf("Unexpected name of the crash report %q", base)
+	}
+}
@@ -0,0 +1,93 @@
+package cobra
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ParallelError is the error returned by ExecuteParallel when invocations fail.
+type ParallelError struct {
+	// Errs holds the errors of the failed invocations, by index of invocation. The
+	// invocations skipped after a failure have the error of the canceled context.
+	Errs map[int]error
+}
+
+// Error returns the errors of the failed invocations, in order.
+func (e *ParallelError) Error() string {
+	indexes := make([]int, 0, len(e.Errs))
+	for i := range e.Errs {
+		indexes = append(indexes, i)
+	}
+	sort.Ints(indexes)
+	errs := make([]string, len(indexes))
+	for j, i := range indexes {
+		errs[j] = fmt.Sprintf("invocation %d: %v", i+1, e.Errs[i])
+	}
+	return fmt.Sprintf("%d of the invocations failed: %s", len(errs), strings.Join(errs, "; "))
+}
+
+// ExecuteParallel executes the invocations concurrently, at most limit at a time, or
+// all at once if limit is below 1, each invocation being the arguments of an execution
+// like the ones given to SetArgs. Every invocation is executed by its own copy of the
+// command tree of c, returned by Instantiate, so that the flag values of the
+// invocations don't interfere. The commands get a context derived from ctx with
+// Context; it is canceled when an invocation fails, and the invocations not started yet
+// are then skipped. The failures are returned as a *ParallelError.
+//
+// The copies share the outputs of c, which must be safe for concurrent use. They also
+// share the custom flag values which Instantiate cannot copy, like values holding maps
+// or structs with unexported fields, unless the values have a Clone() pflag.Value
+// method: the invocations setting such flags interfere, and race.
+func (c *Command) ExecuteParallel(ctx context.Context, invocations [][]string, limit int) error {
+	if limit < 1 || limit > len(invocations) {
+		limit = len(invocations)
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var mu sync.Mutex
+	errs := make(map[int]error)
+	fail := func(i int, err error) {
+		mu.Lock()
+		errs[i] = err
+		mu.Unlock()
+		cancel()
+	}
+
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, limit)
+	for i, args := range invocations {
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if err := ctx.Err(); err != nil {
+			fail(i, err)
+			continue
+		}
+		// The copies are made here rather than by the goroutines, as copying the
+		// tree of c sorts its flags.
+		root := c.Instantiate()
+		root.ctx = ctx
+		root.SetArgs(args)
+		wg.Add(1)
+		go func(i int) {
+			defer func() {
+				<-slots
+				wg.Done()
+			}()
+			if _, err := root.ExecuteC(); err != nil {
+				fail(i, err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if len(errs) > 0 {
+		return &ParallelError{Errs: errs}
+	}
+	return nil
+}
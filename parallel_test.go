@@ -0,0 +1,93 @@
+package cobra
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestExecuteParallel(t *testing.T) {
+	var mu sync.Mutex
+	var targets []string
+	running, maxRunning := 0, 0
+
+	rootCmd := &Command{Use: "root", Run: emptyRun}
+	pingCmd := &Command{
+		Use: "ping",
+		RunE: func(cmd *Command, args []string) error {
+			mu.Lock()
+			running++
+			if running > maxRunning {
+				maxRunning = running
+			}
+			mu.Unlock()
+			runtime.Gosched()
+			mu.Lock()
+			defer mu.Unlock()
+			running--
+			target, _ := cmd.Flags().GetString("target")
+			if target == "down" {
+				return errors.New("unreachable")
+			}
+			targets = append(targets, target)
+			return nil
+		},
+	}
+	pingCmd.Flags().String("target", "", "target")
+	rootCmd.AddCommand(pingCmd)
+	rootCmd.SetOutput(new(strings.Builder))
+	rootCmd.SilenceErrors = true
+
+	invocations := [][]string{
+		{"ping", "--target", "a"},
+		{"ping", "--target", "b"},
+		{"ping", "--target", "c"},
+	}
+	if err := rootCmd.ExecuteParallel(context.Background(), invocations, 2); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	sort.Strings(targets)
+	if strings.Join(targets, ",") != "a,b,c" {
+		t.Errorf("Expected every target to be pinged, got %v", targets)
+	}
+	if maxRunning > 2 {
+		t.Errorf("Expected at most 2 concurrent invocations, got %d", maxRunning)
+	}
+	if pingCmd.Flags().Changed("target") {
+		t.Error("Expected the flags of the template to be untouched")
+	}
+
+	err := rootCmd.ExecuteParallel(context.Background(), [][]string{{"ping", "--target", "down"}}, 0)
+	parallelErr, ok := err.(*ParallelError)
+	if !ok {
+		t.Fatalf("Expected a *ParallelError, got %v", err)
+	}
+	if parallelErr.Errs[0] == nil || err.Error() != "1 of the invocations failed: invocation 1: unreachable" {
+		t.Errorf("Unexpected error %q", err)
+	}
+}
+
+func TestExecuteParallelContext(t *testing.T) {
+	rootCmd := &Command{Use: "root", Run: emptyRun}
+	rootCmd.AddCommand(&Command{
+		Use: "wait",
+		RunE: func(cmd *Command, args []string) error {
+			<-cmd.Context().Done()
+			return cmd.Context().Err()
+		},
+	})
+	rootCmd.SetOutput(new(strings.Builder))
+	rootCmd.SilenceErrors = true
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := rootCmd.ExecuteParallel(ctx, [][]string{{"wait"}, {"wait"}}, 1)
+	parallelErr, ok := err.(*ParallelError)
+	if !ok || len(parallelErr.Errs) != 2 {
+		t.Fatalf("Expected both invocations to fail, got %v", err)
+	}
+}
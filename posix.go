@@ -0,0 +1,34 @@
+package cobra
+
+import (
+	"fmt"
+	"strings"
+)
+
+// checkSingleDashLongFlags returns an error if one of the flags of args, up to the
+// first operand, is a long flag given with a single dash, e.g. "-name" for "--name",
+// which StrictPOSIX commands reject rather than parse as a group of shorthands.
+func (c *Command) checkSingleDashLongFlags(args []string) error {
+	flags := c.Flags()
+	for len(args) > 0 {
+		s := args[0]
+		args = args[1:]
+		switch {
+		case s == "--" || s == "-" || !strings.HasPrefix(s, "-"):
+			return nil
+		case strings.HasPrefix(s, "--"):
+			if !strings.Contains(s, "=") && !hasNoOptDefVal(s[2:], flags) && len(args) > 0 {
+				args = args[1:]
+			}
+		default:
+			name := strings.SplitN(s[1:], "=", 2)[0]
+			if len(name) > 1 && flags.Lookup(name) != nil {
+				return fmt.Errorf("long flag %q given with a single dash, use --%s", s, name)
+			}
+			if len(s) == 2 && !shortHasNoOptDefVal(s[1:], flags) && len(args) > 0 {
+				args = args[1:]
+			}
+		}
+	}
+	return nil
+}
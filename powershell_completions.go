@@ -49,8 +49,10 @@ func generatePowerShellSubcommandCases(out io.Writer, cmd *Command, previousComm
 
 	fmt.Fprintf(out, "\n        '%s' {", cmdName)
 
+	cmd.InitDefaultYesFlag()
+
 	cmd.Flags().VisitAll(func(flag *pflag.Flag) {
-		if nonCompletableFlag(flag) {
+		if nonCompletableFlag(flag) || cmd.flagFeatureDisabled(flag) {
 			return
 		}
 		usage := escapeStringForPowerShell(flag.Usage)
@@ -61,14 +63,22 @@ func generatePowerShellSubcommandCases(out io.Writer, cmd *Command, previousComm
 	})
 
 	for _, subCmd := range cmd.Commands() {
+		if !subCmd.IsVisible() || checkFeature(subCmd) != nil {
+			continue
+		}
 		usage := escapeStringForPowerShell(subCmd.Short)
 		fmt.Fprintf(out, "\n            [CompletionResult]::new('%s', '%s', [CompletionResultType]::ParameterValue, '%s')", subCmd.Name(), subCmd.Name(), usage)
 	}
 
+	if snippet := completionSnippet(cmd, PowerShellCompSnippet, "            "); snippet != "" {
+		fmt.Fprint(out, "\n"+strings.TrimSuffix(snippet, "\n"))
+	}
 	fmt.Fprint(out, "\n            break\n        }")
 
 	for _, subCmd := range cmd.Commands() {
-		generatePowerShellSubcommandCases(out, subCmd, cmdName)
+		if subCmd.IsVisible() && checkFeature(subCmd) == nil {
+			generatePowerShellSubcommandCases(out, subCmd, cmdName)
+		}
 	}
 }
 
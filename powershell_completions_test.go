@@ -120,3 +120,16 @@ func TestPowerShellCompletion(t *testing.T) {
 		})
 	}
 }
+
+func TestPowerShellCompletionSnippet(t *testing.T) {
+	c := &Command{
+		Use:         "app",
+		Annotations: map[string]string{PowerShellCompSnippet: "[CompletionResult]::new('extra', 'extra', [CompletionResultType]::ParameterValue, 'extra')"},
+	}
+
+	buf := new(bytes.Buffer)
+	if err := c.GenPowerShellCompletion(buf); err != nil {
+		t.Fatal(err)
+	}
+	checkStringContains(t, buf.String(), "\n            [CompletionResult]::new('extra', 'extra', [CompletionResultType]::ParameterValue, 'extra')\n            break")
+}
@@ -0,0 +1,134 @@
+package cobra
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	flag "github.com/spf13/pflag"
+)
+
+// AddPresetFlags adds the persistent --preset and --save-preset flags to c. The flags set
+// on the command line are saved as a named preset with --save-preset, and recalled with
+// --preset: the flags of the preset apply unless they are set on the command line.
+// Presets are stored as JSON files in dir, e.g. a directory under os.UserConfigDir.
+// The values of sensitive flags are never saved.
+func (c *Command) AddPresetFlags(dir string) {
+	c.presetDir = dir
+	c.PersistentFlags().String("preset", "", "apply the flags of the named preset")
+	c.PersistentFlags().String("save-preset", "", "save the flags set on the command line as the named preset")
+	MarkFlagCustom(c.PersistentFlags(), "preset", fmt.Sprintf(
+		`COMPREPLY=( $(compgen -W "$(command ls %q 2>/dev/null | sed -n 's/\.json$//p')" -- "$cur") )`, dir))
+}
+
+// presetDirectory returns the directory of the presets given to AddPresetFlags by c or
+// a parent, or an empty string.
+func (c *Command) presetDirectory() string {
+	for p := c; p != nil; p = p.parent {
+		if p.presetDir != "" {
+			return p.presetDir
+		}
+	}
+	return ""
+}
+
+// applyPresets applies the preset given with --preset, then saves the preset given
+// with --save-preset.
+func (c *Command) applyPresets() error {
+	dir := c.presetDirectory()
+	if dir == "" {
+		return nil
+	}
+	if name, err := c.Flags().GetString("preset"); err == nil && name != "" {
+		if err := c.applyPreset(dir, name); err != nil {
+			return err
+		}
+	}
+	if name, err := c.Flags().GetString("save-preset"); err == nil && name != "" {
+		if err := c.savePreset(dir, name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func presetPath(dir, name string) (string, error) {
+	if name != filepath.Base(name) || strings.HasPrefix(name, ".") {
+		return "", fmt.Errorf("invalid preset name %q", name)
+	}
+	return filepath.Join(dir, name+".json"), nil
+}
+
+func (c *Command) applyPreset(dir, name string) error {
+	path, err := presetPath(dir, name)
+	if err != nil {
+		return err
+	}
+	b, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return fmt.Errorf("unknown preset %q", name)
+	}
+	if err != nil {
+		return err
+	}
+	var values map[string]string
+	if err := json.Unmarshal(b, &values); err != nil {
+		return fmt.Errorf("invalid preset %q: %v", name, err)
+	}
+	for flagName, value := range values {
+		f := c.Flags().Lookup(flagName)
+		if f == nil || f.Changed || c.excludedBySetFlag(flagName) {
+			continue
+		}
+		for _, v := range presetValues(f, value) {
+			if err := c.SetFlagFromSource(flagName, v, FlagSourcePreset); err != nil {
+				return fmt.Errorf("invalid preset %q: %v", name, err)
+			}
+		}
+	}
+	return nil
+}
+
+func (c *Command) savePreset(dir, name string) error {
+	path, err := presetPath(dir, name)
+	if err != nil {
+		return err
+	}
+	values := make(map[string]string)
+	c.Flags().Visit(func(f *flag.Flag) {
+		if f.Name == "preset" || f.Name == "save-preset" || isFlagSensitive(f) {
+			return
+		}
+		values[f.Name] = f.Value.String()
+	})
+	b, err := json.MarshalIndent(values, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, b, 0600)
+}
+
+// presetValues returns the saved value as accepted by the flag: the slice and map values
+// of pflag are saved between brackets, and each element of array values is set apart.
+func presetValues(f *flag.Flag, value string) []string {
+	t := f.Value.Type()
+	if !strings.HasSuffix(t, "Slice") && !strings.HasSuffix(t, "Array") && !strings.HasPrefix(t, "stringTo") {
+		return []string{value}
+	}
+	value = strings.TrimSuffix(strings.TrimPrefix(value, "["), "]")
+	if !strings.HasSuffix(t, "Array") {
+		return []string{value}
+	}
+	values, err := csv.NewReader(strings.NewReader(value)).Read()
+	if err != nil {
+		return []string{value}
+	}
+	return values
+}
@@ -0,0 +1,73 @@
+package cobra
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPresets(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cobra-presets")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var namespace, cluster, token string
+	var tags []string
+	rootCmd := &Command{Use: "root", Run: emptyRun}
+	rootCmd.AddPresetFlags(filepath.Join(dir, "presets"))
+	childCmd := &Command{Use: "child", Run: emptyRun}
+	childCmd.Flags().StringVar(&namespace, "namespace", "default", "namespace")
+	childCmd.Flags().StringVar(&cluster, "cluster", "local", "cluster")
+	childCmd.Flags().StringVar(&token, "token", "", "token")
+	childCmd.Flags().StringArrayVar(&tags, "tag", nil, "tags")
+	childCmd.MarkFlagSensitive("token")
+	rootCmd.AddCommand(childCmd)
+
+	reset := func() { namespace, cluster, token, tags = "default", "local", "", nil }
+	newRoot := func() *Command {
+		for _, f := range []string{"namespace", "cluster", "token", "tag", "preset", "save-preset"} {
+			if fl := childCmd.Flags().Lookup(f); fl != nil {
+				fl.Changed = false
+			}
+		}
+		reset()
+		return rootCmd
+	}
+
+	_, err = executeCommand(newRoot(), "child", "--save-preset", "prod", "--namespace", "prod", "--cluster", "eu",
+		"--token", "s3cr3t", "--tag", "a,b", "--tag", "c")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	b, err := ioutil.ReadFile(filepath.Join(dir, "presets", "prod.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkStringOmits(t, string(b), "s3cr3t")
+
+	_, err = executeCommand(newRoot(), "child", "--preset", "prod", "--cluster", "us")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if namespace != "prod" || cluster != "us" || token != "" {
+		t.Errorf("Expected namespace=prod cluster=us and no token, got namespace=%s cluster=%s token=%s", namespace, cluster, token)
+	}
+	if len(tags) != 2 || tags[0] != "a,b" || tags[1] != "c" {
+		t.Errorf("Expected tags [a,b c], got %q", tags)
+	}
+
+	if _, err = executeCommand(newRoot(), "child", "--preset", "staging"); err == nil {
+		t.Error("Expected error for an unknown preset")
+	}
+	if _, err = executeCommand(newRoot(), "child", "--preset", "../prod"); err == nil {
+		t.Error("Expected error for an invalid preset name")
+	}
+
+	buf := new(bytes.Buffer)
+	rootCmd.GenBashCompletion(buf)
+	checkStringContains(t, buf.String(), `flags_with_completion+=("--preset")`)
+}
@@ -0,0 +1,102 @@
+package cobra
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/pflag"
+)
+
+// FlagRememberRecent is the annotation of flags whose last values are remembered to be
+// offered by shell completion.
+const FlagRememberRecent = "cobra_annotation_flag_remember_recent"
+
+// MarkFlagRememberRecent adds the FlagRememberRecent annotation to the named flag if it
+// exists. The last n distinct values given to the flag in successful executions are
+// then stored under the user cache directory, and offered by bash and zsh completion
+// along with the other completion of the flag.
+func (c *Command) MarkFlagRememberRecent(name string, n int) error {
+	return MarkFlagRememberRecent(c.Flags(), name, n)
+}
+
+// MarkPersistentFlagRememberRecent adds the FlagRememberRecent annotation to the named
+// persistent flag if it exists. See MarkFlagRememberRecent.
+func (c *Command) MarkPersistentFlagRememberRecent(name string, n int) error {
+	return MarkFlagRememberRecent(c.PersistentFlags(), name, n)
+}
+
+// MarkFlagRememberRecent adds the FlagRememberRecent annotation to the named flag if it
+// exists. See Command.MarkFlagRememberRecent.
+func MarkFlagRememberRecent(flags *pflag.FlagSet, name string, n int) error {
+	if n <= 0 {
+		return fmt.Errorf("invalid number of recent values %d for flag %q", n, name)
+	}
+	return flags.SetAnnotation(name, FlagRememberRecent, []string{strconv.Itoa(n)})
+}
+
+// RecentFlagValues returns the values remembered for the named flag, most recent first.
+func (c *Command) RecentFlagValues(name string) []string {
+	path, err := c.recentFlagValuesPath(name)
+	if err != nil {
+		return nil
+	}
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	var values []string
+	for _, v := range strings.Split(string(b), "\n") {
+		if v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+func (c *Command) recentFlagValuesPath(name string) (string, error) {
+	dir, err := userCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, c.Root().Name(), "recent", name), nil
+}
+
+// rememberRecentFlagValues stores the values of the flags annotated with
+// FlagRememberRecent. Storing is best effort: errors are ignored.
+func (c *Command) rememberRecentFlagValues() {
+	c.Flags().Visit(func(f *pflag.Flag) {
+		recent := f.Annotations[FlagRememberRecent]
+		if len(recent) == 0 || isFlagSensitive(f) {
+			return
+		}
+		n, err := strconv.Atoi(recent[0])
+		if err != nil {
+			return
+		}
+		value := f.Value.String()
+		if value == "" || strings.Contains(value, "\n") {
+			return
+		}
+		values := []string{value}
+		for _, v := range c.RecentFlagValues(f.Name) {
+			if len(values) == n {
+				break
+			}
+			if v != value {
+				values = append(values, v)
+			}
+		}
+		path, err := c.recentFlagValuesPath(f.Name)
+		if err != nil {
+			return
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+			return
+		}
+		ioutil.WriteFile(path, []byte(strings.Join(values, "\n")+"\n"), 0600)
+	})
+}
@@ -0,0 +1,109 @@
+package cobra
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestMarkFlagRememberRecent(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cobra-recent-flags")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defer func(f func() (string, error)) { userCacheDir = f }(userCacheDir)
+	userCacheDir = func() (string, error) { return dir, nil }
+
+	rootCmd := &Command{Use: "root", Run: emptyRun}
+	rootCmd.PersistentFlags().String("namespace", "", "namespace")
+	rootCmd.PersistentFlags().String("token", "", "token")
+	childCmd := &Command{Use: "child", Run: emptyRun}
+	rootCmd.AddCommand(childCmd)
+
+	if err := rootCmd.MarkPersistentFlagRememberRecent("namespace", 2); err != nil {
+		t.Fatal(err)
+	}
+	if err := rootCmd.MarkPersistentFlagRememberRecent("token", 2); err != nil {
+		t.Fatal(err)
+	}
+	if err := rootCmd.MarkPersistentFlagSensitive("token"); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, ns := range []string{"dev", "prod", "dev", "test"} {
+		if _, err := executeCommand(rootCmd, "child", "--namespace", ns, "--token", "s3cr3t"); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+
+	expected := []string{"test", "dev"}
+	if got := childCmd.RecentFlagValues("namespace"); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected recent values %v, got %v", expected, got)
+	}
+	if got := childCmd.RecentFlagValues("token"); len(got) != 0 {
+		t.Errorf("Expected sensitive flag not to be remembered, got %v", got)
+	}
+
+	buf := new(bytes.Buffer)
+	if err := rootCmd.GenBashCompletion(buf); err != nil {
+		t.Fatal(err)
+	}
+	checkStringContains(t, buf.String(), `flags_completion+=("__root_handle_recent_values_flag namespace")`)
+}
+
+func TestRememberRecentKeepsCompletion(t *testing.T) {
+	rootCmd := &Command{Use: "root", Run: emptyRun}
+	rootCmd.Flags().String("config", "", "config")
+	rootCmd.Flags().String("namespace", "", "namespace")
+	rootCmd.MarkFlagFilename("config", "yaml")
+	rootCmd.MarkFlagRememberRecent("config", 3)
+	rootCmd.MarkFlagRememberRecent("namespace", 3)
+
+	bash := new(bytes.Buffer)
+	if err := rootCmd.GenBashCompletion(bash); err != nil {
+		t.Fatal(err)
+	}
+	checkStringContains(t, bash.String(), `flags_completion+=("__root_handle_recent_values_flag config __root_handle_filename_extension_flag yaml")`)
+	checkStringContains(t, bash.String(), `flags_completion+=("__root_handle_recent_values_flag namespace")`)
+
+	zsh := new(bytes.Buffer)
+	if err := rootCmd.GenZshCompletion(zsh); err != nil {
+		t.Fatal(err)
+	}
+	checkStringContains(t, zsh.String(), "function __root_recent_values {")
+	checkStringContains(t, zsh.String(), `":(recent)"`)
+	checkStringContains(t, zsh.String(), `'--config[config]:filename:{__root_recent_values config; _files -g "yaml"}'`)
+	checkStringContains(t, zsh.String(), `'--namespace[namespace]:value:__root_recent_values namespace'`)
+}
+
+func TestRememberRecentKeepsCompletionInBash(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cobra-recent-flags")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defer os.Setenv("XDG_CACHE_HOME", os.Getenv("XDG_CACHE_HOME"))
+	os.Setenv("XDG_CACHE_HOME", dir)
+	if err := os.MkdirAll(filepath.Join(dir, "c", "recent"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "c", "recent", "label"), []byte("team=core\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	c := &Command{Use: "c", Run: emptyRun}
+	c.Flags().StringArray("label", nil, "")
+	c.MarkFlagKeyValue("label", map[string][]string{"env": nil})
+	c.MarkFlagRememberRecent("label", 3)
+	buf := new(bytes.Buffer)
+	c.GenBashCompletion(buf)
+
+	expected := []string{"env=", "team=core"}
+	if got := runBashCompletion(t, buf.String(), "c", "--label", ""); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %q, got %q", expected, got)
+	}
+}
@@ -0,0 +1,168 @@
+package cobra
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// Phase is the phase of an execution in which an error occurred.
+type Phase int
+
+const (
+	// PhaseResolve is the lookup of the command to execute from the arguments.
+	PhaseResolve Phase = iota
+	// PhaseFlags is the parsing of the flags, including --help and --version.
+	PhaseFlags
+	// PhaseArgs is the validation of the positional arguments and of the flags.
+	PhaseArgs
+	// PhaseConfirm is the confirmation of a Dangerous command.
+	PhaseConfirm
+	// PhaseRun is the execution of the hooks and of the Run function.
+	PhaseRun
+)
+
+var phaseNames = [...]string{"resolve", "flags", "args", "confirm", "run"}
+
+func (p Phase) String() string {
+	if p < 0 || int(p) >= len(phaseNames) {
+		return "unknown"
+	}
+	return phaseNames[p]
+}
+
+// Exit codes of a Result.
+const (
+	// ExitOK is the exit code of a successful execution.
+	ExitOK = 0
+	// ExitError is the exit code of an execution that failed at run time.
+	ExitError = 1
+	// ExitUsage is the exit code of an execution that failed because of its command
+	// line: unknown command, invalid flags or arguments.
+	ExitUsage = 2
+)
+
+// ExitCodes are the exit codes suggested by ExecuteResult, see SetExitCodes.
+type ExitCodes struct {
+	// Help is the exit code of an execution displaying the help, by the help flag or
+	// the help command.
+	Help int
+	// Usage is the exit code of an execution that failed because of its command line,
+	// e.g. invalid flags or arguments or a missing subcommand.
+	Usage int
+	// UnknownCommand is the exit code of an execution naming an unknown command.
+	UnknownCommand int
+	// Error is the exit code of an execution that failed at run time.
+	Error int
+}
+
+// DefaultExitCodes are the exit codes of the commands which didn't set theirs.
+var DefaultExitCodes = ExitCodes{
+	Help:           ExitOK,
+	Usage:          ExitUsage,
+	UnknownCommand: ExitUsage,
+	Error:          ExitError,
+}
+
+// SetExitCodes sets the exit codes suggested by ExecuteResult for this command and its
+// subcommands, e.g. to exit with a non-zero status when the help is displayed.
+func (c *Command) SetExitCodes(codes ExitCodes) {
+	c.exitCodes = &codes
+}
+
+// ExitCodes returns the exit codes set with SetExitCodes for this command or a parent,
+// or DefaultExitCodes.
+func (c *Command) ExitCodes() ExitCodes {
+	for p := c; p != nil; p = p.parent {
+		if p.exitCodes != nil {
+			return *p.exitCodes
+		}
+	}
+	return DefaultExitCodes
+}
+
+// Result describes an execution, as returned by ExecuteResult.
+type Result struct {
+	// Cmd is the executed command, or the command whose lookup failed.
+	Cmd *Command
+	// Phase is the last phase reached by the execution.
+	Phase Phase
+	// Err is the error returned by the execution, if any.
+	Err error
+	// Help is true if the execution displayed the help, by the help flag or the help
+	// command.
+	Help bool
+	// ExitCode is the suggested exit code of the process, according to the ExitCodes
+	// of Cmd. Run time errors having an ExitCode() int method, and the *exec.ExitError
+	// of external commands, suggest their own positive exit code.
+	ExitCode int
+}
+
+// UsageError returns true if the execution failed because of its command line, rather
+// than at run time. Retrying such an execution can't succeed.
+func (r Result) UsageError() bool {
+	return r.Err != nil && r.Phase < PhaseConfirm
+}
+
+// ExecuteResult executes the command like ExecuteC, and returns a Result telling in
+// which phase the execution failed, so that callers can tell usage errors from run
+// time errors without inspecting error messages.
+func (c *Command) ExecuteResult() Result {
+	cmd, err := c.ExecuteC()
+	r := Result{Cmd: cmd, Err: err}
+	if cmd != nil {
+		r.Phase = cmd.phase
+		r.Help = err == nil && cmd.helpDisplayed()
+	}
+	r.ExitCode = exitCode(cmd, err, r.Help)
+	return r
+}
+
+// exitCode returns the exit code suggested for the last execution of cmd, which
+// returned err and displayed the help if help is true, according to its ExitCodes.
+func exitCode(cmd *Command, err error, help bool) int {
+	codes, phase := DefaultExitCodes, PhaseResolve
+	if cmd != nil {
+		codes, phase = cmd.ExitCodes(), cmd.phase
+	}
+	switch {
+	case help:
+		return codes.Help
+	case err == nil:
+		return ExitOK
+	case phase == PhaseResolve && err != ErrSubCommandRequired:
+		return codes.UnknownCommand
+	case phase < PhaseConfirm:
+		return codes.Usage
+	}
+	if code := errorExitCode(err); code > 0 {
+		return code
+	}
+	return codes.Error
+}
+
+// errorExitCode returns the exit code suggested by err, by its ExitCode() int method or,
+// for the *exec.ExitError of an external command, by its wait status, or 0.
+func errorExitCode(err error) int {
+	if coder, ok := err.(interface{ ExitCode() int }); ok {
+		return coder.ExitCode()
+	}
+	// *exec.ExitError only has an ExitCode method from Go 1.12.
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		if status, ok := exitErr.Sys().(syscall.WaitStatus); ok {
+			return status.ExitStatus()
+		}
+	}
+	return 0
+}
+
+// helpDisplayed returns true if the last successful execution of c displayed the help.
+func (c *Command) helpDisplayed() bool {
+	if c.IsHelpCommand() {
+		return true
+	}
+	if c.phase != PhaseFlags || c.flags == nil {
+		return false
+	}
+	help, _ := c.flags.GetBool("help")
+	return help
+}
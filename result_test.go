@@ -0,0 +1,102 @@
+package cobra
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestExecuteResult(t *testing.T) {
+	runErr := errors.New("run failed")
+	tests := []struct {
+		name     string
+		args     []string
+		phase    Phase
+		exitCode int
+		usage    bool
+	}{
+		{"success", []string{"child"}, PhaseRun, ExitOK, false},
+		{"help", []string{"child", "--help"}, PhaseFlags, ExitOK, false},
+		{"unknown command", []string{"unknown"}, PhaseResolve, ExitUsage, true},
+		{"unknown flag", []string{"child", "--unknown"}, PhaseFlags, ExitUsage, true},
+		{"invalid args", []string{"child", "a", "b"}, PhaseArgs, ExitUsage, true},
+		{"required flag", []string{"required"}, PhaseArgs, ExitUsage, true},
+		{"subcommand required", []string{"group"}, PhaseResolve, ExitUsage, true},
+		{"run error", []string{"failing"}, PhaseRun, ExitError, false},
+		{"exit code error", []string{"exiting"}, PhaseRun, 42, false},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			rootCmd := &Command{Use: "root", Run: emptyRun, SilenceErrors: true, SilenceUsage: true}
+			childCmd := &Command{Use: "child", Args: MaximumNArgs(1), Run: emptyRun}
+			requiredCmd := &Command{Use: "required", Run: emptyRun}
+			requiredCmd.Flags().String("name", "", "")
+			requiredCmd.MarkFlagRequired("name")
+			groupCmd := &Command{Use: "group"}
+			groupCmd.AddCommand(&Command{Use: "sub", Run: emptyRun})
+			failingCmd := &Command{Use: "failing", RunE: func(*Command, []string) error { return runErr }}
+			exitingCmd := &Command{Use: "exiting", RunE: func(*Command, []string) error { return exitCodeError(42) }}
+			rootCmd.AddCommand(childCmd, requiredCmd, groupCmd, failingCmd, exitingCmd)
+			rootCmd.SetOutput(new(bytes.Buffer))
+			rootCmd.SetArgs(tc.args)
+
+			r := rootCmd.ExecuteResult()
+			if r.Phase != tc.phase {
+				t.Errorf("Expected phase %v, got %v (error: %v)", tc.phase, r.Phase, r.Err)
+			}
+			if r.ExitCode != tc.exitCode {
+				t.Errorf("Expected exit code %d, got %d", tc.exitCode, r.ExitCode)
+			}
+			if r.UsageError() != tc.usage {
+				t.Errorf("Expected usage error %v, got %v", tc.usage, r.UsageError())
+			}
+			if tc.name == "run error" && r.Err != runErr {
+				t.Errorf("Expected error %v, got %v", runErr, r.Err)
+			}
+		})
+	}
+}
+
+type exitCodeError int
+
+func (e exitCodeError) Error() string { return "exit code error" }
+func (e exitCodeError) ExitCode() int { return int(e) }
+
+func TestSetExitCodes(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		help     bool
+		exitCode int
+	}{
+		{"success", []string{"child"}, false, 0},
+		{"help flag", []string{"child", "--help"}, true, 10},
+		{"help command", []string{"help", "child"}, true, 10},
+		{"version", []string{"--version"}, false, 0},
+		{"subcommand required", []string{"group"}, false, 11},
+		{"invalid args", []string{"child", "a", "b"}, false, 11},
+		{"unknown command", []string{"unknown"}, false, 12},
+		{"run error", []string{"failing"}, false, 13},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			rootCmd := &Command{Use: "root", Version: "1.0", Run: emptyRun, SilenceErrors: true, SilenceUsage: true}
+			childCmd := &Command{Use: "child", Args: MaximumNArgs(1), Run: emptyRun}
+			groupCmd := &Command{Use: "group"}
+			groupCmd.AddCommand(&Command{Use: "sub", Run: emptyRun})
+			failingCmd := &Command{Use: "failing", RunE: func(*Command, []string) error { return errors.New("failed") }}
+			rootCmd.AddCommand(childCmd, groupCmd, failingCmd)
+			rootCmd.SetExitCodes(ExitCodes{Help: 10, Usage: 11, UnknownCommand: 12, Error: 13})
+			rootCmd.SetOutput(new(bytes.Buffer))
+			rootCmd.SetArgs(tc.args)
+
+			r := rootCmd.ExecuteResult()
+			if r.Help != tc.help {
+				t.Errorf("Expected help %v, got %v", tc.help, r.Help)
+			}
+			if r.ExitCode != tc.exitCode {
+				t.Errorf("Expected exit code %d, got %d (error: %v)", tc.exitCode, r.ExitCode, r.Err)
+			}
+		})
+	}
+}
@@ -0,0 +1,172 @@
+package cobra
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"unsafe"
+
+	flag "github.com/spf13/pflag"
+)
+
+// ChainError is the error returned by ExecuteChain when an invocation fails.
+type ChainError struct {
+	// Index is the index of the failed invocation.
+	Index int
+	// Err is the error of the failed invocation.
+	Err error
+	// RollbackErrs holds the errors of the rollbacks of the previous invocations, if any.
+	RollbackErrs []error
+}
+
+// Error returns the error of the failed invocation, followed by the errors of the
+// rollbacks.
+func (e *ChainError) Error() string {
+	return fmt.Sprintf("invocation %d failed: %v%s", e.Index+1, e.Err, rollbackErrsString(e.RollbackErrs))
+}
+
+// rollbackErrsString returns the suffix of the message of an error listing the errors
+// of the rollbacks, if any.
+func rollbackErrsString(rollbackErrs []error) string {
+	if len(rollbackErrs) == 0 {
+		return ""
+	}
+	errs := make([]string, len(rollbackErrs))
+	for i, err := range rollbackErrs {
+		errs[i] = err.Error()
+	}
+	return fmt.Sprintf(" (rollback failed: %s)", strings.Join(errs, "; "))
+}
+
+// Unwrap returns the error of the failed invocation.
+func (e *ChainError) Unwrap() error {
+	return e.Err
+}
+
+// executedInvocation is an invocation of a chain executed successfully by cmd.
+type executedInvocation struct {
+	cmd   *Command
+	flags []string
+}
+
+// ExecuteChain executes the invocations in order through the tree of the root command
+// of c, each invocation being the arguments of an execution like the ones given to
+// SetArgs, and stops at the first failure. The commands already executed are then
+// rolled back by their RollbackE, in reverse order, and a *ChainError is returned.
+// Commands without RollbackE are not rolled back. The flags of the tree are reset to
+// their defaults before each invocation, so that an invocation only gets the flags it
+// sets.
+func (c *Command) ExecuteChain(invocations [][]string) error {
+	root := c.Root()
+	saved := root.args
+	defer func() { root.args = saved }()
+
+	var executed []executedInvocation
+	for i, args := range invocations {
+		resetTreeFlags(root)
+		root.SetArgs(args)
+		cmd, err := root.ExecuteC()
+		if err != nil {
+			return &ChainError{Index: i, Err: err, RollbackErrs: rollback(executed)}
+		}
+		if cmd.RollbackE == nil {
+			continue
+		}
+		var flags []string
+		if root.TraverseChildren {
+			_, flags, err = root.Traverse(args)
+		} else {
+			_, flags, err = root.Find(args)
+		}
+		if err == nil {
+			executed = append(executed, executedInvocation{cmd: cmd, flags: flags})
+		}
+	}
+	return nil
+}
+
+// rollback calls the RollbackE of the executed invocations in reverse order, with the
+// flags of their execution parsed again, and returns their errors.
+func rollback(executed []executedInvocation) []error {
+	var errs []error
+	for i := len(executed) - 1; i >= 0; i-- {
+		if err := rollbackInvocation(executed[i]); err != nil {
+			errs = append(errs, fmt.Errorf("rolling back %q: %v", executed[i].cmd.CommandPath(), err))
+		}
+	}
+	return errs
+}
+
+func rollbackInvocation(e executedInvocation) error {
+	cmd, flags := e.cmd, e.flags
+	// The flags hold the values of the invocations executed after this one.
+	resetFlags(cmd.Flags())
+	if err := cmd.ParseFlags(flags); err != nil {
+		return err
+	}
+	args := cmd.Flags().Args()
+	if cmd.DisableFlagParsing {
+		args = flags
+	}
+	return cmd.RollbackE(cmd, args)
+}
+
+// resetTreeFlags resets the flags of c and its descendants, see resetFlags.
+func resetTreeFlags(c *Command) {
+	resetFlags(c.Flags())
+	resetFlags(c.PersistentFlags())
+	for _, sub := range c.commands {
+		resetTreeFlags(sub)
+	}
+}
+
+// resetFlags sets the flags of flags back to their default values and marks them not
+// set, so that parsing arguments again gives the values of these arguments only.
+func resetFlags(flags *flag.FlagSet) {
+	flags.VisitAll(func(f *flag.Flag) {
+		f.Changed = false
+		resetFlagValue(f.Value, f.DefValue)
+	})
+}
+
+// resetFlagValue sets v back to the default value def, without reading the files of
+// the values given by MarkFlagValueFromFile.
+func resetFlagValue(v flag.Value, def string) {
+	switch v := v.(type) {
+	case *FileValue:
+		resetFlagValue(v.Value, def)
+		return
+	case *filePathValue:
+		v.path = ""
+		return
+	}
+	if isPflagValue(v) && resetPflagValue(v, def) {
+		return
+	}
+	// DefValue is the string of the default value, which the value parses back.
+	v.Set(def)
+}
+
+// resetPflagValue sets the slice or map value v of pflag to def in place, so that the
+// variables bound to v see it, and marks v not set so that its next Set replaces the
+// value rather than appending to it. These values keep their storage and state behind
+// unexported fields.
+func resetPflagValue(v flag.Value, def string) bool {
+	fresh, ok := freshPflagValue(v.Type(), def)
+	if !ok {
+		return false
+	}
+	dst, src := reflect.ValueOf(v).Elem(), reflect.ValueOf(fresh).Elem()
+	value, changed := dst.FieldByName("value"), dst.FieldByName("changed")
+	if value.Kind() != reflect.Ptr || changed.Kind() != reflect.Bool {
+		return false
+	}
+	unexported(value).Elem().Set(unexported(src.FieldByName("value")).Elem())
+	unexported(changed).SetBool(false)
+	return true
+}
+
+// unexported returns the addressable unexported field f as a settable value.
+func unexported(f reflect.Value) reflect.Value {
+	return reflect.NewAt(f.Type(), unsafe.Pointer(f.UnsafeAddr())).Elem()
+}
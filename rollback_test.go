@@ -0,0 +1,132 @@
+package cobra
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestExecuteChain(t *testing.T) {
+	var log []string
+	rootCmd := &Command{Use: "root", Run: emptyRun}
+	rootCmd.SetOutput(new(strings.Builder))
+	createCmd := &Command{
+		Use: "create",
+		Run: func(cmd *Command, args []string) {
+			size, _ := cmd.Flags().GetString("size")
+			log = append(log, "create "+args[0]+" "+size)
+		},
+		RollbackE: func(cmd *Command, args []string) error {
+			size, _ := cmd.Flags().GetString("size")
+			log = append(log, "delete "+args[0]+" "+size)
+			if args[0] == "locked" {
+				return errors.New("locked")
+			}
+			return nil
+		},
+	}
+	createCmd.Flags().String("size", "small", "size")
+	failCmd := &Command{
+		Use:  "fail",
+		RunE: func(cmd *Command, args []string) error { return errors.New("boom") },
+	}
+	rootCmd.AddCommand(createCmd, failCmd, &Command{Use: "noop", Run: emptyRun})
+
+	err := rootCmd.ExecuteChain([][]string{
+		{"create", "a", "--size", "large"},
+		{"noop"},
+		{"create", "b"},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	log = nil
+	err = rootCmd.ExecuteChain([][]string{
+		{"create", "locked", "--size", "small"},
+		{"create", "c", "--size", "large"},
+		{"noop"},
+		{"fail"},
+		{"create", "d"},
+	})
+	chainErr, ok := err.(*ChainError)
+	if !ok {
+		t.Fatalf("Expected a *ChainError, got %v", err)
+	}
+	if chainErr.Index != 3 || chainErr.Err.Error() != "boom" {
+		t.Errorf("Unexpected error %+v", chainErr)
+	}
+	expected := []string{"create locked small", "create c large", "delete c large", "delete locked small"}
+	if !reflect.DeepEqual(log, expected) {
+		t.Errorf("Expected %q, got %q", expected, log)
+	}
+	if got := err.Error(); got != `invocation 4 failed: boom (rollback failed: rolling back "root create": locked)` {
+		t.Errorf("Unexpected error message %q", got)
+	}
+}
+
+func TestExecuteChainRollbackResetsFlags(t *testing.T) {
+	var log []string
+	rootCmd := &Command{Use: "root", Run: emptyRun}
+	rootCmd.SetOutput(new(strings.Builder))
+	createCmd := &Command{
+		Use: "create",
+		Run: emptyRun,
+		RollbackE: func(cmd *Command, args []string) error {
+			size, _ := cmd.Flags().GetString("size")
+			tags, _ := cmd.Flags().GetStringSlice("tag")
+			log = append(log, "delete "+args[0]+" "+size+" "+strings.Join(tags, ",")+" "+strconv.FormatBool(cmd.Flags().Changed("size")))
+			return nil
+		},
+	}
+	createCmd.Flags().String("size", "small", "size")
+	createCmd.Flags().StringSlice("tag", []string{"new"}, "tags")
+	failCmd := &Command{
+		Use:  "fail",
+		RunE: func(cmd *Command, args []string) error { return errors.New("boom") },
+	}
+	rootCmd.AddCommand(createCmd, failCmd)
+
+	rootCmd.ExecuteChain([][]string{
+		{"create", "a", "--size", "large", "--tag", "x"},
+		{"create", "b"},
+		{"create", "c", "--tag", "y", "--tag", "z"},
+		{"fail"},
+	})
+	expected := []string{"delete c small y,z false", "delete b small new false", "delete a large x true"}
+	if !reflect.DeepEqual(log, expected) {
+		t.Errorf("Expected %q, got %q", expected, log)
+	}
+}
+
+func TestExecuteChainResetsFlags(t *testing.T) {
+	var log []string
+	var tags []string
+	rootCmd := &Command{Use: "root", Run: emptyRun}
+	rootCmd.SetOutput(new(strings.Builder))
+	deployCmd := &Command{
+		Use: "deploy",
+		Run: func(cmd *Command, args []string) {
+			force, _ := cmd.Flags().GetBool("force")
+			log = append(log, strconv.FormatBool(force)+" "+strconv.FormatBool(cmd.Flags().Changed("force"))+" "+strings.Join(tags, ","))
+		},
+	}
+	deployCmd.Flags().Bool("force", false, "force")
+	deployCmd.Flags().StringSliceVar(&tags, "tag", []string{"new"}, "tags")
+	rootCmd.AddCommand(deployCmd)
+
+	err := rootCmd.ExecuteChain([][]string{
+		{"deploy", "--force", "--tag", "x"},
+		{"deploy"},
+		{"deploy", "--tag", "y"},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := []string{"true true x", "false false new", "false false y"}
+	if !reflect.DeepEqual(log, expected) {
+		t.Errorf("Expected %q, got %q", expected, log)
+	}
+}
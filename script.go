@@ -0,0 +1,130 @@
+package cobra
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ScriptError is the error returned by ExecuteScript for a line of the script.
+type ScriptError struct {
+	// Line is the number of the line, starting at 1. The line of an invocation continued
+	// on several lines is its first line.
+	Line int
+	// Err is the syntax error of the line, or the error of its execution.
+	Err error
+	// RollbackErrs holds the errors of the rollbacks of the previous lines, if any.
+	RollbackErrs []error
+}
+
+// Error returns the error of the line, prefixed by its number.
+func (e *ScriptError) Error() string {
+	return fmt.Sprintf("line %d: %v%s", e.Line, e.Err, rollbackErrsString(e.RollbackErrs))
+}
+
+// Unwrap returns the error of the line.
+func (e *ScriptError) Unwrap() error {
+	return e.Err
+}
+
+// ExecuteScript reads a script from r, one invocation of root per line given as the
+// arguments of the root command, and executes the invocations in order like
+// ExecuteChain: they share the context of root, each line only gets the flags it sets,
+// and the first failure stops the script and rolls back the previous invocations.
+// Words are separated by spaces and can be quoted like in a shell, "#" starts a comment
+// and a line ending with "\" continues on the next line. The script is checked before
+// executing anything. Errors are returned as a *ScriptError.
+func ExecuteScript(root *Command, r io.Reader) error {
+	var invocations [][]string
+	var lines []int
+	scanner := bufio.NewScanner(r)
+	number, first, pending := 0, 0, ""
+	for scanner.Scan() {
+		number++
+		line := scanner.Text()
+		if pending == "" {
+			first = number
+		}
+		if trimmed := strings.TrimRight(line, " \t"); continuedLine(trimmed) {
+			pending += trimmed[:len(trimmed)-1] + " "
+			continue
+		}
+		args, err := splitWords(pending + line)
+		pending = ""
+		if err != nil {
+			return &ScriptError{Line: first, Err: err}
+		}
+		if len(args) > 0 {
+			invocations = append(invocations, args)
+			lines = append(lines, first)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	if pending != "" {
+		return &ScriptError{Line: first, Err: fmt.Errorf("missing continuation line")}
+	}
+
+	if err := root.ExecuteChain(invocations); err != nil {
+		chainErr := err.(*ChainError)
+		return &ScriptError{Line: lines[chainErr.Index], Err: chainErr.Err, RollbackErrs: chainErr.RollbackErrs}
+	}
+	return nil
+}
+
+// continuedLine returns whether line ends with an unescaped backslash.
+func continuedLine(line string) bool {
+	n := 0
+	for i := len(line) - 1; i >= 0 && line[i] == '\\'; i-- {
+		n++
+	}
+	return n%2 == 1
+}
+
+// splitWords splits s into words like a shell does: words are separated by spaces,
+// single quotes preserve their content, double quotes preserve their content except
+// for backslash escapes, backslashes escape the next character and "#" at the start
+// of a word starts a comment.
+func splitWords(s string) ([]string, error) {
+	var words []string
+	var word strings.Builder
+	inWord := false
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		ch := s[i]
+		switch {
+		case quote == '\'' && ch == '\'', quote == '"' && ch == '"':
+			quote = 0
+		case quote == '\'':
+			word.WriteByte(ch)
+		case ch == '\\' && i+1 < len(s):
+			i++
+			word.WriteByte(s[i])
+			inWord = true
+		case quote == '"':
+			word.WriteByte(ch)
+		case ch == '\'' || ch == '"':
+			quote, inWord = ch, true
+		case ch == ' ' || ch == '\t':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		case ch == '#' && !inWord:
+			i = len(s)
+		default:
+			word.WriteByte(ch)
+			inWord = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated quote %c", quote)
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words, nil
+}
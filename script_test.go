@@ -0,0 +1,89 @@
+package cobra
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestExecuteScript(t *testing.T) {
+	var log []string
+	rootCmd := &Command{Use: "root", Run: emptyRun}
+	rootCmd.SetOutput(new(strings.Builder))
+	echoCmd := &Command{
+		Use: "echo",
+		Run: func(cmd *Command, args []string) {
+			log = append(log, strings.Join(args, "|"))
+		},
+		RollbackE: func(cmd *Command, args []string) error {
+			log = append(log, "undo "+strings.Join(args, "|"))
+			return nil
+		},
+	}
+	failCmd := &Command{
+		Use:  "fail",
+		RunE: func(cmd *Command, args []string) error { return errors.New("boom") },
+	}
+	rootCmd.AddCommand(echoCmd, failCmd)
+
+	script := `# provisioning
+echo a 'b c' "d \"e\"" # trailing comment
+
+echo f \
+    g
+`
+	if err := ExecuteScript(rootCmd, strings.NewReader(script)); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := []string{`a|b c|d "e"`, "f|g"}
+	if !reflect.DeepEqual(log, expected) {
+		t.Errorf("Expected %q, got %q", expected, log)
+	}
+
+	log = nil
+	err := ExecuteScript(rootCmd, strings.NewReader("echo a\n\nfail\necho b\n"))
+	if err == nil || err.Error() != "line 3: boom" {
+		t.Errorf("Expected the error of line 3, got %v", err)
+	}
+	expected = []string{"a", "undo a"}
+	if !reflect.DeepEqual(log, expected) {
+		t.Errorf("Expected %q, got %q", expected, log)
+	}
+
+	log = nil
+	err = ExecuteScript(rootCmd, strings.NewReader("echo a\necho 'b\n"))
+	if err == nil || err.Error() != "line 2: unterminated quote '" {
+		t.Errorf("Expected a syntax error of line 2, got %v", err)
+	}
+	if len(log) != 0 {
+		t.Errorf("Expected nothing to be executed, got %q", log)
+	}
+}
+
+func TestExecuteScriptResetsFlags(t *testing.T) {
+	var log []string
+	rootCmd := &Command{Use: "root", Run: emptyRun}
+	rootCmd.SetOutput(new(strings.Builder))
+	deployCmd := &Command{
+		Use: "deploy",
+		Run: func(cmd *Command, args []string) {
+			env, _ := cmd.Flags().GetString("env")
+			force, _ := cmd.Flags().GetBool("force")
+			log = append(log, fmt.Sprintf("%s %s %v", args[0], env, force))
+		},
+	}
+	deployCmd.Flags().String("env", "staging", "environment")
+	deployCmd.Flags().Bool("force", false, "force")
+	rootCmd.AddCommand(deployCmd)
+
+	script := "deploy a --env prod --force\ndeploy b\ndeploy c --force\n"
+	if err := ExecuteScript(rootCmd, strings.NewReader(script)); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := []string{"a prod true", "b staging false", "c staging true"}
+	if !reflect.DeepEqual(log, expected) {
+		t.Errorf("Expected %q, got %q", expected, log)
+	}
+}
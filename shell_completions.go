@@ -1,9 +1,37 @@
 package cobra
 
 import (
+	"sort"
+	"strings"
+
 	"github.com/spf13/pflag"
 )
 
+// Annotations of commands holding shell specific snippets, which the generator of the
+// shell inserts as is in the completion function of the command. E.g. a zsh _message
+// hint: cmd.Annotations[ZshCompSnippet] = "_message 'resource name'".
+const (
+	BashCompSnippet       = "cobra_annotation_bash_completion_snippet"
+	ZshCompSnippet        = "cobra_annotation_zsh_completion_snippet"
+	PowerShellCompSnippet = "cobra_annotation_powershell_completion_snippet"
+)
+
+// completionSnippet returns the snippet of the command for the annotation key, with
+// each line indented, followed by a newline, or an empty string.
+func completionSnippet(cmd *Command, key, indent string) string {
+	snippet := strings.TrimRight(cmd.Annotations[key], "\n")
+	if snippet == "" {
+		return ""
+	}
+	return indent + strings.Replace(snippet, "\n", "\n"+indent, -1) + "\n"
+}
+
+// hasOptionalValue returns true if the flag takes a value only in the --flag=value form,
+// as it has a NoOptDefVal and is not a bool flag.
+func hasOptionalValue(f *pflag.Flag) bool {
+	return f.NoOptDefVal != "" && f.Value.Type() != "bool"
+}
+
 // MarkFlagRequired adds the BashCompOneRequiredFlag annotation to the named flag if it exists,
 // and causes your command to report an error if invoked without the flag.
 func (c *Command) MarkFlagRequired(name string) error {
@@ -62,7 +90,7 @@ func MarkFlagCustom(flags *pflag.FlagSet, name string, f string) error {
 // MarkFlagDirname instructs the various shell completion implementations to
 // complete only directories with this named flag.
 //
-// Shell Completion compatibility matrix: zsh
+// Shell Completion compatibility matrix: bash, zsh
 func (c *Command) MarkFlagDirname(name string) error {
 	return MarkFlagDirname(c.Flags(), name)
 }
@@ -70,7 +98,7 @@ func (c *Command) MarkFlagDirname(name string) error {
 // MarkPersistentFlagDirname instructs the various shell completion
 // implementations to complete only directories with this persistent named flag.
 //
-// Shell Completion compatibility matrix: zsh
+// Shell Completion compatibility matrix: bash, zsh
 func (c *Command) MarkPersistentFlagDirname(name string) error {
 	return MarkFlagDirname(c.PersistentFlags(), name)
 }
@@ -78,8 +106,168 @@ func (c *Command) MarkPersistentFlagDirname(name string) error {
 // MarkFlagDirname instructs the various shell completion implementations to
 // complete only directories with this specified flag.
 //
-// Shell Completion compatibility matrix: zsh
+// Shell Completion compatibility matrix: bash, zsh
 func MarkFlagDirname(flags *pflag.FlagSet, name string) error {
-	zshPattern := "-(/)"
-	return flags.SetAnnotation(name, zshCompDirname, []string{zshPattern})
+	return flags.SetAnnotation(name, BashCompSubdirsInDir, []string{})
+}
+
+// MarkFlagSubdirsInDir instructs the various shell completion implementations to
+// complete only the subdirectories of dir with this named flag, e.g. the themes of
+// a themes directory.
+//
+// Shell Completion compatibility matrix: bash, zsh
+func (c *Command) MarkFlagSubdirsInDir(name, dir string) error {
+	return MarkFlagSubdirsInDir(c.Flags(), name, dir)
+}
+
+// MarkPersistentFlagSubdirsInDir instructs the various shell completion
+// implementations to complete only the subdirectories of dir with this persistent
+// named flag.
+//
+// Shell Completion compatibility matrix: bash, zsh
+func (c *Command) MarkPersistentFlagSubdirsInDir(name, dir string) error {
+	return MarkFlagSubdirsInDir(c.PersistentFlags(), name, dir)
+}
+
+// MarkFlagSubdirsInDir instructs the various shell completion implementations to
+// complete only the subdirectories of dir with this specified flag.
+//
+// Shell Completion compatibility matrix: bash, zsh
+func MarkFlagSubdirsInDir(flags *pflag.FlagSet, name, dir string) error {
+	return flags.SetAnnotation(name, BashCompSubdirsInDir, []string{dir})
+}
+
+// FlagRepeatable is the annotation of flags which can be given several times, like
+// --set key=value, so that the completion keeps suggesting them once given.
+// Slice and array flags are repeatable without the annotation.
+const FlagRepeatable = "cobra_annotation_flag_repeatable"
+
+// MarkFlagRepeatable adds the FlagRepeatable annotation to the named flag if it exists.
+// The values of each occurrence are completed as configured for the flag, e.g. by
+// MarkFlagCustom.
+//
+// Shell Completion compatibility matrix: zsh (bash and PowerShell always suggest flags again)
+func (c *Command) MarkFlagRepeatable(name string) error {
+	return MarkFlagRepeatable(c.Flags(), name)
+}
+
+// MarkPersistentFlagRepeatable adds the FlagRepeatable annotation to the named persistent
+// flag if it exists.
+//
+// Shell Completion compatibility matrix: zsh (bash and PowerShell always suggest flags again)
+func (c *Command) MarkPersistentFlagRepeatable(name string) error {
+	return MarkFlagRepeatable(c.PersistentFlags(), name)
+}
+
+// MarkFlagRepeatable adds the FlagRepeatable annotation to the named flag if it exists.
+//
+// Shell Completion compatibility matrix: zsh (bash and PowerShell always suggest flags again)
+func MarkFlagRepeatable(flags *pflag.FlagSet, name string) error {
+	return flags.SetAnnotation(name, FlagRepeatable, []string{"true"})
+}
+
+// MarkFlagKeyValue makes the completion of the named flag, e.g. --set or --label, offer
+// the given keys followed by "=", then the values of the key once its "=" is typed.
+// A key without values only completes up to its "=". Keys and values can't hold
+// whitespace.
+//
+// Shell Completion compatibility matrix: bash
+func (c *Command) MarkFlagKeyValue(name string, keys map[string][]string) error {
+	return MarkFlagKeyValue(c.Flags(), name, keys)
+}
+
+// MarkPersistentFlagKeyValue makes the completion of the named persistent flag offer
+// keys, then their values. See MarkFlagKeyValue.
+//
+// Shell Completion compatibility matrix: bash
+func (c *Command) MarkPersistentFlagKeyValue(name string, keys map[string][]string) error {
+	return MarkFlagKeyValue(c.PersistentFlags(), name, keys)
+}
+
+// MarkFlagKeyValue makes the completion of the named flag offer keys, then their values.
+// See Command.MarkFlagKeyValue.
+//
+// Shell Completion compatibility matrix: bash
+func MarkFlagKeyValue(flags *pflag.FlagSet, name string, keys map[string][]string) error {
+	var entries []string
+	for key, values := range keys {
+		if len(values) == 0 {
+			entries = append(entries, key+"=")
+		}
+		for _, value := range values {
+			entries = append(entries, key+"="+value)
+		}
+	}
+	sort.Strings(entries)
+	return flags.SetAnnotation(name, BashCompKeyValue, entries)
+}
+
+// CompletionKind is a common kind of flag values, completed by MarkFlagCompletionKind.
+type CompletionKind string
+
+// Kinds of flag values completed by MarkFlagCompletionKind.
+const (
+	// CompleteHosts completes the host names of the ssh configuration and known hosts.
+	CompleteHosts CompletionKind = "hosts"
+	// CompleteURLsFromHistory completes the http and https URLs of the shell history.
+	CompleteURLsFromHistory CompletionKind = "urls"
+	// CompleteEnvVars completes the names of the environment variables.
+	CompleteEnvVars CompletionKind = "env-vars"
+	// CompleteUsers completes the names of the users.
+	CompleteUsers CompletionKind = "users"
+	// CompleteGroups completes the names of the groups.
+	CompleteGroups CompletionKind = "groups"
+	// CompleteDurations completes common durations, like 30s or 5m. It is the default
+	// of duration flags.
+	CompleteDurations CompletionKind = "durations"
+	// CompleteTimestamps completes now, today and the current time in RFC3339 format.
+	// It is the default of TimeValue flags.
+	CompleteTimestamps CompletionKind = "timestamps"
+)
+
+// FlagCompletionKind is the annotation of flags whose values are of a CompletionKind.
+const FlagCompletionKind = "cobra_annotation_flag_completion_kind"
+
+// MarkFlagCompletionKind makes the completion of the named flag offer the values of the
+// given kind, e.g. host names for a --host flag.
+//
+// Shell Completion compatibility matrix: bash, zsh
+func (c *Command) MarkFlagCompletionKind(name string, kind CompletionKind) error {
+	return MarkFlagCompletionKind(c.Flags(), name, kind)
+}
+
+// MarkPersistentFlagCompletionKind makes the completion of the named persistent flag
+// offer the values of the given kind.
+//
+// Shell Completion compatibility matrix: bash, zsh
+func (c *Command) MarkPersistentFlagCompletionKind(name string, kind CompletionKind) error {
+	return MarkFlagCompletionKind(c.PersistentFlags(), name, kind)
+}
+
+// MarkFlagCompletionKind makes the completion of the named flag offer the values of the
+// given kind.
+//
+// Shell Completion compatibility matrix: bash, zsh
+func MarkFlagCompletionKind(flags *pflag.FlagSet, name string, kind CompletionKind) error {
+	return flags.SetAnnotation(name, FlagCompletionKind, []string{string(kind)})
+}
+
+// flagCompletionKind returns the kind of the values of the flag: the one it was marked
+// with, or the default of its type if it has no other completion annotation.
+func flagCompletionKind(f *pflag.Flag) CompletionKind {
+	if kind := f.Annotations[FlagCompletionKind]; len(kind) > 0 {
+		return CompletionKind(kind[0])
+	}
+	for _, key := range []string{BashCompFilenameExt, BashCompCustom, BashCompSubdirsInDir, BashCompKeyValue} {
+		if _, ok := f.Annotations[key]; ok {
+			return ""
+		}
+	}
+	switch f.Value.Type() {
+	case "duration":
+		return CompleteDurations
+	case "time":
+		return CompleteTimestamps
+	}
+	return ""
 }
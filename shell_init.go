@@ -0,0 +1,100 @@
+package cobra
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// ShellInitOptions configures the shell init snippets generated by GenShellInit.
+type ShellInitOptions struct {
+	// Aliases are the shell aliases to define, by name, e.g. "k": "mycli kubernetes".
+	Aliases map[string]string
+	// Env are the environment variables to export, by name.
+	Env map[string]string
+	// CommandNotFound adds the handler of a command not found suggesting the
+	// subcommands, see GenBashCommandNotFoundHandler.
+	CommandNotFound bool
+}
+
+// AddShellInitCommand adds the "generate-shell-init [bash|zsh]" command to c, printing
+// the snippet generated by GenShellInit, so that users get the whole shell integration
+// with one line in their shell init file, e.g. in ~/.bashrc:
+//
+//	eval "$(mycli generate-shell-init bash)"
+func (c *Command) AddShellInitCommand(opts ShellInitOptions) {
+	c.AddCommand(&Command{
+		Use:       "generate-shell-init [bash|zsh]",
+		Short:     "Generate the shell integration, to evaluate in the shell init file",
+		ValidArgs: []string{"bash", "zsh"},
+		Args:      ExactValidArgs(1),
+		RunE: func(cmd *Command, args []string) error {
+			return cmd.GenShellInit(cmd.OutOrStdout(), args[0], opts)
+		},
+	})
+}
+
+// GenShellInit generates the shell init snippet of the root command for shell, "bash"
+// or "zsh", and writes it to the passed writer: the exports of opts.Env, the aliases
+// of opts.Aliases, the completion of the root command and, optionally, the handler of
+// a command not found.
+func (c *Command) GenShellInit(w io.Writer, shell string, opts ShellInitOptions) error {
+	root := c.Root()
+	buf := new(bytes.Buffer)
+	buf.WriteString(fmt.Sprintf("# %s init for %s\n\n", shell, root.Name()))
+
+	for _, name := range sortedKeys(opts.Env) {
+		buf.WriteString(fmt.Sprintf("export %s=%s\n", name, shellSingleQuote(opts.Env[name])))
+	}
+	for _, name := range sortedKeys(opts.Aliases) {
+		buf.WriteString(fmt.Sprintf("alias %s=%s\n", name, shellSingleQuote(opts.Aliases[name])))
+	}
+	if len(opts.Env) > 0 || len(opts.Aliases) > 0 {
+		buf.WriteString("\n")
+	}
+
+	var err error
+	switch shell {
+	case "bash":
+		err = root.GenBashCompletion(buf)
+		if err == nil && opts.CommandNotFound {
+			buf.WriteString("\n")
+			err = root.GenBashCommandNotFoundHandler(buf)
+		}
+	case "zsh":
+		err = root.GenZshCompletion(buf)
+		if err == nil {
+			// The completion is evaluated rather than autoloaded, it must be registered.
+			buf.WriteString(fmt.Sprintf("\nif (( $+functions[compdef] )); then\n    compdef _%s %s\nfi\n", root.Name(), root.Name()))
+		}
+		if err == nil && opts.CommandNotFound {
+			buf.WriteString("\n")
+			err = root.GenZshCommandNotFoundHandler(buf)
+		}
+	default:
+		return fmt.Errorf("unsupported shell %q, expected one of: bash, zsh", shell)
+	}
+	if err != nil {
+		return err
+	}
+
+	_, err = buf.WriteTo(w)
+	return err
+}
+
+// sortedKeys returns the keys of m in order.
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// shellSingleQuote quotes s for bash and zsh.
+func shellSingleQuote(s string) string {
+	return "'" + strings.Replace(s, "'", `'\''`, -1) + "'"
+}
@@ -0,0 +1,39 @@
+package cobra
+
+import (
+	"testing"
+)
+
+func TestShellInitCommand(t *testing.T) {
+	rootCmd := &Command{Use: "mycli", Run: emptyRun}
+	rootCmd.AddCommand(&Command{Use: "deploy", Run: emptyRun})
+	rootCmd.AddShellInitCommand(ShellInitOptions{
+		Aliases:         map[string]string{"md": "mycli deploy"},
+		Env:             map[string]string{"MYCLI_MOTTO": "it's fine"},
+		CommandNotFound: true,
+	})
+
+	output, err := executeCommand(rootCmd, "generate-shell-init", "bash")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	check(t, output, "export MYCLI_MOTTO='it'\\''s fine'\n")
+	check(t, output, "alias md='mycli deploy'\n")
+	check(t, output, "complete -o default -F __start_mycli mycli")
+	check(t, output, "command_not_found_handle() {")
+
+	rootCmd = &Command{Use: "mycli", Run: emptyRun}
+	rootCmd.AddShellInitCommand(ShellInitOptions{})
+	output, err = executeCommand(rootCmd, "generate-shell-init", "zsh")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	check(t, output, "#compdef _mycli mycli")
+	check(t, output, "    compdef _mycli mycli\n")
+	checkOmit(t, output, "alias")
+	checkOmit(t, output, "command_not_found_handler")
+
+	if _, err := executeCommand(rootCmd, "generate-shell-init", "fish"); err == nil {
+		t.Error("Expected an error for an unsupported shell")
+	}
+}
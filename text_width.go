@@ -0,0 +1,117 @@
+package cobra
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// wideRanges are the ranges of the runes displayed on two columns by terminals: the
+// East Asian wide and fullwidth characters, e.g. Chinese, Japanese and Korean, and
+// the emojis.
+var wideRanges = &unicode.RangeTable{
+	R16: []unicode.Range16{
+		{Lo: 0x1100, Hi: 0x115f, Stride: 1},
+		{Lo: 0x2e80, Hi: 0x303e, Stride: 1},
+		{Lo: 0x3041, Hi: 0x33ff, Stride: 1},
+		{Lo: 0x3400, Hi: 0x4dbf, Stride: 1},
+		{Lo: 0x4e00, Hi: 0x9fff, Stride: 1},
+		{Lo: 0xa000, Hi: 0xa4cf, Stride: 1},
+		{Lo: 0xac00, Hi: 0xd7a3, Stride: 1},
+		{Lo: 0xf900, Hi: 0xfaff, Stride: 1},
+		{Lo: 0xfe30, Hi: 0xfe4f, Stride: 1},
+		{Lo: 0xff00, Hi: 0xff60, Stride: 1},
+		{Lo: 0xffe0, Hi: 0xffe6, Stride: 1},
+	},
+	R32: []unicode.Range32{
+		{Lo: 0x1f300, Hi: 0x1f64f, Stride: 1},
+		{Lo: 0x1f900, Hi: 0x1f9ff, Stride: 1},
+		{Lo: 0x20000, Hi: 0x3fffd, Stride: 1},
+	},
+}
+
+// displayWidth returns the number of terminal columns taken by s: wide runes take two
+// columns, and combining marks, format characters and ANSI escape sequences, e.g.
+// colors, none.
+func displayWidth(s string) int {
+	width := 0
+	for i := 0; i < len(s); {
+		if n := ansiSequenceLen(s[i:]); n > 0 {
+			i += n
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		i += size
+		switch {
+		case unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf):
+		case unicode.Is(wideRanges, r):
+			width += 2
+		default:
+			width++
+		}
+	}
+	return width
+}
+
+// ansiSequenceLen returns the length of the ANSI escape sequence s starts with, or 0:
+// a control sequence like "\x1b[1;31m", or an operating system command like the
+// hyperlinks "\x1b]8;;url\x1b\\", terminated by BEL or ST.
+func ansiSequenceLen(s string) int {
+	if len(s) < 2 || s[0] != '\x1b' {
+		return 0
+	}
+	switch s[1] {
+	case '[':
+		for i := 2; i < len(s); i++ {
+			if s[i] >= 0x40 && s[i] <= 0x7e {
+				return i + 1
+			}
+		}
+	case ']':
+		for i := 2; i < len(s); i++ {
+			if s[i] == '\a' {
+				return i + 1
+			}
+			if s[i] == '\x1b' && i+1 < len(s) && s[i+1] == '\\' {
+				return i + 2
+			}
+		}
+	default:
+		return 0
+	}
+	return len(s)
+}
+
+// wrap wraps the words of the lines of s to width columns, counting them by display
+// width, and keeps the indentation of the lines. It is available to templates, e.g.
+// {{wrap 80 .Long}}. Words longer than width are not split, and a width below 1
+// doesn't wrap.
+func wrap(width int, s string) string {
+	if width < 1 {
+		return s
+	}
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		indent := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+		var b strings.Builder
+		b.WriteString(indent)
+		start := displayWidth(indent)
+		col := start
+		for j, word := range strings.Fields(line) {
+			w := displayWidth(word)
+			switch {
+			case j == 0:
+			case col+1+w > width:
+				b.WriteString("\n" + indent)
+				col = start
+			default:
+				b.WriteByte(' ')
+				col++
+			}
+			b.WriteString(word)
+			col += w
+		}
+		lines[i] = b.String()
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,70 @@
+package cobra
+
+import (
+	"fmt"
+	"time"
+
+	flag "github.com/spf13/pflag"
+)
+
+// timeNow returns the current time, it is overridden by tests.
+var timeNow = time.Now
+
+// TimeValue is a flag value holding a point in time, given in RFC3339 format, as a
+// date like 2006-01-02 in the local time zone, or as "now" or "today". Its flags
+// complete timestamps, see CompleteTimestamps:
+//
+//	var since time.Time
+//	cmd.Flags().Var(cobra.NewTimeValue(time.Time{}, &since), "since", "show events since this time")
+type TimeValue struct {
+	p *time.Time
+}
+
+// NewTimeValue returns a TimeValue storing its value in p, which is set to val.
+func NewTimeValue(val time.Time, p *time.Time) *TimeValue {
+	*p = val
+	return &TimeValue{p: p}
+}
+
+// Set parses the time.
+func (v *TimeValue) Set(s string) error {
+	now := timeNow()
+	switch s {
+	case "now":
+		*v.p = now
+		return nil
+	case "today":
+		y, m, d := now.Date()
+		*v.p = time.Date(y, m, d, 0, 0, 0, 0, now.Location())
+		return nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		*v.p = t
+		return nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", s, now.Location()); err == nil {
+		*v.p = t
+		return nil
+	}
+	return fmt.Errorf("expected a time in RFC3339 format (%s), a date (2006-01-02), now or today", time.RFC3339)
+}
+
+// String returns the time in RFC3339 format, or an empty string for the zero time.
+func (v *TimeValue) String() string {
+	if v.p.IsZero() {
+		return ""
+	}
+	return v.p.Format(time.RFC3339)
+}
+
+// Type returns "time".
+func (v *TimeValue) Type() string {
+	return "time"
+}
+
+// Clone returns a copy of the value storing its value in a new time.Time, so that
+// Clone and Instantiate copy TimeValue flags.
+func (v *TimeValue) Clone() flag.Value {
+	t := *v.p
+	return &TimeValue{p: &t}
+}
@@ -0,0 +1,69 @@
+package cobra
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestTimeValue(t *testing.T) {
+	now := time.Date(2019, 7, 14, 15, 4, 5, 0, time.UTC)
+	timeNow = func() time.Time { return now }
+	defer func() { timeNow = time.Now }()
+
+	tests := []struct {
+		value    string
+		expected time.Time
+	}{
+		{"now", now},
+		{"today", time.Date(2019, 7, 14, 0, 0, 0, 0, time.UTC)},
+		{"2019-01-02T03:04:05Z", time.Date(2019, 1, 2, 3, 4, 5, 0, time.UTC)},
+		{"2019-01-02", time.Date(2019, 1, 2, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tc := range tests {
+		var since time.Time
+		c := &Command{Use: "c", Run: emptyRun}
+		c.Flags().Var(NewTimeValue(time.Time{}, &since), "since", "")
+
+		if _, err := executeCommand(c, "--since", tc.value); err != nil {
+			t.Errorf("%s: unexpected error: %v", tc.value, err)
+			continue
+		}
+		if !since.Equal(tc.expected) {
+			t.Errorf("%s: expected %v, got %v", tc.value, tc.expected, since)
+		}
+	}
+
+	var since time.Time
+	c := &Command{Use: "c", Run: emptyRun}
+	c.Flags().Var(NewTimeValue(time.Time{}, &since), "since", "")
+	output, err := executeCommand(c, "--since", "yesterday")
+	if err == nil {
+		t.Fatal("Expected error for an invalid time")
+	}
+	checkStringContains(t, output, `invalid argument "yesterday" for "--since" flag: expected a time in RFC3339 format`)
+}
+
+func TestTimeAndDurationCompletion(t *testing.T) {
+	c := &Command{Use: "c", Run: emptyRun}
+	var since time.Time
+	c.Flags().Var(NewTimeValue(time.Time{}, &since), "since", "")
+	c.Flags().Duration("timeout", 0, "")
+	c.Flags().Duration("interval", 0, "")
+	c.MarkFlagCustom("interval", "__c_intervals")
+
+	buf := new(bytes.Buffer)
+	c.GenBashCompletion(buf)
+	output := buf.String()
+
+	check(t, output, `flags_completion+=("__c_handle_completion_kind_flag timestamps")`)
+	check(t, output, `flags_completion+=("__c_handle_completion_kind_flag durations")`)
+	check(t, output, `flags_completion+=("__c_intervals")`)
+
+	buf.Reset()
+	c.GenZshCompletion(buf)
+	output = buf.String()
+
+	check(t, output, `'--timeout[]:duration:((30s\:"30 seconds"`)
+	check(t, output, `'--since[]:time:((now\:"current time"`)
+}
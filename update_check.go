@@ -0,0 +1,191 @@
+package cobra
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// UpdateCheckOptions configures the update check added by AddUpdateCheck.
+type UpdateCheckOptions struct {
+	// Latest returns the latest released version, e.g. by querying a release endpoint.
+	Latest func() (string, error)
+	// StateFile is the file remembering the last check and its result between
+	// executions, e.g. under os.UserCacheDir.
+	StateFile string
+	// Interval is the minimum duration between two calls to Latest, 24 hours by default.
+	Interval time.Duration
+	// Timeout is the maximum duration of a call to Latest, 2 seconds by default. Latest
+	// is called in the background when the execution starts; a call still running
+	// when the command has finished and Timeout has elapsed is given up.
+	Timeout time.Duration
+	// Skip returns true for the executions which must not print the notice, e.g. the
+	// ones writing JSON. By default, the executions whose stderr is not a terminal,
+	// e.g. scripts or the generation of completion scripts, are skipped.
+	Skip func(cmd *Command) bool
+}
+
+// updateCheckState is the content of the StateFile of an update check.
+type updateCheckState struct {
+	CheckedAt time.Time `json:"checked_at"`
+	Latest    string    `json:"latest"`
+}
+
+// pendingUpdateCheck is a call to Latest running in the background.
+type pendingUpdateCheck struct {
+	result   chan latestVersion
+	deadline time.Time
+}
+
+// latestVersion is the result of a call to Latest.
+type latestVersion struct {
+	version string
+	err     error
+}
+
+// AddUpdateCheck adds the persistent --no-update-check flag to c and makes the
+// successful executions of its subtree print a notice to stderr, after the output of
+// the command, when a version newer than the Version of the root command is released.
+// The released version is checked at most once per Interval, along with the command;
+// errors and timeouts of the check are ignored so that it never gets in the way.
+func (c *Command) AddUpdateCheck(opts UpdateCheckOptions) {
+	if opts.Interval <= 0 {
+		opts.Interval = 24 * time.Hour
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = 2 * time.Second
+	}
+	c.updateCheck = &opts
+	c.PersistentFlags().Bool("no-update-check", false, "don't check for a new version")
+}
+
+// findUpdateCheck returns the options given to AddUpdateCheck by c or a parent, or nil.
+func (c *Command) findUpdateCheck() *UpdateCheckOptions {
+	for p := c; p != nil; p = p.parent {
+		if p.updateCheck != nil {
+			return p.updateCheck
+		}
+	}
+	return nil
+}
+
+// activeUpdateCheck returns the options of the update check of c, or nil if the
+// execution of c must not check for updates.
+func (c *Command) activeUpdateCheck() *UpdateCheckOptions {
+	opts := c.findUpdateCheck()
+	if opts == nil || opts.Latest == nil || c.Root().Version == "" {
+		return nil
+	}
+	if disabled, err := c.Flags().GetBool("no-update-check"); err == nil && disabled {
+		return nil
+	}
+	skip := opts.Skip
+	if skip == nil {
+		skip = func(c *Command) bool {
+			f, ok := c.ErrOrStderr().(*os.File)
+			return !ok || !isTerminal(f)
+		}
+	}
+	if skip(c) {
+		return nil
+	}
+	return opts
+}
+
+func readUpdateCheckState(opts *UpdateCheckOptions) updateCheckState {
+	var state updateCheckState
+	if b, err := ioutil.ReadFile(opts.StateFile); err == nil {
+		json.Unmarshal(b, &state)
+	}
+	return state
+}
+
+// startUpdateCheck calls Latest in the background if the released version is due to
+// be checked, so that the check runs along with the command. See checkForUpdate.
+func (c *Command) startUpdateCheck() {
+	c.pendingUpdate = nil
+	opts := c.activeUpdateCheck()
+	if opts == nil || timeNow().Sub(readUpdateCheckState(opts).CheckedAt) < opts.Interval {
+		return
+	}
+	pending := &pendingUpdateCheck{
+		result:   make(chan latestVersion, 1),
+		deadline: time.Now().Add(opts.Timeout),
+	}
+	go func() {
+		version, err := opts.Latest()
+		pending.result <- latestVersion{version, err}
+	}()
+	c.pendingUpdate = pending
+}
+
+// checkForUpdate prints the notice of the update check, if a newer version is known.
+// It waits for the check started by startUpdateCheck until its deadline.
+func (c *Command) checkForUpdate() {
+	opts := c.activeUpdateCheck()
+	pending := c.pendingUpdate
+	c.pendingUpdate = nil
+	if opts == nil {
+		return
+	}
+
+	state := readUpdateCheckState(opts)
+	if pending != nil {
+		timer := time.NewTimer(time.Until(pending.deadline))
+		select {
+		case latest := <-pending.result:
+			if latest.err == nil {
+				state.Latest = latest.version
+			}
+		case <-timer.C:
+		}
+		timer.Stop()
+		// A failed check is not retried before the interval elapses either.
+		state.CheckedAt = timeNow()
+		if b, err := json.Marshal(state); err == nil && os.MkdirAll(filepath.Dir(opts.StateFile), 0700) == nil {
+			ioutil.WriteFile(opts.StateFile, b, 0600)
+		}
+	}
+	if current := c.Root().Version; compareVersions(state.Latest, current) > 0 {
+		fmt.Fprintf(c.ErrOrStderr(), "\nA new version of %s is available: %s (current: %s)\n",
+			c.Root().Name(), state.Latest, current)
+	}
+}
+
+// compareVersions compares the dot-separated versions a and b, with an optional "v"
+// prefix, number by number, e.g. "v1.10.0" > "1.9.2". It returns a negative number,
+// zero or a positive number when a is older, equal or newer. Suffixes like "-rc1" are
+// ignored.
+func compareVersions(a, b string) int {
+	parse := func(v string) []int {
+		v = strings.TrimPrefix(v, "v")
+		if i := strings.IndexAny(v, "-+"); i >= 0 {
+			v = v[:i]
+		}
+		var numbers []int
+		for _, s := range strings.Split(v, ".") {
+			n, _ := strconv.Atoi(s)
+			numbers = append(numbers, n)
+		}
+		return numbers
+	}
+	x, y := parse(a), parse(b)
+	for i := 0; i < len(x) || i < len(y); i++ {
+		var m, n int
+		if i < len(x) {
+			m = x[i]
+		}
+		if i < len(y) {
+			n = y[i]
+		}
+		if m != n {
+			return m - n
+		}
+	}
+	return 0
+}
@@ -0,0 +1,129 @@
+package cobra
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestUpdateCheck(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cobra-update-check")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	now := time.Date(2020, 5, 1, 12, 0, 0, 0, time.UTC)
+	timeNow = func() time.Time { return now }
+	defer func() { timeNow = time.Now }()
+
+	calls := 0
+	latest, latestErr := "v1.3.0", error(nil)
+	newRoot := func() *Command {
+		rootCmd := &Command{Use: "root", Version: "1.2.5"}
+		rootCmd.AddUpdateCheck(UpdateCheckOptions{
+			Latest: func() (string, error) {
+				calls++
+				return latest, latestErr
+			},
+			StateFile: filepath.Join(dir, "state", "update-check.json"),
+			Interval:  time.Hour,
+			Skip:      func(cmd *Command) bool { return false },
+		})
+		rootCmd.AddCommand(&Command{Use: "child", Run: func(cmd *Command, args []string) { cmd.Print("output") }})
+		return rootCmd
+	}
+	notice := "output\nA new version of root is available: v1.3.0 (current: 1.2.5)\n"
+
+	output, err := executeCommand(newRoot(), "child")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if output != notice {
+		t.Errorf("Expected %q, got %q", notice, output)
+	}
+
+	// The result of the last check is remembered until the interval elapses.
+	now = now.Add(30 * time.Minute)
+	latestErr = errors.New("offline")
+	if output, _ := executeCommand(newRoot(), "child"); output != notice {
+		t.Errorf("Expected %q, got %q", notice, output)
+	}
+	if calls != 1 {
+		t.Errorf("Expected 1 check, got %d", calls)
+	}
+
+	if output, _ := executeCommand(newRoot(), "child", "--no-update-check"); output != "output" {
+		t.Errorf("Expected no notice with --no-update-check, got %q", output)
+	}
+
+	now = now.Add(time.Hour)
+	latest, latestErr = "1.2.5", nil
+	if output, _ := executeCommand(newRoot(), "child"); output != "output" {
+		t.Errorf("Expected no notice for the current version, got %q", output)
+	}
+	if calls != 2 {
+		t.Errorf("Expected 2 checks, got %d", calls)
+	}
+}
+
+func TestUpdateCheckInBackground(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cobra-update-check")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	started, release := make(chan struct{}), make(chan struct{})
+	defer close(release)
+	stateFile := filepath.Join(dir, "update-check.json")
+	rootCmd := &Command{Use: "root", Version: "1.2.5"}
+	rootCmd.AddUpdateCheck(UpdateCheckOptions{
+		Latest: func() (string, error) {
+			close(started)
+			<-release
+			return "v1.3.0", nil
+		},
+		StateFile: stateFile,
+		Timeout:   10 * time.Millisecond,
+		Skip:      func(cmd *Command) bool { return false },
+	})
+	rootCmd.AddCommand(&Command{Use: "child", Run: func(cmd *Command, args []string) {
+		// The check runs along with the command.
+		select {
+		case <-started:
+		case <-time.After(time.Second):
+			t.Error("Expected the check to start before the command ends")
+		}
+		cmd.Print("output")
+	}})
+
+	// A check outliving its timeout is given up, and not retried before the interval.
+	if output, err := executeCommand(rootCmd, "child"); output != "output" || err != nil {
+		t.Errorf("Expected no notice and no error, got %q and %v", output, err)
+	}
+	if _, err := os.Stat(stateFile); err != nil {
+		t.Errorf("Expected the check to be recorded: %v", err)
+	}
+}
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		sign int
+	}{
+		{"v1.10.0", "1.9.2", 1},
+		{"1.2", "1.2.0", 0},
+		{"1.2.0-rc1", "1.2.1", -1},
+		{"", "1.0", -1},
+	}
+	for _, tc := range tests {
+		c := compareVersions(tc.a, tc.b)
+		if (c > 0) != (tc.sign > 0) || (c < 0) != (tc.sign < 0) {
+			t.Errorf("Expected compareVersions(%q, %q) to have the sign of %d, got %d", tc.a, tc.b, tc.sign, c)
+		}
+	}
+}
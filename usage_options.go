@@ -0,0 +1,141 @@
+package cobra
+
+import (
+	"strconv"
+	"strings"
+
+	flag "github.com/spf13/pflag"
+)
+
+// UseLineOptions configures UseLineWithOptions.
+type UseLineOptions struct {
+	// MaxWidth wraps the use line to MaxWidth columns, aligning the continuation lines
+	// after the command path. 0 doesn't wrap.
+	MaxWidth int
+	// HideParents leaves out the path of the parents of the command.
+	HideParents bool
+	// HideFlags leaves out the "[flags]" placeholder, like DisableFlagsInUseLine.
+	HideFlags bool
+}
+
+// UseLineWithOptions returns the use line of c like UseLine, formatted according to
+// opts, e.g. for custom help systems.
+func (c *Command) UseLineWithOptions(opts UseLineOptions) string {
+	path := c.Name()
+	if c.HasParent() && !opts.HideParents {
+		path = c.parent.CommandPath() + " " + path
+	}
+	var rest string
+	if fields := strings.Fields(c.Use); len(fields) > 1 {
+		rest = strings.Join(fields[1:], " ")
+	}
+	if !opts.HideFlags && !c.DisableFlagsInUseLine && c.HasAvailableFlags() && !strings.Contains(rest, "[flags]") {
+		rest = strings.TrimSpace(rest + " [flags]")
+	}
+	if rest == "" {
+		return path
+	}
+	if opts.MaxWidth <= 0 {
+		return path + " " + rest
+	}
+	indent := displayWidth(path) + 1
+	lines := strings.Split(wrap(opts.MaxWidth-indent, rest), "\n")
+	return path + " " + strings.Join(lines, "\n"+strings.Repeat(" ", indent))
+}
+
+// FlagUsagesOptions configures FlagUsagesWithOptions and FlagUsages.
+type FlagUsagesOptions struct {
+	// MaxWidth wraps the usages to MaxWidth columns. 0 doesn't wrap.
+	MaxWidth int
+	// HideDefaults leaves out the default values, e.g. `(default "info")`.
+	HideDefaults bool
+	// HideTypes leaves out the value names of the flags, e.g. "string" in
+	// "--name string".
+	HideTypes bool
+	// HideInherited leaves out the flags inherited from the parents of the command. It
+	// is ignored by FlagUsages.
+	HideInherited bool
+}
+
+// FlagUsagesWithOptions returns the usages of the local and inherited flags of c like
+// LocalFlagUsages and InheritedFlagUsages, merged and formatted according to opts, so
+// that custom help systems reuse the formatting of cobra rather than reimplementing it.
+func (c *Command) FlagUsagesWithOptions(opts FlagUsagesOptions) string {
+	flags := flag.NewFlagSet(c.Name(), flag.ContinueOnError)
+	c.DisplayFlags(c.LocalFlags()).VisitAll(flags.AddFlag)
+	if !opts.HideInherited {
+		c.DisplayFlags(c.InheritedFlags()).VisitAll(flags.AddFlag)
+	}
+	return FlagUsages(flags, opts)
+}
+
+// FlagUsages returns the usages of flags like their FlagUsages method, formatted
+// according to opts.
+func FlagUsages(flags *flag.FlagSet, opts FlagUsagesOptions) string {
+	if !opts.HideDefaults && !opts.HideTypes {
+		return flags.FlagUsagesWrapped(opts.MaxWidth)
+	}
+	formatted := flag.NewFlagSet("usages", flag.ContinueOnError)
+	formatted.SortFlags = flags.SortFlags
+	flags.VisitAll(func(f *flag.Flag) {
+		formatted.AddFlag(usageFlag(f, opts))
+	})
+	return formatted.FlagUsagesWrapped(opts.MaxWidth)
+}
+
+// usageValue is the value of the copies of flags made by usageFlag: pflag renders no
+// value name for the type "bool" and no default value for an empty string.
+type usageValue struct {
+	flag.Value
+	typ string
+	def string
+}
+
+func (v *usageValue) Type() string   { return v.typ }
+func (v *usageValue) String() string { return v.def }
+
+// usageFlag returns a copy of f rendered by pflag without its default value or its
+// value name, according to opts.
+func usageFlag(f *flag.Flag, opts FlagUsagesOptions) *flag.Flag {
+	nf := *f
+	v := &usageValue{Value: f.Value, typ: f.Value.Type()}
+	if !opts.HideDefaults && !defaultIsZeroValue(f) {
+		v.def = f.DefValue
+		if v.typ == "string" && opts.HideTypes {
+			// pflag quotes the default values of the strings only.
+			v.def = strconv.Quote(v.def)
+		}
+	}
+	if opts.HideTypes {
+		v.typ = "bool"
+		// A back-quoted word of the usage names the value.
+		nf.Usage = strings.Replace(f.Usage, "`", "", -1)
+	}
+	nf.Value = v
+	nf.DefValue = v.def
+	return &nf
+}
+
+// defaultIsZeroValue returns true if the default value of f is the zero value of its
+// type, which pflag doesn't render.
+func defaultIsZeroValue(f *flag.Flag) bool {
+	switch f.Value.Type() {
+	case "bool":
+		return f.DefValue == "false"
+	case "duration":
+		return f.DefValue == "0" || f.DefValue == "0s"
+	case "int", "int8", "int16", "int32", "int64", "uint", "uint8", "uint16", "uint32", "uint64", "count", "float32", "float64":
+		return f.DefValue == "0"
+	case "string":
+		return f.DefValue == ""
+	case "ip", "ipMask", "ipNet":
+		return f.DefValue == "<nil>"
+	case "intSlice", "stringSlice", "stringArray":
+		return f.DefValue == "[]"
+	}
+	switch f.DefValue {
+	case "false", "<nil>", "", "0":
+		return true
+	}
+	return false
+}
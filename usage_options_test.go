@@ -0,0 +1,57 @@
+package cobra
+
+import (
+	"testing"
+	"time"
+)
+
+func TestUseLineWithOptions(t *testing.T) {
+	rootCmd := &Command{Use: "root", Run: emptyRun}
+	deployCmd := &Command{Use: "deploy <service> [--env ENV] [--replicas N] [--wait]", Run: emptyRun}
+	deployCmd.Flags().String("env", "", "environment")
+	rootCmd.AddCommand(deployCmd)
+
+	tests := []struct {
+		opts     UseLineOptions
+		expected string
+	}{
+		{UseLineOptions{}, deployCmd.UseLine()},
+		{UseLineOptions{HideParents: true, HideFlags: true}, "deploy <service> [--env ENV] [--replicas N] [--wait]"},
+		{UseLineOptions{MaxWidth: 40}, "root deploy <service> [--env ENV]\n            [--replicas N] [--wait]\n            [flags]"},
+	}
+	for _, tc := range tests {
+		if got := deployCmd.UseLineWithOptions(tc.opts); got != tc.expected {
+			t.Errorf("Expected use line %q with %+v, got %q", tc.expected, tc.opts, got)
+		}
+	}
+	if got := rootCmd.UseLineWithOptions(UseLineOptions{}); got != "root" {
+		t.Errorf("Expected use line %q, got %q", "root", got)
+	}
+}
+
+func TestFlagUsagesWithOptions(t *testing.T) {
+	rootCmd := &Command{Use: "root", Run: emptyRun}
+	rootCmd.PersistentFlags().String("region", "ca-east", "the `region` to use")
+	childCmd := &Command{Use: "child", Run: emptyRun}
+	childCmd.Flags().Duration("timeout", time.Minute, "timeout")
+	childCmd.Flags().BoolP("force", "f", false, "force")
+	childCmd.Flags().Int("retries", 0, "retries")
+	rootCmd.AddCommand(childCmd)
+
+	output := childCmd.FlagUsagesWithOptions(FlagUsagesOptions{})
+	checkStringContains(t, output, "  -f, --force              force\n")
+	checkStringContains(t, output, `--region region      the region to use (default "ca-east")`)
+	checkStringContains(t, output, "--timeout duration   timeout (default 1m0s)")
+
+	output = childCmd.FlagUsagesWithOptions(FlagUsagesOptions{HideTypes: true, HideInherited: true})
+	checkStringContains(t, output, "--timeout   timeout (default 1m0s)")
+	checkStringContains(t, output, "--retries   retries\n")
+	checkStringOmits(t, output, "region")
+
+	output = childCmd.FlagUsagesWithOptions(FlagUsagesOptions{HideDefaults: true})
+	checkStringContains(t, output, "--timeout duration   timeout\n")
+	checkStringContains(t, output, "--region region      the region to use\n")
+
+	output = FlagUsages(rootCmd.PersistentFlags(), FlagUsagesOptions{HideTypes: true})
+	checkStringContains(t, output, `--region   the region to use (default "ca-east")`)
+}
@@ -0,0 +1,49 @@
+package cobra
+
+import "fmt"
+
+// SetVisibilityFunc sets a function deciding at run time whether the subcommands of
+// this command are visible, e.g. according to the role or the license of the user.
+// The commands it rejects, and their subcommands, are left out of the help, the
+// documentation, the suggestions and the completion, and can't be executed. It
+// applies to the whole subtree, unless a subcommand sets its own.
+func (c *Command) SetVisibilityFunc(f func(cmd *Command) bool) {
+	c.visibilityFunc = f
+}
+
+// IsVisible returns true unless the command or one of its parents is rejected by the
+// function set with SetVisibilityFunc.
+func (c *Command) IsVisible() bool {
+	return c.invisibleAncestor() == nil
+}
+
+// invisibleAncestor returns the topmost of c and its parents rejected by the function
+// set with SetVisibilityFunc on its parents, or nil.
+func (c *Command) invisibleAncestor() *Command {
+	var invisible *Command
+	for p := c; p.HasParent(); p = p.parent {
+		if visible := p.parent.findVisibilityFunc(); visible != nil && !visible(p) {
+			invisible = p
+		}
+	}
+	return invisible
+}
+
+func (c *Command) findVisibilityFunc() func(*Command) bool {
+	for p := c; p != nil; p = p.parent {
+		if p.visibilityFunc != nil {
+			return p.visibilityFunc
+		}
+	}
+	return nil
+}
+
+// checkVisible returns an unknown command error, and the parent of the command to
+// report it on, if cmd is not visible.
+func checkVisible(cmd *Command) (*Command, error) {
+	invisible := cmd.invisibleAncestor()
+	if invisible == nil {
+		return cmd, nil
+	}
+	return invisible.parent, fmt.Errorf("unknown command %q for %q", invisible.Name(), invisible.parent.CommandPath())
+}
@@ -0,0 +1,66 @@
+package cobra
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func newVisibilityTree(role *string) *Command {
+	rootCmd := &Command{Use: "root", Run: emptyRun}
+	rootCmd.SetVisibilityFunc(func(cmd *Command) bool {
+		return cmd.Annotations["role"] == "" || cmd.Annotations["role"] == *role
+	})
+	adminCmd := &Command{Use: "admin", Short: "administer the users", Annotations: map[string]string{"role": "admin"}}
+	adminCmd.AddCommand(&Command{Use: "users", Short: "list the users", Run: emptyRun})
+	rootCmd.AddCommand(adminCmd, &Command{Use: "status", Short: "show the status", Run: emptyRun})
+	return rootCmd
+}
+
+func TestVisibilityFunc(t *testing.T) {
+	role := "user"
+	rootCmd := newVisibilityTree(&role)
+
+	output, err := executeCommand(rootCmd, "--help")
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	checkStringContains(t, output, "status")
+	checkStringOmits(t, output, "admin")
+
+	_, err = executeCommand(rootCmd, "admin", "users")
+	if err == nil || err.Error() != `unknown command "admin" for "root"` {
+		t.Errorf("Expected an unknown command error, got %v", err)
+	}
+
+	output, _ = executeCommand(rootCmd, "help", "admin")
+	checkStringContains(t, output, "Unknown help topic")
+
+	if suggestions := rootCmd.SuggestionsFor("admn"); len(suggestions) != 0 {
+		t.Errorf("Expected no suggestions, got %v", suggestions)
+	}
+
+	for name, gen := range map[string]func(*bytes.Buffer) error{
+		"bash":       func(b *bytes.Buffer) error { return rootCmd.GenBashCompletion(b) },
+		"zsh":        func(b *bytes.Buffer) error { return rootCmd.GenZshCompletion(b) },
+		"powershell": func(b *bytes.Buffer) error { return rootCmd.GenPowerShellCompletion(b) },
+	} {
+		buf := new(bytes.Buffer)
+		if err := gen(buf); err != nil {
+			t.Fatal(err)
+		}
+		if strings.Contains(buf.String(), "admin") {
+			t.Errorf("Expected the %s completion to omit the admin commands", name)
+		}
+	}
+
+	role = "admin"
+	output, err = executeCommand(rootCmd, "--help")
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	checkStringContains(t, output, "admin")
+	if _, err := executeCommand(rootCmd, "admin", "users"); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
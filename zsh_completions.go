@@ -4,7 +4,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"os"
+	"path/filepath"
 	"sort"
 	"strings"
 	"text/template"
@@ -16,44 +18,48 @@ const (
 	zshCompArgumentAnnotation   = "cobra_annotations_zsh_completion_argument_annotation"
 	zshCompArgumentFilenameComp = "cobra_annotations_zsh_completion_argument_file_completion"
 	zshCompArgumentWordComp     = "cobra_annotations_zsh_completion_argument_word_completion"
-	zshCompDirname              = "cobra_annotations_zsh_dirname"
+	zshCompArgumentDirnameComp  = "cobra_annotations_zsh_completion_argument_dirname_completion"
 )
 
 var (
 	zshCompFuncMap = template.FuncMap{
 		"genZshFuncName":              zshCompGenFuncName,
+		"zshCompletable":              zshCompCompletable,
 		"extractFlags":                zshCompExtractFlag,
 		"genFlagEntryForZshArguments": zshCompGenFlagEntryForArguments,
 		"extractArgsCompletions":      zshCompExtractArgumentCompletionHintsForRendering,
+		"escapeColons":                zshCompEscapeColons,
+		"snippet":                     zshCompSnippet,
+		"recentValuesFunc":            zshCompRecentValuesFunc,
 	}
 	zshCompletionText = `
 {{/* should accept Command (that contains subcommands) as parameter */}}
 {{define "argumentsC" -}}
 {{ $cmdPath := genZshFuncName .}}
 function {{$cmdPath}} {
-  local -a commands
+{{snippet .}}  local -a commands
 
   _arguments -C \{{- range extractFlags .}}
-    {{genFlagEntryForZshArguments .}} \{{- end}}
+    {{genFlagEntryForZshArguments $ .}} \{{- end}}
     "1: :->cmnds" \
     "*::arg:->args"
 
   case $state in
   cmnds)
-    commands=({{range .Commands}}{{if not .Hidden}}
-      "{{.Name}}:{{.Short}}"{{end}}{{end}}
+    commands=({{range .Commands}}{{if zshCompletable .}}
+      "{{escapeColons .Name}}:{{.Short}}"{{end}}{{end}}
     )
     _describe "command" commands
     ;;
   esac
 
-  case "$words[1]" in {{- range .Commands}}{{if not .Hidden}}
+  case "$words[1]" in {{- range .Commands}}{{if zshCompletable .}}
   {{.Name}})
     {{$cmdPath}}_{{.Name}}
     ;;{{end}}{{end}}
   esac
 }
-{{range .Commands}}{{if not .Hidden}}
+{{range .Commands}}{{if zshCompletable .}}
 {{template "selectCmdTemplate" .}}
 {{- end}}{{end}}
 {{- end}}
@@ -61,8 +67,8 @@ function {{$cmdPath}} {
 {{/* should accept Command without subcommands as parameter */}}
 {{define "arguments" -}}
 function {{genZshFuncName .}} {
-{{"  _arguments"}}{{range extractFlags .}} \
-    {{genFlagEntryForZshArguments . -}}
+{{snippet .}}{{"  _arguments"}}{{range extractFlags .}} \
+    {{genFlagEntryForZshArguments $ . -}}
 {{end}}{{range extractArgsCompletions .}} \
     {{.}}{{end}}
 }
@@ -70,7 +76,7 @@ function {{genZshFuncName .}} {
 
 {{/* dispatcher for commands with or without subcommands */}}
 {{define "selectCmdTemplate" -}}
-{{if .Hidden}}{{/* ignore hidden*/}}{{else -}}
+{{if not (zshCompletable .)}}{{/* ignore hidden*/}}{{else -}}
 {{if .Commands}}{{template "argumentsC" .}}{{else}}{{template "arguments" .}}{{end}}
 {{- end}}
 {{- end}}
@@ -78,8 +84,23 @@ function {{genZshFuncName .}} {
 {{/* template entry point */}}
 {{define "Main" -}}
 #compdef _{{.Name}} {{.Name}}
+{{recentValuesFunc .}}
+{{template "selectCmdTemplate" .}}
+{{end}}
 
+{{/* entry point of autoloadable completion functions */}}
+{{define "Autoload" -}}
+#compdef {{.Name}}
+
+# zsh completion for {{.Name}}
+#
+# Install this file as _{{.Name}} in a directory of your $fpath, before compinit
+# is called in ~/.zshrc, e.g. in ~/.zsh/completions with:
+#   fpath=(~/.zsh/completions $fpath)
+#   autoload -U compinit && compinit
+{{recentValuesFunc .}}
 {{template "selectCmdTemplate" .}}
+{{genZshFuncName .}} "$@"
 {{end}}
 `
 )
@@ -119,6 +140,46 @@ func (c *Command) GenZshCompletion(w io.Writer) error {
 	return tmpl.Execute(w, c.Root())
 }
 
+// GenZshCompletionAutoloadFile generates an autoloadable zsh completion file.
+func (c *Command) GenZshCompletionAutoloadFile(filename string) error {
+	outFile, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer outFile.Close()
+
+	return c.GenZshCompletionAutoload(outFile)
+}
+
+// GenZshCompletionAutoload generates an autoloadable zsh completion function and
+// writes to the passed writer. Unlike the output of GenZshCompletion, it has a
+// proper #compdef header and needs no compdef call: it is meant to be installed
+// as _<root name> in a directory of $fpath.
+func (c *Command) GenZshCompletionAutoload(w io.Writer) error {
+	tmpl, err := template.New("Main").Funcs(zshCompFuncMap).Parse(zshCompletionText)
+	if err != nil {
+		return fmt.Errorf("error creating zsh completion template: %v", err)
+	}
+	return tmpl.ExecuteTemplate(w, "Autoload", c.Root())
+}
+
+// GenZshCompletionOhMyZshPlugin generates an oh-my-zsh custom plugin in the
+// <dir>/<root name> directory, usually with dir being $ZSH_CUSTOM/plugins. The plugin
+// is enabled by adding the root name to the plugins of ~/.zshrc.
+func (c *Command) GenZshCompletionOhMyZshPlugin(dir string) error {
+	name := c.Root().Name()
+	pluginDir := filepath.Join(dir, name)
+	if err := os.MkdirAll(pluginDir, 0755); err != nil {
+		return err
+	}
+	plugin := fmt.Sprintf("# oh-my-zsh plugin for %[1]s, enable it with plugins=(... %[1]s) in ~/.zshrc.\n"+
+		"# oh-my-zsh adds this directory to $fpath, where the _%[1]s completion function is.\n", name)
+	if err := ioutil.WriteFile(filepath.Join(pluginDir, name+".plugin.zsh"), []byte(plugin), 0644); err != nil {
+		return err
+	}
+	return c.GenZshCompletionAutoloadFile(filepath.Join(pluginDir, "_"+name))
+}
+
 // MarkZshCompPositionalArgumentFile marks the specified argument (first
 // argument is 1) as completed by file selection. patterns (e.g. "*.txt") are
 // optional - if not provided the completion will search for all files.
@@ -165,6 +226,27 @@ func (c *Command) MarkZshCompPositionalArgumentWords(argPosition int, words ...s
 	return c.zshCompSetArgsAnnotations(annotation)
 }
 
+// MarkPositionalArgumentDirname marks the specified positional argument (first
+// argument is 1) as completed by directory selection.
+//
+// Shell Completion compatibility matrix: bash, zsh
+func (c *Command) MarkPositionalArgumentDirname(argPosition int) error {
+	if argPosition < 1 {
+		return fmt.Errorf("Invalid argument position (%d)", argPosition)
+	}
+	annotation, err := c.zshCompGetArgsAnnotations()
+	if err != nil {
+		return err
+	}
+	if c.zshcompArgsAnnotationnIsDuplicatePosition(annotation, argPosition) {
+		return fmt.Errorf("Duplicate annotation for positional argument at index %d", argPosition)
+	}
+	annotation[argPosition] = zshCompArgHint{
+		Tipe: zshCompArgumentDirnameComp,
+	}
+	return c.zshCompSetArgsAnnotations(annotation)
+}
+
 func zshCompExtractArgumentCompletionHintsForRendering(c *Command) ([]string, error) {
 	var result []string
 	annotation, err := c.zshCompGetArgsAnnotations()
@@ -208,6 +290,8 @@ func zshCompRenderZshCompArgHint(i int, z zshCompArgHint) (string, error) {
 			words = append(words, fmt.Sprintf("%q", w))
 		}
 		return fmt.Sprintf(`'%d: :(%s)'`, i, strings.Join(words, " ")), nil
+	case zshCompArgumentDirnameComp:
+		return fmt.Sprintf(`'%d: :_files -/'`, i), nil
 	default:
 		return "", fmt.Errorf("Invalid zsh argument completion annotation: %s", t)
 	}
@@ -243,6 +327,10 @@ func (c *Command) zshCompSetArgsAnnotations(annotation zshCompArgsAnnotation) er
 	return nil
 }
 
+func zshCompSnippet(c *Command) string {
+	return completionSnippet(c, ZshCompSnippet, "  ")
+}
+
 func zshCompGenFuncName(c *Command) string {
 	if c.HasParent() {
 		return zshCompGenFuncName(c.Parent()) + "_" + c.Name()
@@ -250,15 +338,22 @@ func zshCompGenFuncName(c *Command) string {
 	return "_" + c.Name()
 }
 
+// zshCompCompletable returns true if c is offered by the completion: it is not hidden,
+// and neither hidden by a visibility function nor gated by a disabled feature.
+func zshCompCompletable(c *Command) bool {
+	return !c.Hidden && c.IsVisible() && checkFeature(c) == nil
+}
+
 func zshCompExtractFlag(c *Command) []*pflag.Flag {
+	c.InitDefaultYesFlag()
 	var flags []*pflag.Flag
 	c.LocalFlags().VisitAll(func(f *pflag.Flag) {
-		if !f.Hidden {
+		if !f.Hidden && !c.flagFeatureDisabled(f) {
 			flags = append(flags, f)
 		}
 	})
 	c.InheritedFlags().VisitAll(func(f *pflag.Flag) {
-		if !f.Hidden {
+		if !f.Hidden && !c.flagFeatureDisabled(f) {
 			flags = append(flags, f)
 		}
 	})
@@ -267,54 +362,85 @@ func zshCompExtractFlag(c *Command) []*pflag.Flag {
 
 // zshCompGenFlagEntryForArguments returns an entry that matches _arguments
 // zsh-completion parameters. It's too complicated to generate in a template.
-func zshCompGenFlagEntryForArguments(f *pflag.Flag) string {
+func zshCompGenFlagEntryForArguments(c *Command, f *pflag.Flag) string {
+	// The flags mutually exclusive with f are not offered once f is set.
+	var exclusions []string
+	for _, name := range c.mutuallyExclusiveFlags(f.Name) {
+		exclusions = append(exclusions, "--"+name)
+		if other := c.Flags().Lookup(name); other != nil && other.Shorthand != "" {
+			exclusions = append(exclusions, "-"+other.Shorthand)
+		}
+	}
 	if f.Name == "" || f.Shorthand == "" {
-		return zshCompGenFlagEntryForSingleOptionFlag(f)
+		return zshCompGenFlagEntryForSingleOptionFlag(c, f, exclusions)
 	}
-	return zshCompGenFlagEntryForMultiOptionFlag(f)
+	return zshCompGenFlagEntryForMultiOptionFlag(c, f, exclusions)
 }
 
-func zshCompGenFlagEntryForSingleOptionFlag(f *pflag.Flag) string {
-	var option, multiMark, extras string
+func zshCompGenFlagEntryForSingleOptionFlag(c *Command, f *pflag.Flag, exclusions []string) string {
+	var option, multiMark, extras, excluded string
 
 	if zshCompFlagCouldBeSpecifiedMoreThenOnce(f) {
 		multiMark = "*"
 	}
+	if len(exclusions) > 0 {
+		excluded = "(" + strings.Join(exclusions, " ") + ")"
+	}
 
 	option = "--" + f.Name
 	if option == "--" {
 		option = "-" + f.Shorthand
 	}
-	extras = zshCompGenFlagEntryExtras(f)
+	option += zshCompOptionSuffix(f)
+	extras = zshCompGenFlagEntryExtras(c, f)
 
-	return fmt.Sprintf(`'%s%s[%s]%s'`, multiMark, option, zshCompQuoteFlagDescription(f.Usage), extras)
+	return fmt.Sprintf(`'%s%s%s[%s]%s'`, excluded, multiMark, option, zshCompQuoteFlagDescription(f.Usage), extras)
 }
 
-func zshCompGenFlagEntryForMultiOptionFlag(f *pflag.Flag) string {
-	var options, parenMultiMark, curlyMultiMark, extras string
+func zshCompGenFlagEntryForMultiOptionFlag(c *Command, f *pflag.Flag, exclusions []string) string {
+	var options, parenMultiMark, curlyMultiMark, extras, excluded string
 
 	if zshCompFlagCouldBeSpecifiedMoreThenOnce(f) {
 		parenMultiMark = "*"
 		curlyMultiMark = "\\*"
 	}
+	if len(exclusions) > 0 {
+		excluded = " " + strings.Join(exclusions, " ")
+	}
 
-	options = fmt.Sprintf(`'(%s-%s %s--%s)'{%s-%s,%s--%s}`,
-		parenMultiMark, f.Shorthand, parenMultiMark, f.Name, curlyMultiMark, f.Shorthand, curlyMultiMark, f.Name)
-	extras = zshCompGenFlagEntryExtras(f)
+	suffix := zshCompOptionSuffix(f)
+	options = fmt.Sprintf(`'(%s-%s %s--%s%s)'{%s-%s%s,%s--%s%s}`,
+		parenMultiMark, f.Shorthand, parenMultiMark, f.Name, excluded, curlyMultiMark, f.Shorthand, suffix, curlyMultiMark, f.Name, suffix)
+	extras = zshCompGenFlagEntryExtras(c, f)
 
 	return fmt.Sprintf(`%s'[%s]%s'`, options, zshCompQuoteFlagDescription(f.Usage), extras)
 }
 
-func zshCompGenFlagEntryExtras(f *pflag.Flag) string {
-	if f.NoOptDefVal != "" {
+// zshCompOptionSuffix returns the suffix of the options of a flag with an optional
+// value, which _arguments then only completes in the same word, after a "=".
+func zshCompOptionSuffix(f *pflag.Flag) string {
+	if hasOptionalValue(f) {
+		return "=-"
+	}
+	return ""
+}
+
+func zshCompGenFlagEntryExtras(c *Command, f *pflag.Flag) string {
+	if f.NoOptDefVal != "" && !hasOptionalValue(f) {
 		return ""
 	}
 
 	extras := ":" // allow options for flag (even without assistance)
+	if action, ok := zshCompKindActions[flagCompletionKind(f)]; ok {
+		extras = action
+	}
 	for key, values := range f.Annotations {
 		switch key {
-		case zshCompDirname:
-			extras = fmt.Sprintf(":filename:_files -g %q", values[0])
+		case BashCompSubdirsInDir:
+			extras = `:filename:_files -g "-(/)"`
+			if len(values) == 1 {
+				extras = fmt.Sprintf(`:filename:_files -W %q -g "-(/)"`, values[0])
+			}
 		case BashCompFilenameExt:
 			extras = ":filename:_files"
 			for _, pattern := range values {
@@ -322,15 +448,92 @@ func zshCompGenFlagEntryExtras(f *pflag.Flag) string {
 			}
 		}
 	}
+	if _, ok := f.Annotations[FlagRememberRecent]; ok {
+		extras = zshCompAddRecentValues(c, f, extras)
+	}
+	if hasOptionalValue(f) {
+		// the value is optional
+		extras = ":" + extras
+	}
 
 	return extras
 }
 
+// zshCompKindActions are the _arguments actions completing the kinds of values.
+var zshCompKindActions = map[CompletionKind]string{
+	CompleteHosts:           ":host:_hosts",
+	CompleteURLsFromHistory: ":url:_urls",
+	CompleteEnvVars:         ":variable:_parameters -g \"*export*\"",
+	CompleteUsers:           ":user:_users",
+	CompleteGroups:          ":group:_groups",
+	CompleteDurations:       `:duration:((30s\:"30 seconds" 1m\:"1 minute" 5m\:"5 minutes" 15m\:"15 minutes" 1h\:"1 hour" 24h\:"1 day"))`,
+	CompleteTimestamps:      `:time:((now\:"current time" today\:"start of today"))`,
+}
+
+// zshCompAddRecentValues adds the recent values of the flag to the _arguments action
+// extras completing its values. The literal lists of values, like the ones of
+// CompleteDurations, cannot be combined with other completions and are kept alone.
+func zshCompAddRecentValues(c *Command, f *pflag.Flag, extras string) string {
+	recent := fmt.Sprintf("__%s_recent_values %s", c.Root().Name(), f.Name)
+	if extras == ":" {
+		return ":value:" + recent
+	}
+	parts := strings.SplitN(extras, ":", 3)
+	if len(parts) != 3 || strings.HasPrefix(parts[2], "(") {
+		return extras
+	}
+	return fmt.Sprintf(":%s:{%s; %s}", parts[1], recent, parts[2])
+}
+
+// zshCompRecentValuesFunc returns the function offering the recent values of a flag,
+// marked "(recent)", if a flag of the tree of c remembers its values.
+func zshCompRecentValuesFunc(c *Command) string {
+	if !hasRecentFlags(c) {
+		return ""
+	}
+	return fmt.Sprintf(`
+function __%[1]s_recent_values {
+  local dir=${XDG_CACHE_HOME:-$HOME/.cache}
+  [[ $OSTYPE == darwin* ]] && dir=$HOME/Library/Caches
+  local -a recent
+  recent=(${(f)"$(cat "$dir/%[1]s/recent/$1" 2>/dev/null)"})
+  recent=(${^${recent//:/\\:}}":(recent)")
+  _describe -t recent-values "recent values" recent
+}
+`, c.Name())
+}
+
+// hasRecentFlags returns true if a flag of c or its descendants is annotated with
+// FlagRememberRecent.
+func hasRecentFlags(c *Command) bool {
+	found := false
+	visit := func(f *pflag.Flag) {
+		if _, ok := f.Annotations[FlagRememberRecent]; ok {
+			found = true
+		}
+	}
+	c.Flags().VisitAll(visit)
+	c.PersistentFlags().VisitAll(visit)
+	for _, sub := range c.Commands() {
+		if found || hasRecentFlags(sub) {
+			return true
+		}
+	}
+	return found
+}
+
 func zshCompFlagCouldBeSpecifiedMoreThenOnce(f *pflag.Flag) bool {
-	return strings.Contains(f.Value.Type(), "Slice") ||
+	return len(f.Annotations[FlagRepeatable]) > 0 ||
+		strings.Contains(f.Value.Type(), "Slice") ||
 		strings.Contains(f.Value.Type(), "Array")
 }
 
 func zshCompQuoteFlagDescription(s string) string {
 	return strings.Replace(s, "'", `'\''`, -1)
 }
+
+// zshCompEscapeColons escapes the colons of a _describe candidate, where an
+// unescaped colon separates the candidate from its description.
+func zshCompEscapeColons(s string) string {
+	return strings.Replace(s, ":", `\:`, -1)
+}
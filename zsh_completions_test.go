@@ -2,6 +2,9 @@ package cobra
 
 import (
 	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"regexp"
 	"strings"
 	"testing"
@@ -100,16 +103,57 @@ func TestGenZshCompletion(t *testing.T) {
 				`:_files -g "\*.log" -g "\*.txt"`,
 			},
 		},
+		{
+			name: "flags with optional values",
+			root: func() *Command {
+				r := &Command{
+					Use: "mycmd",
+					Run: emptyRun,
+				}
+				r.Flags().String("color", "", "colorize")
+				r.Flags().Lookup("color").NoOptDefVal = "auto"
+				r.Flags().StringP("format", "f", "", "output format")
+				r.Flags().Lookup("format").NoOptDefVal = "json"
+				r.Flags().Bool("quiet", false, "quiet")
+				return r
+			}(),
+			expectedExpressions: []string{
+				`'--color=-\[colorize]::'`,
+				`'\(-f --format\)'{-f=-,--format=-}'\[output format]::'`,
+				`'--quiet\[quiet]'`,
+			},
+		},
+		{
+			name: "flags with completion kinds",
+			root: func() *Command {
+				r := &Command{
+					Use: "mycmd",
+					Run: emptyRun,
+				}
+				r.Flags().String("host", "", "remote host")
+				r.MarkFlagCompletionKind("host", CompleteHosts)
+				r.Flags().String("env", "", "variable")
+				r.MarkFlagCompletionKind("env", CompleteEnvVars)
+				return r
+			}(),
+			expectedExpressions: []string{
+				`'--host\[remote host]:host:_hosts'`,
+				`'--env\[variable]:variable:_parameters -g "\*export\*"'`,
+			},
+		},
 		{
 			name: "repeated variables both with and without value",
 			root: func() *Command {
 				r := genTestCommand("mycmd", true)
 				_ = r.Flags().BoolSliceP("debug", "d", []bool{}, "debug usage")
 				_ = r.Flags().StringArray("option", []string{}, "options")
+				_ = r.Flags().String("set", "", "set a value")
+				_ = r.MarkFlagRepeatable("set")
 				return r
 			}(),
 			expectedExpressions: []string{
 				`'\*--option\[options]`,
+				`'\*--set\[set a value]`,
 				`'\(\*-d \*--debug\)'{\\\*-d,\\\*--debug}`,
 			},
 		},
@@ -214,19 +258,33 @@ func TestGenZshCompletion(t *testing.T) {
 				`'1: :\("word1" "word2"\)' \\`,
 			},
 		},
+		{
+			name: "colons in command names are escaped for _describe",
+			root: func() *Command {
+				r := genTestCommand("root", false)
+				r.AddCommand(genTestCommand("cmd:colon", true))
+				return r
+			}(),
+			expectedExpressions: []string{
+				`"cmd\\:colon:`,
+			},
+		},
 		{
 			name: "directory completion for flag",
 			root: func() *Command {
 				r := genTestCommand("root", true)
 				r.Flags().String("test", "", "test")
 				r.PersistentFlags().String("ptest", "", "ptest")
+				r.Flags().String("theme", "", "theme")
 				r.MarkFlagDirname("test")
 				r.MarkPersistentFlagDirname("ptest")
+				r.MarkFlagSubdirsInDir("theme", "themes")
 				return r
 			}(),
 			expectedExpressions: []string{
 				`--test\[test]:filename:_files -g "-\(/\)"`,
 				`--ptest\[ptest]:filename:_files -g "-\(/\)"`,
+				`--theme\[theme]:filename:_files -W "themes" -g "-\(/\)"`,
 			},
 		},
 	}
@@ -350,6 +408,28 @@ func TestMarkZshCompPositionalArgumentFile(t *testing.T) {
 	})
 }
 
+func TestMarkPositionalArgumentDirname(t *testing.T) {
+	c := &Command{Use: "c", Run: emptyRun}
+	if err := c.MarkPositionalArgumentDirname(2); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.MarkPositionalArgumentDirname(2); err == nil {
+		t.Error("Didn't receive an error when trying to overwrite argument position")
+	}
+
+	buf := new(bytes.Buffer)
+	if err := c.GenZshCompletion(buf); err != nil {
+		t.Fatal(err)
+	}
+	checkStringContains(t, buf.String(), `'2: :_files -/'`)
+
+	buf.Reset()
+	if err := c.GenBashCompletion(buf); err != nil {
+		t.Fatal(err)
+	}
+	checkStringContains(t, buf.String(), "    dirname_args=()\n    dirname_args+=(2)\n")
+}
+
 func TestMarkZshCompPositionalArgumentWords(t *testing.T) {
 	t.Run("Doesn't allow overwriting existing positional argument", func(t *testing.T) {
 		c := &Command{}
@@ -473,3 +553,54 @@ func genTestCommand(name string, withRun bool) *Command {
 
 	return r
 }
+
+func TestGenZshCompletionAutoload(t *testing.T) {
+	r := &Command{Use: "mycommand", Run: emptyRun}
+	r.AddCommand(&Command{Use: "sub", Short: "sub command", Run: emptyRun})
+
+	buf := new(bytes.Buffer)
+	if err := r.GenZshCompletionAutoload(buf); err != nil {
+		t.Fatal(err)
+	}
+	output := buf.String()
+	if !strings.HasPrefix(output, "#compdef mycommand\n") {
+		t.Errorf("Expected #compdef header, got:\n%s", output)
+	}
+	checkStringContains(t, output, "function _mycommand_sub {")
+	if !strings.HasSuffix(output, "\n_mycommand \"$@\"\n") {
+		t.Errorf("Expected a call of the completion function at the end, got:\n%s", output)
+	}
+}
+
+func TestGenZshCompletionOhMyZshPlugin(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cobra-zsh")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	r := &Command{Use: "mycommand", Run: emptyRun}
+	if err := r.GenZshCompletionOhMyZshPlugin(dir); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"mycommand.plugin.zsh", "_mycommand"} {
+		if _, err := os.Stat(filepath.Join(dir, "mycommand", name)); err != nil {
+			t.Errorf("Expected plugin file %s: %v", name, err)
+		}
+	}
+}
+
+func TestGenZshCompletionSnippet(t *testing.T) {
+	r := &Command{Use: "mycommand", Run: emptyRun}
+	r.AddCommand(&Command{
+		Use:         "get",
+		Run:         emptyRun,
+		Annotations: map[string]string{ZshCompSnippet: "_message 'resource name'"},
+	})
+
+	buf := new(bytes.Buffer)
+	if err := r.GenZshCompletion(buf); err != nil {
+		t.Fatal(err)
+	}
+	checkStringContains(t, buf.String(), "function _mycommand_get {\n  _message 'resource name'\n  _arguments")
+}